@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"task_tracker/src/auth"
+	"task_tracker/src/entities"
+	"task_tracker/src/passport"
+	"task_tracker/src/repository"
+)
+
+// runCreateAdminCommand creates an admin with a password directly in the
+// database. Only admins can create users through the API, so the first one
+// is created this way.
+func runCreateAdminCommand(ctx context.Context, repo repository.UserRepository, args []string) error {
+	if len(args) != 2 {
+		return fmt.Errorf(`usage: create-admin "<passportSerie> <passportNumber>" <password>`)
+	}
+
+	passport_serie, passport_number, err := passport.Parse(args[0])
+	if err != nil {
+		return fmt.Errorf("invalid passport: %w", err)
+	}
+	password_hash, err := auth.HashPassword(args[1])
+	if err != nil {
+		return err
+	}
+
+	var user_id int
+	err = repo.WithTx(ctx, func(ctx context.Context) error {
+		user_id, err = repo.CreateUser(ctx, entities.User{PassportSerie: passport_serie, PassportNumber: passport_number})
+		if err != nil {
+			return err
+		}
+		if err := repo.SetUserPassword(ctx, user_id, password_hash); err != nil {
+			return err
+		}
+		return repo.SetUserRole(ctx, entities.UserAccess{UserId: user_id, Role: entities.RoleAdmin})
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Created admin with id=%d\n", user_id)
+	return nil
+}
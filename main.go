@@ -2,33 +2,149 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
 	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
 	"task_tracker/src/api"
+	"task_tracker/src/auth"
+	"task_tracker/src/clients/people_info"
+	"task_tracker/src/config"
+	"task_tracker/src/jobs"
+	"task_tracker/src/metrics"
+	"task_tracker/src/passport"
 	"task_tracker/src/repository"
+	"task_tracker/src/server"
+	"task_tracker/src/services"
+	"task_tracker/src/tracing"
 	"task_tracker/src/utils"
 	"time"
 
-	"net/http"
-
 	"github.com/gorilla/mux"
 )
 
 func main() {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	cfg, args, err := config.Load(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	} else if err != nil {
+		fmt.Fprintln(os.Stderr, "Invalid configuration:", err)
+		os.Exit(2)
+	}
+
+	// ctx is cancelled on SIGINT or SIGTERM, startup_ctx only bounds the checks
+	// made before serving.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	startup_ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
-	log := utils.GetLogger()
-	postgres_pool := repository.GetPostgresPool(ctx, log)
+
+	log := utils.GetLogger(cfg.Log)
+	shutdown_tracing, err := tracing.Setup(startup_ctx, cfg.Tracing)
+	if err != nil {
+		log.Fatal("Error setting up tracing: ", err)
+	}
+	postgres_pool := repository.GetPostgresPool(startup_ctx, cfg.Postgres, log)
+
+	if len(args) > 0 && args[0] == "migrate" {
+		err := runMigrateCommand(ctx, postgres_pool, log, args[1:])
+		postgres_pool.Close()
+		if err != nil {
+			log.Fatal("Error running migrations: ", err)
+		}
+		return
+	}
+
+	if err := repository.CheckSchemaVersion(startup_ctx, postgres_pool, log); err != nil {
+		log.Fatal("Database schema is not up to date, run `migrate up`: ", err)
+	}
+
+	repo := repository.NewPostgresRepository(postgres_pool, log)
+
+	if len(args) > 0 && args[0] == "set-role" {
+		err := runSetRoleCommand(ctx, repo, args[1:])
+		postgres_pool.Close()
+		if err != nil {
+			log.Fatal("Error setting user role: ", err)
+		}
+		return
+	}
+
+	if len(args) > 0 && args[0] == "create-admin" {
+		passport.SetFormat(cfg.Passport)
+		err := runCreateAdminCommand(ctx, repo, args[1:])
+		postgres_pool.Close()
+		if err != nil {
+			log.Fatal("Error creating admin: ", err)
+		}
+		return
+	}
+
+	var people_info_provider services.PeopleInfoProvider
+	var people_info_pinger services.Pinger
+	if cfg.PeopleInfo.URL != "" {
+		people_info_client := people_info.NewClient(people_info.Config{
+			BaseURL:    cfg.PeopleInfo.URL,
+			Timeout:    cfg.PeopleInfo.Timeout,
+			MaxRetries: cfg.PeopleInfo.MaxRetries,
+			RetryDelay: cfg.PeopleInfo.RetryDelay,
+		}, log)
+		people_info_provider = people_info_client
+		people_info_pinger = people_info_client
+	}
+
+	token_manager := auth.NewTokenManager(auth.Config{
+		Secret:          []byte(cfg.Auth.JWTSecret),
+		AccessTokenTTL:  cfg.Auth.AccessTokenTTL,
+		RefreshTokenTTL: cfg.Auth.RefreshTokenTTL,
+	})
+
+	metrics.Registry.MustRegister(metrics.NewPoolCollector(postgres_pool))
+	utils.SetStrictDecoding(cfg.Server.StrictJSON)
+	passport.SetFormat(cfg.Passport)
 
 	router := mux.NewRouter()
-	api.InitUserRoutes(router, postgres_pool, log)
-	api.InitTaskRoutes(router, postgres_pool, log)
+	router.Use(api.TracingMiddleware)
+	router.Use(api.RequestLoggingMiddleware(log))
+	router.Use(api.MetricsMiddleware)
+	router.Use(api.AuthMiddleware(token_manager))
+	router.Use(api.BodyLimitMiddleware(cfg.Server.MaxBodyBytes))
+	router.Use(api.IdempotencyMiddleware(repo, cfg.Idempotency.TTL))
+	api.InitAuthRoutes(router, repo, token_manager)
+	api.InitHealthRoutes(router, repo, people_info_pinger)
+	api.InitMetricsRoutes(router)
+	api.InitUserRoutes(router, repo, people_info_provider, cfg.Pagination)
+	api.InitTaskRoutes(router, repo)
+	api.InitProjectRoutes(router, repo)
+	api.InitTimesheetRoutes(router, repo)
+	api.InitAuditRoutes(router, repo, cfg.Pagination)
+
+	retention_ctx, stop_retention := context.WithCancel(ctx)
+	retention_done := make(chan struct{})
+	go func() {
+		defer close(retention_done)
+		jobs.RunRetention(retention_ctx, repo, cfg.Retention, log)
+	}()
 
-	fmt.Println("Server is running on port 8080")
-	srv := &http.Server{
-		Handler:      router,
-		Addr:         "127.0.0.1:8080",
-		WriteTimeout: 15 * time.Second,
-		ReadTimeout:  15 * time.Second,
+	srv := server.New(cfg.Server, router, log)
+	srv.OnShutdown("retention job", func(ctx context.Context) error {
+		stop_retention()
+		select {
+		case <-retention_done:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+	srv.OnShutdown("postgres pool", func(ctx context.Context) error {
+		postgres_pool.Close()
+		return nil
+	})
+	srv.OnShutdown("tracer provider", shutdown_tracing)
+	if err := srv.Run(ctx); err != nil {
+		log.Fatal("Server stopped with error: ", err)
 	}
-	log.Fatal(srv.ListenAndServe())
+	log.Info("Server stopped")
 }
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"task_tracker/src/entities"
+	"task_tracker/src/repository"
+)
+
+// runSetRoleCommand assigns a role directly in the database. It is used to
+// bootstrap the first admin, after that roles are managed through the API.
+func runSetRoleCommand(ctx context.Context, repo repository.AccessRepository, args []string) error {
+	if len(args) < 2 || len(args) > 3 {
+		return fmt.Errorf("usage: set-role <userId> admin|manager|member [managerId]")
+	}
+
+	user_id, err := strconv.Atoi(args[0])
+	if err != nil {
+		return fmt.Errorf("userId must be a number")
+	}
+	role := args[1]
+	if role != entities.RoleAdmin && role != entities.RoleManager && role != entities.RoleMember {
+		return fmt.Errorf("unknown role %q, expected admin, manager or member", role)
+	}
+
+	access := entities.UserAccess{UserId: user_id, Role: role}
+	if len(args) == 3 {
+		manager_id, err := strconv.Atoi(args[2])
+		if err != nil {
+			return fmt.Errorf("managerId must be a number")
+		}
+		access.ManagerId = &manager_id
+	}
+	return repo.SetUserRole(ctx, access)
+}
@@ -1,3 +0,0 @@
-package main
-
-var USERS_PAGINATE_BY = 10
@@ -0,0 +1,89 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"task_tracker/src/config"
+	"task_tracker/src/entities"
+	"task_tracker/src/errors/api_errors"
+	"task_tracker/src/repository"
+	"task_tracker/src/services"
+
+	"github.com/gorilla/mux"
+)
+
+func InitAuditRoutes(
+	router *mux.Router,
+	repo repository.AuditRepository,
+	pagination config.PaginationConfig,
+) {
+	router.HandleFunc("/audit", getAuditEvents(repo, pagination.AuditEventsPerPage)).Methods("GET")
+}
+
+func getAuditEvents(repo repository.AuditRepository, events_per_page int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		query := r.URL.Query()
+		page, err := strconv.Atoi(query.Get("page"))
+		if err != nil || page < 1 {
+			page = 1
+		}
+
+		filter := entities.AuditEventsFilter{
+			Entity: query.Get("entity"),
+			Action: query.Get("action"),
+			Offset: (page - 1) * events_per_page,
+			Limit:  events_per_page,
+		}
+		filter.EntityId, err = parseIdParam(query, "entityId")
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		filter.ActorId, err = parseIdParam(query, "actorId")
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		filter.DateFrom, err = parseDateParam(query, "dateFrom")
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		filter.DateTo, err = parseDateParam(query, "dateTo")
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		events, events_count, err := services.GetAuditEvents(r.Context(), repo, filter)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		response := entities.GetAuditEventsResponse{
+			Events:   events,
+			Page:     page,
+			LastPage: int(math.Ceil(float64(events_count) / float64(events_per_page))),
+		}
+		json.NewEncoder(w).Encode(response)
+	}
+}
+
+func parseIdParam(query url.Values, name string) (*int, error) {
+	value := query.Get(name)
+	if value == "" {
+		return nil, nil
+	}
+	id, err := strconv.Atoi(value)
+	if err != nil {
+		return nil, &api_errors.BadRequestError{Detail: fmt.Sprintf("Parametr %s must be a number", name)}
+	}
+	return &id, nil
+}
@@ -0,0 +1,125 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"task_tracker/src/auth"
+	"task_tracker/src/entities"
+	"task_tracker/src/errors/api_errors"
+	"task_tracker/src/repository"
+	"task_tracker/src/services"
+	"task_tracker/src/utils"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+// Routes that can be called without an access token, keyed by method and
+// path template.
+var public_routes = map[string]bool{
+	"POST /auth/login":   true,
+	"POST /auth/refresh": true,
+	"POST /auth/logout":  true,
+	"GET /healthz":       true,
+	"GET /readyz":        true,
+	"GET /metrics":       true,
+}
+
+func InitAuthRoutes(router *mux.Router, repo repository.AuthRepository, token_manager *auth.TokenManager) {
+	router.HandleFunc("/auth/login", login(repo, token_manager)).Methods("POST")
+	router.HandleFunc("/auth/refresh", refreshTokens(repo, token_manager)).Methods("POST")
+	router.HandleFunc("/auth/logout", logout(repo, token_manager)).Methods("POST")
+}
+
+func AuthMiddleware(token_manager *auth.TokenManager) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if route := mux.CurrentRoute(r); route != nil {
+				path_template, _ := route.GetPathTemplate()
+				if public_routes[r.Method+" "+path_template] {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+
+			token, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !found || token == "" {
+				writeUnauthorized(w, "Missing bearer token")
+				return
+			}
+			user_id, err := token_manager.ParseAccessToken(token)
+			if err != nil {
+				writeUnauthorized(w, "Invalid access token")
+				return
+			}
+
+			utils.AddLogFields(r.Context(), logrus.Fields{"user_id": user_id})
+			next.ServeHTTP(w, r.WithContext(auth.WithUserId(r.Context(), user_id)))
+		})
+	}
+}
+
+func writeUnauthorized(w http.ResponseWriter, detail string) {
+	writeError(w, api_errors.UnauthorizedError{Detail: detail})
+}
+
+func login(repo repository.AuthRepository, token_manager *auth.TokenManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		validated_request_data, err := utils.ValidateRequestData(entities.LoginRequest{}, r.Body)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		tokens, err := services.Login(r.Context(), repo, token_manager, *validated_request_data)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		json.NewEncoder(w).Encode(tokens)
+	}
+}
+
+func refreshTokens(repo repository.AuthRepository, token_manager *auth.TokenManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		validated_request_data, err := utils.ValidateRequestData(entities.RefreshTokenRequest{}, r.Body)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		tokens, err := services.RefreshTokens(r.Context(), repo, token_manager, validated_request_data.RefreshToken)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		json.NewEncoder(w).Encode(tokens)
+	}
+}
+
+func logout(repo repository.AuthRepository, token_manager *auth.TokenManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		validated_request_data, err := utils.ValidateRequestData(entities.RefreshTokenRequest{}, r.Body)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		err = services.Logout(r.Context(), repo, token_manager, validated_request_data.RefreshToken)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,21 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// BodyLimitMiddleware caps request bodies so a client cannot make a handler
+// buffer arbitrary amounts of JSON. Reads past the limit fail and are
+// reported as 413 by ValidateRequestData.
+func BodyLimitMiddleware(max_body_bytes int) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Body != nil {
+				r.Body = http.MaxBytesReader(w, r.Body, int64(max_body_bytes))
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package api
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"task_tracker/src/entities"
+	"task_tracker/src/errors/api_errors"
+	"task_tracker/src/passport"
+	"time"
+)
+
+var users_sorts = map[string]bool{
+	entities.UsersSortId:        true,
+	entities.UsersSortSurname:   true,
+	entities.UsersSortName:      true,
+	entities.UsersSortCreatedAt: true,
+}
+
+// Query parameters of GET /users that are carried by its cursors.
+var users_query_params = []string{"sort", "surname", "name", "passportSerie", "createdFrom", "createdTo"}
+
+// encodeUsersCursor returns an empty string for a nil request, so missing
+// cursors are left out of responses.
+func encodeUsersCursor(request *entities.UsersPageRequest) string {
+	if request == nil {
+		return ""
+	}
+	encoded, _ := json.Marshal(request)
+	return base64.RawURLEncoding.EncodeToString(encoded)
+}
+
+func decodeUsersCursor(cursor string) (entities.UsersPageRequest, error) {
+	invalid_cursor := &api_errors.BadRequestError{Detail: "Parametr cursor is not a cursor returned by GET /users"}
+
+	decoded, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return entities.UsersPageRequest{}, invalid_cursor
+	}
+	var request entities.UsersPageRequest
+	if err = json.Unmarshal(decoded, &request); err != nil {
+		return entities.UsersPageRequest{}, invalid_cursor
+	}
+	if !users_sorts[request.Sort] || request.Position == nil {
+		return entities.UsersPageRequest{}, invalid_cursor
+	}
+	if request.Sort == entities.UsersSortCreatedAt {
+		if _, err = time.Parse(time.RFC3339Nano, request.Position.Key); err != nil {
+			return entities.UsersPageRequest{}, invalid_cursor
+		}
+	}
+	return request, nil
+}
+
+// parseUsersPageRequest reads the first page of GET /users from the query.
+// sort is a column name, prefixed with a minus for descending order.
+func parseUsersPageRequest(query url.Values) (entities.UsersPageRequest, error) {
+	request := entities.UsersPageRequest{
+		Filter: entities.UsersFilter{
+			SurnamePrefix: query.Get("surname"),
+			NamePrefix:    query.Get("name"),
+			PassportSerie: query.Get("passportSerie"),
+		},
+		Sort: entities.UsersSortId,
+	}
+	if sort := query.Get("sort"); sort != "" {
+		request.Sort, request.Descending = strings.CutPrefix(sort, "-")
+		if !users_sorts[request.Sort] {
+			return entities.UsersPageRequest{}, &api_errors.BadRequestError{
+				Detail: fmt.Sprintf(
+					"Parametr sort must be one of %s, %s, %s or %s",
+					entities.UsersSortId, entities.UsersSortSurname, entities.UsersSortName, entities.UsersSortCreatedAt,
+				),
+			}
+		}
+	}
+
+	var err error
+	if request.Filter.PassportSerie != "" {
+		// Matched against stored series, which are normalized by passport.Parse.
+		request.Filter.PassportSerie, err = passport.ParseSerie(request.Filter.PassportSerie)
+		if err != nil {
+			return entities.UsersPageRequest{}, &api_errors.BadRequestError{
+				Detail:        fmt.Sprintf("Incorrect passportSerie: %s", err),
+				InvalidParams: []entities.InvalidParam{{Name: "passportSerie", Reason: err.Error()}},
+			}
+		}
+	}
+	request.Filter.CreatedFrom, err = parseDateParam(query, "createdFrom")
+	if err != nil {
+		return entities.UsersPageRequest{}, err
+	}
+	request.Filter.CreatedTo, err = parseDateParam(query, "createdTo")
+	if err != nil {
+		return entities.UsersPageRequest{}, err
+	}
+	return request, nil
+}
+
+func parseLimitParam(query url.Values, default_limit int, max_limit int) (int, error) {
+	value := query.Get("limit")
+	if value == "" {
+		return default_limit, nil
+	}
+	limit, err := strconv.Atoi(value)
+	if err != nil || limit < 1 || limit > max_limit {
+		return 0, &api_errors.BadRequestError{
+			Detail: fmt.Sprintf("Parametr limit must be a number from 1 to %d", max_limit),
+		}
+	}
+	return limit, nil
+}
@@ -0,0 +1,41 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"task_tracker/src/entities"
+	"task_tracker/src/errors/api_errors"
+)
+
+const problem_content_type = "application/problem+json"
+
+// writeError is the single place errors are turned into responses. Errors
+// that are not api_errors are reported as internal without their message, so
+// nothing unexpected leaks to clients.
+func writeError(w http.ResponseWriter, err error) {
+	var problem api_errors.Problem
+	if !errors.As(err, &problem) {
+		problem = api_errors.InternalServerError{}
+	}
+
+	response := entities.ProblemResponse{
+		Type:   "about:blank",
+		Title:  problem.Title(),
+		Status: problem.Status(),
+		Detail: problem.ProblemDetail(),
+		Code:   problem.Code(),
+	}
+	if with_params, ok := problem.(interface {
+		InvalidParameters() []entities.InvalidParam
+	}); ok {
+		response.InvalidParams = with_params.InvalidParameters()
+	}
+
+	w.Header().Set("Content-Type", problem_content_type)
+	if problem.Status() == http.StatusUnauthorized {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+	}
+	w.WriteHeader(problem.Status())
+	json.NewEncoder(w).Encode(response)
+}
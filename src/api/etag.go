@@ -0,0 +1,63 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"task_tracker/src/entities"
+)
+
+func formatETag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+func setETag(w http.ResponseWriter, version int) {
+	w.Header().Set("ETag", formatETag(version))
+}
+
+// getETags returns the entity tags listed in the header, which may be
+// repeated.
+func getETags(r *http.Request, header string) []string {
+	tags := []string{}
+	for _, value := range r.Header.Values(header) {
+		for _, tag := range strings.Split(value, ",") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				tags = append(tags, tag)
+			}
+		}
+	}
+	return tags
+}
+
+// getIfMatch returns the versions allowed by If-Match. Weak tags never match
+// since If-Match uses the strong comparison, and neither do tags that are not
+// versions.
+func getIfMatch(r *http.Request) entities.VersionPrecondition {
+	tags := getETags(r, "If-Match")
+	if len(tags) == 0 {
+		return nil
+	}
+	versions := entities.VersionPrecondition{}
+	for _, tag := range tags {
+		if tag == "*" {
+			return nil
+		}
+		version, err := strconv.Atoi(strings.Trim(tag, `"`))
+		if err == nil && tag == formatETag(version) {
+			versions = append(versions, version)
+		}
+	}
+	return versions
+}
+
+// writeNotModified answers 304 when If-None-Match lists the current version,
+// comparing weakly. The ETag header must already be set.
+func writeNotModified(w http.ResponseWriter, r *http.Request, version int) bool {
+	for _, tag := range getETags(r, "If-None-Match") {
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == formatETag(version) {
+			w.WriteHeader(http.StatusNotModified)
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,65 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"task_tracker/src/errors/api_errors"
+	"task_tracker/src/export"
+)
+
+// negotiateFormat picks the response format from the format query parameter
+// or, when it is absent, from the Accept header.
+func negotiateFormat(r *http.Request) (export.Format, error) {
+	if name := r.URL.Query().Get("format"); name != "" {
+		format, ok := export.ParseFormat(name)
+		if !ok {
+			return "", &api_errors.BadRequestError{Detail: "Parametr format must be one of: json, csv, jsonl"}
+		}
+		return format, nil
+	}
+
+	format, ok := export.FormatFromAccept(r.Header.Get("Accept"))
+	if !ok {
+		return "", &api_errors.NotAcceptableError{
+			Detail: "Supported media types are application/json, text/csv and application/x-ndjson",
+		}
+	}
+	return format, nil
+}
+
+// exportWriter sets the export headers right before the first byte of the
+// body, so errors returned before any output still get a regular response.
+type exportWriter struct {
+	w        http.ResponseWriter
+	format   export.Format
+	filename string
+	started  bool
+}
+
+func newExportWriter(w http.ResponseWriter, format export.Format, filename string) *exportWriter {
+	return &exportWriter{w: w, format: format, filename: filename}
+}
+
+func (e *exportWriter) Write(p []byte) (int, error) {
+	if !e.started {
+		e.started = true
+		e.w.Header().Set("Content-Type", export.ContentType(e.format))
+		e.w.Header().Set(
+			"Content-Disposition",
+			fmt.Sprintf(`attachment; filename="%s.%s"`, e.filename, export.FileExtension(e.format)),
+		)
+	}
+	return e.w.Write(p)
+}
+
+// finishExport writes err as a regular error response if nothing has been
+// sent yet. Once the body has started the response can only be cut short.
+func finishExport(e *exportWriter, err error, close func() error) {
+	if err != nil {
+		if !e.started {
+			writeError(e.w, err)
+		}
+		return
+	}
+	close()
+}
@@ -0,0 +1,43 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"task_tracker/src/entities"
+	"task_tracker/src/repository"
+	"task_tracker/src/services"
+
+	"github.com/gorilla/mux"
+)
+
+// InitHealthRoutes registers the orchestrator probes. people_info_pinger may be
+// nil when enrichment is disabled.
+func InitHealthRoutes(
+	router *mux.Router,
+	repo repository.HealthRepository,
+	people_info_pinger services.Pinger,
+) {
+	router.HandleFunc("/healthz", liveness()).Methods("GET")
+	router.HandleFunc("/readyz", readiness(repo, people_info_pinger)).Methods("GET")
+}
+
+func liveness() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		json.NewEncoder(w).Encode(entities.LivenessResponse{Status: entities.HealthStatusOk})
+	}
+}
+
+func readiness(repo repository.HealthRepository, people_info_pinger services.Pinger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+
+		report := services.CheckReadiness(r.Context(), repo, people_info_pinger)
+		if report.Status == entities.HealthStatusFailing {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		json.NewEncoder(w).Encode(report)
+	}
+}
@@ -0,0 +1,161 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"task_tracker/src/auth"
+	"task_tracker/src/entities"
+	"task_tracker/src/errors/api_errors"
+	"task_tracker/src/repository"
+	"task_tracker/src/services"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+const max_idempotency_key_length = 255
+
+// Routes honoring the Idempotency-Key header, keyed by method and path
+// template.
+var idempotent_routes = map[string]bool{
+	"POST /users": true,
+	"POST /tasks": true,
+}
+
+// Response headers stored with an idempotent response and sent on replays.
+var idempotent_headers = []string{"Content-Type", "ETag", "Location"}
+
+// IdempotencyMiddleware replays the stored response when a request is sent
+// again with the same Idempotency-Key, and rejects keys reused with a
+// different request. Keys are scoped to the user, so anonymous requests can
+// not use them. It must run after AuthMiddleware and, since it buffers the
+// body, after BodyLimitMiddleware.
+func IdempotencyMiddleware(repo repository.IdempotencyRepository, ttl time.Duration) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get("Idempotency-Key")
+			if key == "" || !idempotent_routes[r.Method+" "+routeTemplate(r)] {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if !validIdempotencyKey(key) {
+				writeError(w, api_errors.BadRequestError{
+					Detail: fmt.Sprintf("Idempotency-Key must be at most %d printable ASCII characters", max_idempotency_key_length),
+				})
+				return
+			}
+			user_id, ok := auth.UserIdFromContext(r.Context())
+			if !ok {
+				writeError(w, api_errors.BadRequestError{Detail: "Idempotency-Key can only be used by authenticated clients"})
+				return
+			}
+
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				var max_bytes_err *http.MaxBytesError
+				if errors.As(err, &max_bytes_err) {
+					writeError(w, api_errors.PayloadTooLargeError{
+						Detail: fmt.Sprintf("Request body must not exceed %d bytes", max_bytes_err.Limit),
+					})
+					return
+				}
+				writeError(w, api_errors.BadRequestError{Detail: "Error reading request body"})
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			stored, err := services.BeginIdempotentRequest(
+				r.Context(), repo, user_id, key, requestFingerprint(r, body), ttl,
+			)
+			if err != nil {
+				writeError(w, err)
+				return
+			}
+			if stored != nil {
+				replayResponse(w, *stored)
+				return
+			}
+
+			recorder := &responseCapture{ResponseWriter: w, status: http.StatusOK}
+			completed := false
+			defer func() {
+				// completed is false when the handler panicked, the key is released then.
+				record := entities.IdempotencyRecord{UserId: user_id, Key: key}
+				if completed {
+					record.StatusCode = recorder.status
+					record.Headers = map[string]string{}
+					for _, name := range idempotent_headers {
+						if value := w.Header().Get(name); value != "" {
+							record.Headers[name] = value
+						}
+					}
+					record.Body = recorder.body.Bytes()
+				}
+				services.FinishIdempotentRequest(context.WithoutCancel(r.Context()), repo, record)
+			}()
+			next.ServeHTTP(recorder, r)
+			completed = true
+		})
+	}
+}
+
+func validIdempotencyKey(key string) bool {
+	if len(key) > max_idempotency_key_length {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] < 0x20 || key[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// requestFingerprint tells apart requests sent with the same key.
+func requestFingerprint(r *http.Request, body []byte) string {
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%s %s\n", r.Method, r.URL.RequestURI())
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func replayResponse(w http.ResponseWriter, record entities.IdempotencyRecord) {
+	for name, value := range record.Headers {
+		w.Header().Set(name, value)
+	}
+	w.Header().Set("Idempotent-Replayed", "true")
+	w.WriteHeader(record.StatusCode)
+	w.Write(record.Body)
+}
+
+// responseCapture keeps a copy of the response while writing it through.
+type responseCapture struct {
+	http.ResponseWriter
+	status       int
+	body         bytes.Buffer
+	wrote_header bool
+}
+
+func (c *responseCapture) WriteHeader(status int) {
+	if !c.wrote_header {
+		c.status = status
+		c.wrote_header = true
+	}
+	c.ResponseWriter.WriteHeader(status)
+}
+
+func (c *responseCapture) Write(data []byte) (int, error) {
+	c.wrote_header = true
+	c.body.Write(data)
+	return c.ResponseWriter.Write(data)
+}
+
+func (c *responseCapture) Unwrap() http.ResponseWriter {
+	return c.ResponseWriter
+}
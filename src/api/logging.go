@@ -0,0 +1,62 @@
+package api
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"regexp"
+	"task_tracker/src/utils"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const request_id_header = "X-Request-ID"
+
+// Incoming request ids are reused only when they are safe to log.
+var valid_request_id = regexp.MustCompile(`^[A-Za-z0-9._-]{1,128}$`)
+
+// RequestLoggingMiddleware assigns every request an id, echoed in the
+// X-Request-ID response header, puts a logger with the id, route and trace id
+// in the request context and writes an access log line when the request is
+// done.
+func RequestLoggingMiddleware(log *logrus.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			request_id := r.Header.Get(request_id_header)
+			if !valid_request_id.MatchString(request_id) {
+				request_id = newRequestId()
+			}
+			w.Header().Set(request_id_header, request_id)
+
+			fields := logrus.Fields{
+				"request_id": request_id,
+				"method":     r.Method,
+				"route":      routeTemplate(r),
+			}
+			if span_context := trace.SpanContextFromContext(r.Context()); span_context.IsValid() {
+				fields["trace_id"] = span_context.TraceID().String()
+			}
+			ctx := utils.ContextWithLogger(r.Context(), log.WithFields(fields))
+			ctx = utils.ContextWithRequestId(ctx, request_id)
+
+			recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+			start_time := time.Now()
+			next.ServeHTTP(recorder, r.WithContext(ctx))
+
+			utils.LoggerFromContext(ctx, log).WithFields(logrus.Fields{
+				"path":        r.URL.Path,
+				"status":      recorder.status,
+				"duration_ms": float64(time.Since(start_time).Microseconds()) / 1000,
+				"bytes":       recorder.bytes,
+			}).Info("Request completed")
+		})
+	}
+}
+
+func newRequestId() string {
+	id := make([]byte, 16)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
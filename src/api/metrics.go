@@ -0,0 +1,30 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"task_tracker/src/metrics"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+func InitMetricsRoutes(router *mux.Router) {
+	router.Handle("/metrics", promhttp.HandlerFor(metrics.Registry, promhttp.HandlerOpts{})).Methods("GET")
+}
+
+// MetricsMiddleware records requests by route template so /users/1 and
+// /users/2 share a series. It must run before AuthMiddleware for rejected
+// requests to be counted.
+func MetricsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route_template := routeTemplate(r)
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		start_time := time.Now()
+		next.ServeHTTP(recorder, r)
+
+		metrics.HTTPRequestDuration.WithLabelValues(r.Method, route_template).Observe(time.Since(start_time).Seconds())
+		metrics.HTTPRequests.WithLabelValues(r.Method, route_template, strconv.Itoa(recorder.status)).Inc()
+	})
+}
@@ -0,0 +1,129 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"task_tracker/src/entities"
+	"task_tracker/src/errors/api_errors"
+	"task_tracker/src/repository"
+	"task_tracker/src/services"
+	"task_tracker/src/utils"
+
+	"github.com/gorilla/mux"
+)
+
+func InitProjectRoutes(router *mux.Router, repo repository.ProjectRepository) {
+	router.HandleFunc("/projects", createProject(repo)).Methods("POST")
+	router.HandleFunc("/projects", getProjects(repo)).Methods("GET")
+	router.HandleFunc("/projects/{projectId}", getProject(repo)).Methods("GET")
+	router.HandleFunc("/projects/{projectId}", updateProject(repo)).Methods("PATCH")
+	router.HandleFunc("/projects/{projectId}", deleteProject(repo)).Methods("DELETE")
+}
+
+func createProject(repo repository.ProjectRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		validated_project_data, err := utils.ValidateRequestData(entities.CreateProjectRequest{}, r.Body)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		created_project, err := services.CreateProject(r.Context(), repo, *validated_project_data)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		json.NewEncoder(w).Encode(created_project)
+	}
+}
+
+func getProjects(repo repository.ProjectRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		projects, err := services.GetProjects(r.Context(), repo)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		json.NewEncoder(w).Encode(entities.GetProjectsResponse{Projects: projects})
+	}
+}
+
+func getProject(repo repository.ProjectRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		project_id, err := getProjectIdFromRequest(r)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		project, err := services.GetProject(r.Context(), repo, project_id)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		json.NewEncoder(w).Encode(project)
+	}
+}
+
+func updateProject(repo repository.ProjectRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		project_id, err := getProjectIdFromRequest(r)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		validated_project_data, err_parse := utils.ValidateRequestData(entities.UpdateProjectRequest{}, r.Body)
+		if err_parse != nil {
+			writeError(w, err_parse)
+			return
+		}
+
+		updated_project, err := services.UpdateProject(r.Context(), repo, *validated_project_data, project_id)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		json.NewEncoder(w).Encode(updated_project)
+	}
+}
+
+func deleteProject(repo repository.ProjectRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		project_id, err := getProjectIdFromRequest(r)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		err = services.DeleteProject(r.Context(), repo, project_id)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func getProjectIdFromRequest(r *http.Request) (int, error) {
+	project_id, err := strconv.Atoi(mux.Vars(r)["projectId"])
+	if err != nil {
+		return 0, &api_errors.BadRequestError{Detail: "Parametr projectId must be a number"}
+	}
+	return project_id, nil
+}
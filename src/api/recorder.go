@@ -0,0 +1,44 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+func routeTemplate(r *http.Request) string {
+	if route := mux.CurrentRoute(r); route != nil {
+		if path_template, err := route.GetPathTemplate(); err == nil {
+			return path_template
+		}
+	}
+	return "unknown"
+}
+
+// statusRecorder remembers the status code and body size for middlewares
+// that report on the response.
+type statusRecorder struct {
+	http.ResponseWriter
+	status       int
+	bytes        int
+	wrote_header bool
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if !r.wrote_header {
+		r.status = status
+		r.wrote_header = true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(data []byte) (int, error) {
+	r.wrote_header = true
+	written, err := r.ResponseWriter.Write(data)
+	r.bytes += written
+	return written, err
+}
+
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
@@ -2,86 +2,321 @@ package api
 
 import (
 	"encoding/json"
-	"errors"
+	"fmt"
 	"net/http"
+	"net/url"
+	"strconv"
 	"task_tracker/src/entities"
 	"task_tracker/src/errors/api_errors"
+	"task_tracker/src/repository"
 	"task_tracker/src/services"
 	"task_tracker/src/utils"
+	"time"
 
 	"github.com/gorilla/mux"
-	"github.com/jackc/pgx/v4/pgxpool"
+)
 
-	"github.com/sirupsen/logrus"
+const (
+	date_time_layout = "2006-01-02 15:04"
+	date_time_format = "YYYY-MM-DD HH:MM"
 )
 
-func InitTaskRoutes(router *mux.Router, pool *pgxpool.Pool, log *logrus.Logger) {
-	router.HandleFunc("/tasks", createTask(pool, log)).Methods("POST")
-	router.HandleFunc("/tasks/finish", finishTask(pool, log)).Methods("POST")
+func InitTaskRoutes(router *mux.Router, repo repository.TaskRepository) {
+	router.HandleFunc("/tasks", createTask(repo)).Methods("POST")
+	router.HandleFunc("/tasks/finish", finishTask(repo)).Methods("POST")
+	router.HandleFunc("/tasks", getTasks(repo)).Methods("GET")
+	router.HandleFunc("/tasks/{taskId}", getTask(repo)).Methods("GET")
+	router.HandleFunc("/tasks/{taskId}", updateTask(repo)).Methods("PATCH")
+	router.HandleFunc("/tasks/{taskId}", deleteTask(repo)).Methods("DELETE")
+	router.HandleFunc("/tasks/{taskId}/pause", pauseTask(repo)).Methods("POST")
+	router.HandleFunc("/tasks/{taskId}/resume", resumeTask(repo)).Methods("POST")
+	router.HandleFunc("/tasks/{taskId}/reopen", reopenTask(repo)).Methods("POST")
 }
 
-func createTask(pool *pgxpool.Pool, log *logrus.Logger) http.HandlerFunc {
+func createTask(repo repository.TaskRepository) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 
 		var task entities.CreateTaskRequest
 		task_data_validated, err := utils.ValidateRequestData(task, r.Body)
 		if err != nil {
-			w.WriteHeader(http.StatusBadRequest)
-			resp := entities.ErrorResponse{Error: err.Error()}
-			json.NewEncoder(w).Encode(resp)
+			writeError(w, err)
 			return
 		}
 
 		created_task, err := services.CreateTask(
 			r.Context(),
-			pool,
-			log,
+			repo,
 			*task_data_validated,
 		)
 		if err != nil {
-			resp := entities.ErrorResponse{Error: err.Error()}
-			var bad_request_error *api_errors.BadRequestError
-
-			if errors.As(err, &bad_request_error) {
-				w.WriteHeader(http.StatusBadRequest)
-			} else {
-				w.WriteHeader(http.StatusInternalServerError)
-			}
-			json.NewEncoder(w).Encode(resp)
+			writeError(w, err)
 			return
 		}
 
+		setETag(w, created_task.Version)
 		json.NewEncoder(w).Encode(created_task)
 	}
 }
 
-func finishTask(pool *pgxpool.Pool, log *logrus.Logger) http.HandlerFunc {
+func finishTask(repo repository.TaskRepository) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 
 		var task_request entities.FinishTaskRequest
 		validated_request_data, err := utils.ValidateRequestData(task_request, r.Body)
 		if err != nil {
-			w.WriteHeader(http.StatusBadRequest)
-			resp := entities.ErrorResponse{Error: err.Error()}
-			json.NewEncoder(w).Encode(resp)
+			writeError(w, err)
 			return
 		}
 
-		err = services.FinishTask(
+		task, err := services.FinishTask(
 			r.Context(),
-			pool,
-			log,
+			repo,
 			validated_request_data.TaskId,
+			getIfMatch(r),
+		)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		setETag(w, task.Version)
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+func pauseTask(repo repository.TaskRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		task_id, err := getTaskIdFromRequest(r)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		task, err := services.PauseTask(
+			r.Context(),
+			repo,
+			task_id,
+		)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		setETag(w, task.Version)
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+func resumeTask(repo repository.TaskRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		task_id, err := getTaskIdFromRequest(r)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		task, err := services.ResumeTask(
+			r.Context(),
+			repo,
+			task_id,
 		)
 		if err != nil {
-			resp := entities.ErrorResponse{Error: err.Error()}
-			w.WriteHeader(http.StatusInternalServerError)
-			json.NewEncoder(w).Encode(resp)
+			writeError(w, err)
 			return
 		}
 
+		setETag(w, task.Version)
 		w.WriteHeader(http.StatusOK)
 	}
 }
+
+func getTask(repo repository.TaskRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		task_id, err := getTaskIdFromRequest(r)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		task, err := services.GetTask(r.Context(), repo, task_id)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		setETag(w, task.Version)
+		if writeNotModified(w, r, task.Version) {
+			return
+		}
+
+		json.NewEncoder(w).Encode(task)
+	}
+}
+
+func getTasks(repo repository.TaskRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		query := r.URL.Query()
+		var filters entities.TasksFilter
+		var err error
+
+		if user_id_param := query.Get("userId"); user_id_param != "" {
+			user_id, err_parse := strconv.Atoi(user_id_param)
+			if err_parse != nil {
+				writeError(w, &api_errors.BadRequestError{Detail: "Parametr userId must be a number"})
+				return
+			}
+			filters.UserId = &user_id
+		}
+
+		if project_id_param := query.Get("projectId"); project_id_param != "" {
+			project_id, err_parse := strconv.Atoi(project_id_param)
+			if err_parse != nil {
+				writeError(w, &api_errors.BadRequestError{Detail: "Parametr projectId must be a number"})
+				return
+			}
+			filters.ProjectId = &project_id
+		}
+
+		if finished_param := query.Get("finished"); finished_param != "" {
+			is_finished, err_parse := strconv.ParseBool(finished_param)
+			if err_parse != nil {
+				writeError(w, &api_errors.BadRequestError{Detail: "Parametr finished must be a boolean"})
+				return
+			}
+			filters.IsFinished = &is_finished
+		}
+
+		filters.DateFrom, err = parseDateParam(query, "dateFrom")
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		filters.DateTo, err = parseDateParam(query, "dateTo")
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		tasks, err := services.GetTasks(r.Context(), repo, filters)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		json.NewEncoder(w).Encode(entities.GetTasksResponse{Tasks: tasks})
+	}
+}
+
+func updateTask(repo repository.TaskRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		task_id, err := getTaskIdFromRequest(r)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		validated_task_data, err_parse := utils.ValidateRequestData(entities.UpdateTaskRequest{}, r.Body)
+		if err_parse != nil {
+			writeError(w, err_parse)
+			return
+		}
+
+		updated_task, err := services.UpdateTask(
+			r.Context(),
+			repo,
+			*validated_task_data,
+			task_id,
+			getIfMatch(r),
+		)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		setETag(w, updated_task.Version)
+
+		json.NewEncoder(w).Encode(updated_task)
+	}
+}
+
+func deleteTask(repo repository.TaskRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		task_id, err := getTaskIdFromRequest(r)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		err = services.DeleteTask(r.Context(), repo, task_id, getIfMatch(r))
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func reopenTask(repo repository.TaskRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		task_id, err := getTaskIdFromRequest(r)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		reopened_task, err := services.ReopenTask(r.Context(), repo, task_id)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		setETag(w, reopened_task.Version)
+		json.NewEncoder(w).Encode(reopened_task)
+	}
+}
+
+func getTaskIdFromRequest(r *http.Request) (int, error) {
+	task_id, err := strconv.Atoi(mux.Vars(r)["taskId"])
+	if err != nil {
+		return 0, &api_errors.BadRequestError{Detail: "Parametr taskId must be a number"}
+	}
+	return task_id, nil
+}
+
+func getUserIdFromRequest(r *http.Request) (int, error) {
+	user_id, err := strconv.Atoi(mux.Vars(r)["userId"])
+	if err != nil {
+		return 0, &api_errors.BadRequestError{Detail: "Parametr userId must be a number"}
+	}
+	return user_id, nil
+}
+
+func parseDateParam(query url.Values, name string) (*time.Time, error) {
+	value := query.Get(name)
+	if value == "" {
+		return nil, nil
+	}
+	date_parsed, err := time.Parse(date_time_layout, value)
+	if err != nil {
+		return nil, &api_errors.BadRequestError{
+			Detail: fmt.Sprintf("Parametr %s must be a date. Format %s", name, date_time_format),
+		}
+	}
+	return &date_parsed, nil
+}
@@ -0,0 +1,95 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"task_tracker/src/errors/api_errors"
+	"task_tracker/src/export"
+	"task_tracker/src/repository"
+	"task_tracker/src/services"
+
+	"github.com/gorilla/mux"
+)
+
+func InitTimesheetRoutes(router *mux.Router, repo repository.TimesheetRepository) {
+	router.HandleFunc("/timesheets", getTeamTimesheet(repo)).Methods("GET")
+	router.HandleFunc("/timesheets/{userId}", getTimesheet(repo)).Methods("GET")
+}
+
+func getTimesheet(repo repository.TimesheetRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		user_id, err := strconv.Atoi(mux.Vars(r)["userId"])
+		if err != nil {
+			writeError(w, &api_errors.BadRequestError{Detail: "Parametr userId must be a number"})
+			return
+		}
+
+		query := r.URL.Query()
+		period, err := services.ParseTimesheetPeriod(query.Get("week"), query.Get("month"), query.Get("timezone"))
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		w.Header().Add("Vary", "Accept")
+		format, err := negotiateFormat(r)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		if format != export.FormatJSON {
+			writer := newExportWriter(w, format, fmt.Sprintf("user-%d-timesheet-%s%s", user_id, query.Get("week"), query.Get("month")))
+			encoder := export.NewTimesheetEncoder(writer, format, services.TimesheetDays(period))
+			err = services.StreamTimesheet(r.Context(), repo, user_id, period, encoder.Encode)
+			finishExport(writer, err, encoder.Close)
+			return
+		}
+
+		timesheet, err := services.GetTimesheet(r.Context(), repo, user_id, period)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		json.NewEncoder(w).Encode(timesheet)
+	}
+}
+
+func getTeamTimesheet(repo repository.TimesheetRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		query := r.URL.Query()
+		period, err := services.ParseTimesheetPeriod(query.Get("week"), query.Get("month"), query.Get("timezone"))
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		w.Header().Add("Vary", "Accept")
+		format, err := negotiateFormat(r)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		if format != export.FormatJSON {
+			writer := newExportWriter(w, format, fmt.Sprintf("timesheet-%s%s", query.Get("week"), query.Get("month")))
+			encoder := export.NewTimesheetEncoder(writer, format, services.TimesheetDays(period))
+			err = services.StreamTeamTimesheet(r.Context(), repo, period, encoder.Encode)
+			finishExport(writer, err, encoder.Close)
+			return
+		}
+
+		team_timesheet, err := services.GetTeamTimesheet(r.Context(), repo, period)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		json.NewEncoder(w).Encode(team_timesheet)
+	}
+}
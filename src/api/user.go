@@ -2,244 +2,272 @@ package api
 
 import (
 	"encoding/json"
-	"errors"
 	"fmt"
-	"math"
 	"net/http"
-	"strconv"
+	"task_tracker/src/config"
 	"task_tracker/src/entities"
 	"task_tracker/src/errors/api_errors"
+	"task_tracker/src/export"
+	"task_tracker/src/repository"
 	"task_tracker/src/services"
 	"task_tracker/src/utils"
-	"time"
 
 	"github.com/gorilla/mux"
-	"github.com/jackc/pgx/v4/pgxpool"
-
-	"github.com/sirupsen/logrus"
 )
 
-func InitUserRoutes(router *mux.Router, pool *pgxpool.Pool, log *logrus.Logger) {
-	router.HandleFunc("/users", createUser(pool, log)).Methods("POST")
-	router.HandleFunc("/users/{userId}", updateUser(pool, log)).Methods("PATCH")
-	router.HandleFunc("/users/{userId}", deleteUser(pool, log)).Methods("DELETE")
-	router.HandleFunc("/users", getUsers(pool, log)).Methods("GET")
-	router.HandleFunc("/user-activities/{userId}", getUserActivities(pool, log)).Methods("GET")
+func InitUserRoutes(
+	router *mux.Router,
+	repo repository.UserRepository,
+	people_info_provider services.PeopleInfoProvider,
+	pagination config.PaginationConfig,
+) {
+	router.HandleFunc("/users", createUser(repo, people_info_provider)).Methods("POST")
+	router.HandleFunc("/users/{userId}", getUser(repo)).Methods("GET")
+	router.HandleFunc("/users/{userId}", updateUser(repo)).Methods("PATCH")
+	router.HandleFunc("/users/{userId}", deleteUser(repo)).Methods("DELETE")
+	router.HandleFunc("/users/{userId}/restore", restoreUser(repo)).Methods("POST")
+	router.HandleFunc("/users/{userId}/role", setUserRole(repo)).Methods("PUT")
+	router.HandleFunc("/users", getUsers(repo, pagination)).Methods("GET")
+	router.HandleFunc("/user-activities/{userId}", getUserActivities(repo)).Methods("GET")
 }
 
-func createUser(pool *pgxpool.Pool, log *logrus.Logger) http.HandlerFunc {
+func createUser(
+	repo repository.UserRepository,
+	people_info_provider services.PeopleInfoProvider,
+) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 
 		var user entities.UserCreateRequest
 		user_data_validated, err := utils.ValidateRequestData(user, r.Body)
 		if err != nil {
-			w.WriteHeader(http.StatusBadRequest)
-			resp := entities.ErrorResponse{Error: err.Error()}
-			json.NewEncoder(w).Encode(resp)
+			writeError(w, err)
 			return
 		}
 
 		created_user, err := services.CreateUser(
 			r.Context(),
-			pool,
-			log,
+			repo,
+			people_info_provider,
 			*user_data_validated,
 		)
 		if err != nil {
-			resp := entities.ErrorResponse{Error: err.Error()}
-			var bad_request_error *api_errors.BadRequestError
-
-			if errors.As(err, &bad_request_error) {
-				w.WriteHeader(http.StatusBadRequest)
-			} else {
-				w.WriteHeader(http.StatusInternalServerError)
-			}
-			json.NewEncoder(w).Encode(resp)
+			writeError(w, err)
 			return
 		}
 
 		response := entities.UserCreateResponse(created_user)
+		setETag(w, created_user.Version)
 		json.NewEncoder(w).Encode(response)
 	}
 }
 
-func updateUser(pool *pgxpool.Pool, log *logrus.Logger) http.HandlerFunc {
+func updateUser(repo repository.UserRepository) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 
-		vars := mux.Vars(r)
-		userID := vars["userId"]
-
-		if userID == "" {
-			resp := entities.ErrorResponse{Error: api_errors.BadRequestError{Detail: "Parametr userId is required"}.Error()}
-			w.WriteHeader(http.StatusBadRequest)
-			json.NewEncoder(w).Encode(resp)
-			return
-		}
-		user_id, err := strconv.Atoi(userID)
+		user_id, err := getUserIdFromRequest(r)
 		if err != nil {
-			resp := entities.ErrorResponse{Error: api_errors.BadRequestError{Detail: "Parametr userId must be a number"}.Error()}
-			w.WriteHeader(http.StatusBadRequest)
-			json.NewEncoder(w).Encode(resp)
+			writeError(w, err)
 			return
 		}
 
 		validated_user_data, err_parse := utils.ValidateRequestData(entities.UserUpdateRequest{}, r.Body)
 
 		if err_parse != nil {
-			w.WriteHeader(http.StatusBadRequest)
-			resp := entities.ErrorResponse{Error: err_parse.Error()}
-			json.NewEncoder(w).Encode(resp)
+			writeError(w, err_parse)
 			return
 		}
 
 		updated_user, err := services.UpdateUser(
 			r.Context(),
-			pool,
-			log,
+			repo,
 			*validated_user_data,
 			user_id,
+			getIfMatch(r),
 		)
 		if err != nil {
-			resp := entities.ErrorResponse{Error: err.Error()}
-
-			var bad_request_error *api_errors.BadRequestError
-			if errors.As(err, &bad_request_error) {
-				w.WriteHeader(http.StatusBadRequest)
-			} else {
-				w.WriteHeader(http.StatusInternalServerError)
-			}
-			json.NewEncoder(w).Encode(resp)
+			writeError(w, err)
 			return
 		}
 
 		response := entities.UserUpdateResponse(updated_user)
+		setETag(w, updated_user.Version)
 		json.NewEncoder(w).Encode(response)
 	}
 }
 
-func deleteUser(pool *pgxpool.Pool, log *logrus.Logger) http.HandlerFunc {
+func getUser(repo repository.UserRepository) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 
-		vars := mux.Vars(r)
-		userID := vars["userId"]
+		user_id, err := getUserIdFromRequest(r)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
 
-		if userID == "" {
-			resp := entities.ErrorResponse{Error: api_errors.BadRequestError{Detail: "Parametr userId is required"}.Error()}
-			w.WriteHeader(http.StatusBadRequest)
-			json.NewEncoder(w).Encode(resp)
+		user, err := services.GetUser(r.Context(), repo, user_id)
+		if err != nil {
+			writeError(w, err)
 			return
 		}
-		user_id, err := strconv.Atoi(userID)
+
+		setETag(w, user.Version)
+		if writeNotModified(w, r, user.Version) {
+			return
+		}
+		json.NewEncoder(w).Encode(entities.GetUserResponse(user))
+	}
+}
+
+func deleteUser(repo repository.UserRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		user_id, err := getUserIdFromRequest(r)
 		if err != nil {
-			resp := entities.ErrorResponse{Error: api_errors.BadRequestError{Detail: "Parametr userId must be a number"}.Error()}
-			w.WriteHeader(http.StatusBadRequest)
-			json.NewEncoder(w).Encode(resp)
+			writeError(w, err)
 			return
 		}
 
-		err = services.DeleteUser(r.Context(), pool, log, user_id)
+		err = services.DeleteUser(r.Context(), repo, user_id, getIfMatch(r))
 		if err != nil {
-			resp := entities.ErrorResponse{Error: err.Error()}
-			w.WriteHeader(http.StatusInternalServerError)
-			json.NewEncoder(w).Encode(resp)
+			writeError(w, err)
 			return
 		}
 		w.WriteHeader(http.StatusNoContent)
 	}
 }
 
-func getUsers(pool *pgxpool.Pool, log *logrus.Logger) http.HandlerFunc {
+func restoreUser(repo repository.UserRepository) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-
 		w.Header().Set("Content-Type", "application/json")
 
-		page, err := strconv.Atoi(r.URL.Query().Get("page"))
+		user_id, err := getUserIdFromRequest(r)
 		if err != nil {
-			page = 1
+			writeError(w, err)
+			return
 		}
 
-		users_per_page := 5
+		err = services.RestoreUser(r.Context(), repo, user_id)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
 
-		users, users_count, err := services.GetUsers(
-			r.Context(),
-			pool,
-			log,
-			(page-1)*users_per_page,
-			users_per_page,
-		)
+func setUserRole(repo repository.UserRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		user_id, err := getUserIdFromRequest(r)
 		if err != nil {
-			resp := entities.ErrorResponse{Error: err.Error()}
-			w.WriteHeader(http.StatusInternalServerError)
-			json.NewEncoder(w).Encode(resp)
+			writeError(w, err)
 			return
 		}
 
-		response := entities.GetUsersResponse{
-			Users:    users,
-			Page:     page,
-			LastPage: int(math.Ceil(float64(users_count) / float64(users_per_page))),
+		validated_request_data, err := utils.ValidateRequestData(entities.SetUserRoleRequest{}, r.Body)
+		if err != nil {
+			writeError(w, err)
+			return
 		}
+
+		access, err := services.SetUserRole(r.Context(), repo, *validated_request_data, user_id)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		response := entities.SetUserRoleResponse(access)
 		json.NewEncoder(w).Encode(response)
 	}
 }
 
-func getUserActivities(pool *pgxpool.Pool, log *logrus.Logger) http.HandlerFunc {
+func getUsers(repo repository.UserRepository, pagination config.PaginationConfig) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
-		vars := mux.Vars(r)
-		userID := vars["userId"]
 
-		if userID == "" {
-			resp := entities.ErrorResponse{Error: api_errors.BadRequestError{Detail: "Parametr userId is required"}.Error()}
-			w.WriteHeader(http.StatusBadRequest)
-			json.NewEncoder(w).Encode(resp)
+		query := r.URL.Query()
+		limit, err := parseLimitParam(query, pagination.UsersPerPage, pagination.MaxUsersPerPage)
+		if err != nil {
+			writeError(w, err)
 			return
 		}
-		user_id, err := strconv.Atoi(userID)
+
+		var request entities.UsersPageRequest
+		if cursor := query.Get("cursor"); cursor != "" {
+			for _, name := range users_query_params {
+				if query.Has(name) {
+					writeError(w, &api_errors.BadRequestError{
+						Detail: fmt.Sprintf("Parametr %s can not be combined with cursor", name),
+					})
+					return
+				}
+			}
+			request, err = decodeUsersCursor(cursor)
+		} else {
+			request, err = parseUsersPageRequest(query)
+		}
 		if err != nil {
-			resp := entities.ErrorResponse{Error: api_errors.BadRequestError{Detail: "Parametr userId must be a number"}.Error()}
-			w.WriteHeader(http.StatusBadRequest)
-			json.NewEncoder(w).Encode(resp)
+			writeError(w, err)
 			return
 		}
+		request.Limit = limit
 
-		date_from := r.URL.Query().Get("dateFrom")
-		date_to := r.URL.Query().Get("dateTo")
+		page, err := services.GetUsers(r.Context(), repo, request)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
 
-		date_time_format := "YYYY-MM-DD HH:MM"
+		users := make([]entities.UserListItem, 0, len(page.Users))
+		for _, user := range page.Users {
+			users = append(users, entities.UserListItem(user))
+		}
+		response := entities.GetUsersResponse{
+			Users: users,
+			Next:  encodeUsersCursor(page.Next),
+			Prev:  encodeUsersCursor(page.Prev),
+		}
+		json.NewEncoder(w).Encode(response)
+	}
+}
 
-		var date_from_parsed *time.Time
-		var date_to_parsed *time.Time
+func getUserActivities(repo repository.UserRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		user_id, err := getUserIdFromRequest(r)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
 
-		if date_from != "" {
-			*date_from_parsed, err = time.Parse(date_from, date_time_format)
-			if err != nil {
-				resp := entities.ErrorResponse{
-					Error: api_errors.BadRequestError{
-						Detail: fmt.Sprintf("Parametr dateFrom must be a date. Format %s", date_time_format),
-					}.Error(),
-				}
-				w.WriteHeader(http.StatusBadRequest)
-				json.NewEncoder(w).Encode(resp)
-				return
-			}
+		query := r.URL.Query()
+		date_from_parsed, err := parseDateParam(query, "dateFrom")
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		date_to_parsed, err := parseDateParam(query, "dateTo")
+		if err != nil {
+			writeError(w, err)
+			return
 		}
 
-		if date_to != "" {
-			*date_to_parsed, err = time.Parse(date_to, date_time_format)
-			if err != nil {
-				resp := entities.ErrorResponse{
-					Error: api_errors.BadRequestError{
-						Detail: fmt.Sprintf("Parametr dateTo must be a date. Format %s", date_time_format),
-					}.Error(),
-				}
-				w.WriteHeader(http.StatusBadRequest)
-				json.NewEncoder(w).Encode(resp)
-				return
-			}
+		group_by := query.Get("groupBy")
+		if group_by != "" && group_by != "task" && group_by != "project" {
+			writeError(w, &api_errors.BadRequestError{Detail: "Parametr groupBy must be one of: task, project"})
+			return
+		}
+
+		w.Header().Add("Vary", "Accept")
+		format, err := negotiateFormat(r)
+		if err != nil {
+			writeError(w, err)
+			return
 		}
 
 		user_activity_filters := entities.UserActivityRequest{
@@ -247,22 +275,35 @@ func getUserActivities(pool *pgxpool.Pool, log *logrus.Logger) http.HandlerFunc
 			DateFrom: date_from_parsed,
 			DateTo:   date_to_parsed,
 		}
+
+		if format != export.FormatJSON {
+			if group_by == "project" {
+				writeError(w, &api_errors.BadRequestError{Detail: "Parametr groupBy=project is only supported for json"})
+				return
+			}
+			writer := newExportWriter(w, format, fmt.Sprintf("user-%d-activities", user_id))
+			encoder := export.NewActivityEncoder(writer, format)
+			err = services.StreamUserActivities(r.Context(), repo, user_activity_filters, encoder.Encode)
+			finishExport(writer, err, encoder.Close)
+			return
+		}
+
 		user_activities, err := services.GetUserActivities(
 			r.Context(),
-			pool,
-			log,
+			repo,
 			user_activity_filters,
 		)
 		if err != nil {
-			resp := entities.ErrorResponse{Error: err.Error()}
-			w.WriteHeader(http.StatusInternalServerError)
-			json.NewEncoder(w).Encode(resp)
+			writeError(w, err)
 			return
 		}
 		resp := entities.UserActivityResponse{
 			UserId: user_id,
 			Tasks:  user_activities,
 		}
+		if group_by == "project" {
+			resp.Projects = services.GroupUserActivitiesByProject(user_activities)
+		}
 		json.NewEncoder(w).Encode(resp)
 	}
 }
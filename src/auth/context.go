@@ -0,0 +1,14 @@
+package auth
+
+import "context"
+
+type context_key struct{}
+
+func WithUserId(ctx context.Context, user_id int) context.Context {
+	return context.WithValue(ctx, context_key{}, user_id)
+}
+
+func UserIdFromContext(ctx context.Context) (int, bool) {
+	user_id, ok := ctx.Value(context_key{}).(int)
+	return user_id, ok
+}
@@ -0,0 +1,135 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	access_token_type  = "access"
+	refresh_token_type = "refresh"
+)
+
+type Config struct {
+	Secret          []byte
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+}
+
+type TokenManager struct {
+	config Config
+}
+
+type claims struct {
+	TokenType string `json:"typ"`
+	jwt.RegisteredClaims
+}
+
+type RefreshToken struct {
+	Token     string
+	TokenId   string
+	UserId    int
+	ExpiresAt time.Time
+}
+
+func NewTokenManager(config Config) *TokenManager {
+	return &TokenManager{config: config}
+}
+
+func (m *TokenManager) AccessTokenTTL() time.Duration {
+	return m.config.AccessTokenTTL
+}
+
+func (m *TokenManager) NewAccessToken(user_id int) (string, error) {
+	token, _, err := m.newToken(user_id, access_token_type, m.config.AccessTokenTTL)
+	return token, err
+}
+
+func (m *TokenManager) NewRefreshToken(user_id int) (RefreshToken, error) {
+	token, token_claims, err := m.newToken(user_id, refresh_token_type, m.config.RefreshTokenTTL)
+	if err != nil {
+		return RefreshToken{}, err
+	}
+	return RefreshToken{
+		Token:     token,
+		TokenId:   token_claims.ID,
+		UserId:    user_id,
+		ExpiresAt: token_claims.ExpiresAt.Time,
+	}, nil
+}
+
+func (m *TokenManager) ParseAccessToken(token string) (int, error) {
+	token_claims, err := m.parseToken(token, access_token_type)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(token_claims.Subject)
+}
+
+func (m *TokenManager) ParseRefreshToken(token string) (RefreshToken, error) {
+	token_claims, err := m.parseToken(token, refresh_token_type)
+	if err != nil {
+		return RefreshToken{}, err
+	}
+	user_id, err := strconv.Atoi(token_claims.Subject)
+	if err != nil {
+		return RefreshToken{}, err
+	}
+	return RefreshToken{
+		Token:     token,
+		TokenId:   token_claims.ID,
+		UserId:    user_id,
+		ExpiresAt: token_claims.ExpiresAt.Time,
+	}, nil
+}
+
+func (m *TokenManager) newToken(user_id int, token_type string, ttl time.Duration) (string, claims, error) {
+	token_id, err := newTokenId()
+	if err != nil {
+		return "", claims{}, err
+	}
+
+	now := time.Now()
+	token_claims := claims{
+		TokenType: token_type,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        token_id,
+			Subject:   strconv.Itoa(user_id),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		},
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, token_claims).SignedString(m.config.Secret)
+	return token, token_claims, err
+}
+
+func (m *TokenManager) parseToken(token string, token_type string) (claims, error) {
+	var token_claims claims
+	_, err := jwt.ParseWithClaims(
+		token,
+		&token_claims,
+		func(*jwt.Token) (interface{}, error) { return m.config.Secret, nil },
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return claims{}, err
+	}
+	if token_claims.TokenType != token_type {
+		return claims{}, errors.New("unexpected token type")
+	}
+	return token_claims, nil
+}
+
+func newTokenId() (string, error) {
+	bytes := make([]byte, 16)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(bytes), nil
+}
@@ -0,0 +1,119 @@
+package people_info
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"task_tracker/src/utils"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+type Config struct {
+	BaseURL    string
+	Timeout    time.Duration
+	MaxRetries int
+	RetryDelay time.Duration
+}
+
+type People struct {
+	Surname    string `json:"surname"`
+	Name       string `json:"name"`
+	Patronymic string `json:"patronymic"`
+	Address    string `json:"address"`
+}
+
+type Client struct {
+	config      Config
+	http_client *http.Client
+	log         *logrus.Logger
+}
+
+func NewClient(config Config, log *logrus.Logger) *Client {
+	return &Client{
+		config:      config,
+		http_client: &http.Client{Timeout: config.Timeout},
+		log:         log,
+	}
+}
+
+// GetPeopleInfo asks the people-info service for the person with the given
+// passport. Network errors and 5xx responses are retried with a linear
+// backoff, any other non-200 response fails immediately.
+func (c *Client) GetPeopleInfo(ctx context.Context, passport_serie string, passport_number string) (*People, error) {
+	query := url.Values{}
+	query.Set("passportSerie", passport_serie)
+	query.Set("passportNumber", passport_number)
+	request_url := c.config.BaseURL + "/info?" + query.Encode()
+
+	var last_err error
+	for attempt := 0; attempt <= c.config.MaxRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(time.Duration(attempt) * c.config.RetryDelay):
+			}
+		}
+
+		people, retry, err := c.getPeopleInfo(ctx, request_url)
+		if err == nil {
+			return people, nil
+		}
+		last_err = err
+		utils.LoggerFromContext(ctx, c.log).Warnf("Error getting people info (attempt %d): %s", attempt+1, err)
+		if !retry {
+			break
+		}
+	}
+	return nil, last_err
+}
+
+// Ping checks that the service answers at all. Client errors are expected
+// since no passport is given, only network errors and 5xx responses fail.
+func (c *Client) Ping(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.config.BaseURL+"/info", nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.http_client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 500 {
+		return fmt.Errorf("people info service responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func (c *Client) getPeopleInfo(ctx context.Context, request_url string) (*People, bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, request_url, nil)
+	if err != nil {
+		return nil, false, err
+	}
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	resp, err := c.http_client.Do(req)
+	if err != nil {
+		return nil, ctx.Err() == nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, resp.StatusCode >= 500, fmt.Errorf("people info service responded with status %d", resp.StatusCode)
+	}
+
+	var people People
+	if err := json.NewDecoder(resp.Body).Decode(&people); err != nil {
+		return nil, false, err
+	}
+	return &people, false, nil
+}
@@ -0,0 +1,312 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+type Config struct {
+	Server      ServerConfig      `yaml:"server"`
+	Postgres    PostgresConfig    `yaml:"postgres"`
+	Log         LogConfig         `yaml:"log"`
+	Auth        AuthConfig        `yaml:"auth"`
+	PeopleInfo  PeopleInfoConfig  `yaml:"people_info"`
+	Pagination  PaginationConfig  `yaml:"pagination"`
+	Tracing     TracingConfig     `yaml:"tracing"`
+	Passport    PassportConfig    `yaml:"passport"`
+	Retention   RetentionConfig   `yaml:"retention"`
+	Idempotency IdempotencyConfig `yaml:"idempotency"`
+}
+
+type ServerConfig struct {
+	Host            string        `yaml:"host"`
+	Port            int           `yaml:"port"`
+	ReadTimeout     time.Duration `yaml:"read_timeout"`
+	WriteTimeout    time.Duration `yaml:"write_timeout"`
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+	MaxBodyBytes    int           `yaml:"max_body_bytes"`
+	StrictJSON      bool          `yaml:"strict_json"`
+}
+
+type PostgresConfig struct {
+	Host     string `yaml:"host"`
+	Port     int    `yaml:"port"`
+	Database string `yaml:"database"`
+	User     string `yaml:"user"`
+	Password string `yaml:"password"`
+}
+
+type LogConfig struct {
+	Level  string `yaml:"level"`
+	Format string `yaml:"format"`
+}
+
+type AuthConfig struct {
+	JWTSecret       string        `yaml:"jwt_secret"`
+	AccessTokenTTL  time.Duration `yaml:"access_token_ttl"`
+	RefreshTokenTTL time.Duration `yaml:"refresh_token_ttl"`
+}
+
+// Tokens are signed with HS256, which needs a key of at least 256 bits.
+const min_jwt_secret_length = 32
+
+type PeopleInfoConfig struct {
+	URL        string        `yaml:"url"`
+	Timeout    time.Duration `yaml:"timeout"`
+	MaxRetries int           `yaml:"max_retries"`
+	RetryDelay time.Duration `yaml:"retry_delay"`
+}
+
+// PaginationConfig sets page sizes. UsersPerPage is the page size of
+// GET /users when the client does not pass a limit, MaxUsersPerPage is the
+// largest limit accepted.
+type PaginationConfig struct {
+	UsersPerPage       int `yaml:"users_per_page"`
+	MaxUsersPerPage    int `yaml:"max_users_per_page"`
+	AuditEventsPerPage int `yaml:"audit_events_per_page"`
+}
+
+type TracingConfig struct {
+	Exporter     string  `yaml:"exporter"`
+	OTLPEndpoint string  `yaml:"otlp_endpoint"`
+	OTLPInsecure bool    `yaml:"otlp_insecure"`
+	SampleRatio  float64 `yaml:"sample_ratio"`
+	ServiceName  string  `yaml:"service_name"`
+}
+
+type PassportConfig struct {
+	SerieDigits  int `yaml:"serie_digits"`
+	NumberDigits int `yaml:"number_digits"`
+}
+
+// RetentionConfig controls purging of soft deleted users and expired
+// idempotency keys. Users are kept forever when DeletedUsersDays is 0.
+type RetentionConfig struct {
+	DeletedUsersDays int           `yaml:"deleted_users_days"`
+	Interval         time.Duration `yaml:"interval"`
+}
+
+// IdempotencyConfig controls how long responses to requests with an
+// Idempotency-Key header are kept for replays.
+type IdempotencyConfig struct {
+	TTL time.Duration `yaml:"ttl"`
+}
+
+// Passport parts are stored in VARCHAR(16) columns.
+const max_passport_part_digits = 16
+
+const (
+	LogFormatJSON = "json"
+	LogFormatText = "text"
+
+	TracingExporterNone   = "none"
+	TracingExporterStdout = "stdout"
+	TracingExporterOTLP   = "otlp"
+)
+
+func Default() Config {
+	return Config{
+		Server: ServerConfig{
+			Host:            "127.0.0.1",
+			Port:            8080,
+			ReadTimeout:     15 * time.Second,
+			WriteTimeout:    15 * time.Second,
+			ShutdownTimeout: 20 * time.Second,
+			MaxBodyBytes:    1 << 20,
+		},
+		Postgres: PostgresConfig{
+			Host:     "localhost",
+			Port:     5432,
+			Database: "postgres",
+			User:     "postgres",
+		},
+		Log: LogConfig{
+			Level:  "info",
+			Format: LogFormatJSON,
+		},
+		Auth: AuthConfig{
+			AccessTokenTTL:  15 * time.Minute,
+			RefreshTokenTTL: 30 * 24 * time.Hour,
+		},
+		PeopleInfo: PeopleInfoConfig{
+			Timeout:    5 * time.Second,
+			MaxRetries: 2,
+			RetryDelay: 200 * time.Millisecond,
+		},
+		Pagination: PaginationConfig{
+			UsersPerPage:       5,
+			MaxUsersPerPage:    100,
+			AuditEventsPerPage: 50,
+		},
+		Tracing: TracingConfig{
+			Exporter:     TracingExporterNone,
+			OTLPEndpoint: "localhost:4318",
+			OTLPInsecure: true,
+			SampleRatio:  1,
+			ServiceName:  "task_tracker",
+		},
+		Passport: PassportConfig{
+			SerieDigits:  4,
+			NumberDigits: 6,
+		},
+		Retention: RetentionConfig{
+			Interval: time.Hour,
+		},
+		Idempotency: IdempotencyConfig{
+			TTL: 24 * time.Hour,
+		},
+	}
+}
+
+func (c ServerConfig) Address() string {
+	return net.JoinHostPort(c.Host, strconv.Itoa(c.Port))
+}
+
+// URL builds the connection string, escaping credentials so passwords with
+// reserved characters work.
+func (c PostgresConfig) URL() string {
+	postgres_url := url.URL{
+		Scheme: "postgres",
+		User:   url.UserPassword(c.User, c.Password),
+		Host:   net.JoinHostPort(c.Host, strconv.Itoa(c.Port)),
+		Path:   "/" + c.Database,
+	}
+	return postgres_url.String()
+}
+
+// Validate reports every invalid setting at once rather than stopping at the
+// first one.
+func (c Config) Validate() error {
+	var errs []error
+	invalid := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	if c.Server.Port < 1 || c.Server.Port > 65535 {
+		invalid("server.port must be between 1 and 65535, got %d", c.Server.Port)
+	}
+	if c.Server.ReadTimeout <= 0 {
+		invalid("server.read_timeout must be positive")
+	}
+	if c.Server.WriteTimeout <= 0 {
+		invalid("server.write_timeout must be positive")
+	}
+	if c.Server.ShutdownTimeout <= 0 {
+		invalid("server.shutdown_timeout must be positive")
+	}
+	if c.Server.MaxBodyBytes < 1 {
+		invalid("server.max_body_bytes must be positive, got %d", c.Server.MaxBodyBytes)
+	}
+
+	if err := c.Postgres.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+
+	if _, err := logrus.ParseLevel(c.Log.Level); err != nil {
+		invalid("log.level %q is not a valid level", c.Log.Level)
+	}
+	if c.Log.Format != LogFormatJSON && c.Log.Format != LogFormatText {
+		invalid("log.format must be %s or %s, got %q", LogFormatJSON, LogFormatText, c.Log.Format)
+	}
+
+	if c.Auth.JWTSecret == "" {
+		invalid("auth.jwt_secret must be set")
+	} else if len(c.Auth.JWTSecret) < min_jwt_secret_length {
+		invalid("auth.jwt_secret must be at least %d characters", min_jwt_secret_length)
+	}
+	if c.Auth.AccessTokenTTL <= 0 {
+		invalid("auth.access_token_ttl must be positive")
+	}
+	if c.Auth.RefreshTokenTTL <= 0 {
+		invalid("auth.refresh_token_ttl must be positive")
+	}
+
+	if c.PeopleInfo.URL != "" {
+		if _, err := url.ParseRequestURI(c.PeopleInfo.URL); err != nil {
+			invalid("people_info.url %q is not a valid URL", c.PeopleInfo.URL)
+		}
+	}
+	if c.PeopleInfo.Timeout <= 0 {
+		invalid("people_info.timeout must be positive")
+	}
+	if c.PeopleInfo.MaxRetries < 0 {
+		invalid("people_info.max_retries must not be negative")
+	}
+	if c.PeopleInfo.RetryDelay < 0 {
+		invalid("people_info.retry_delay must not be negative")
+	}
+
+	if c.Pagination.UsersPerPage < 1 {
+		invalid("pagination.users_per_page must be positive, got %d", c.Pagination.UsersPerPage)
+	}
+	if c.Pagination.MaxUsersPerPage < c.Pagination.UsersPerPage {
+		invalid(
+			"pagination.max_users_per_page must not be less than pagination.users_per_page, got %d",
+			c.Pagination.MaxUsersPerPage,
+		)
+	}
+	if c.Pagination.AuditEventsPerPage < 1 {
+		invalid("pagination.audit_events_per_page must be positive, got %d", c.Pagination.AuditEventsPerPage)
+	}
+
+	switch c.Tracing.Exporter {
+	case TracingExporterNone, TracingExporterStdout:
+	case TracingExporterOTLP:
+		if c.Tracing.OTLPEndpoint == "" {
+			invalid("tracing.otlp_endpoint must be set for the %s exporter", TracingExporterOTLP)
+		}
+	default:
+		invalid(
+			"tracing.exporter must be %s, %s or %s, got %q",
+			TracingExporterNone, TracingExporterStdout, TracingExporterOTLP, c.Tracing.Exporter,
+		)
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		invalid("tracing.sample_ratio must be between 0 and 1, got %g", c.Tracing.SampleRatio)
+	}
+	if c.Tracing.ServiceName == "" {
+		invalid("tracing.service_name must be set")
+	}
+
+	if c.Passport.SerieDigits < 1 || c.Passport.SerieDigits > max_passport_part_digits {
+		invalid("passport.serie_digits must be between 1 and %d, got %d", max_passport_part_digits, c.Passport.SerieDigits)
+	}
+	if c.Passport.NumberDigits < 1 || c.Passport.NumberDigits > max_passport_part_digits {
+		invalid("passport.number_digits must be between 1 and %d, got %d", max_passport_part_digits, c.Passport.NumberDigits)
+	}
+
+	if c.Retention.DeletedUsersDays < 0 {
+		invalid("retention.deleted_users_days must not be negative")
+	}
+	if c.Retention.Interval <= 0 {
+		invalid("retention.interval must be positive")
+	}
+	if c.Idempotency.TTL <= 0 {
+		invalid("idempotency.ttl must be positive")
+	}
+
+	return errors.Join(errs...)
+}
+
+func (c PostgresConfig) Validate() error {
+	var errs []error
+	if c.Host == "" {
+		errs = append(errs, errors.New("postgres.host must be set"))
+	}
+	if c.Port < 1 || c.Port > 65535 {
+		errs = append(errs, fmt.Errorf("postgres.port must be between 1 and 65535, got %d", c.Port))
+	}
+	if c.Database == "" {
+		errs = append(errs, errors.New("postgres.database must be set"))
+	}
+	if c.User == "" {
+		errs = append(errs, errors.New("postgres.user must be set"))
+	}
+	return errors.Join(errs...)
+}
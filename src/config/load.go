@@ -0,0 +1,220 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+// Load builds the configuration from, in increasing order of precedence,
+// defaults, the YAML file given by -config or CONFIG_FILE, environment
+// variables (including a .env file in the working directory) and command line
+// flags. Flags are parsed until the first non-flag argument, the remaining
+// arguments are returned for subcommands. flag.ErrHelp is returned when -h is
+// given.
+func Load(args []string) (Config, []string, error) {
+	godotenv.Load()
+
+	var config_path string
+	discarded := Default()
+	flags := newFlagSet(&discarded, &config_path)
+	if err := flags.Parse(args); err != nil {
+		return Config{}, nil, err
+	}
+	if config_path == "" {
+		config_path = os.Getenv("CONFIG_FILE")
+	}
+
+	config := Default()
+	if config_path != "" {
+		if err := loadFile(&config, config_path); err != nil {
+			return Config{}, nil, err
+		}
+	}
+
+	env := envLoader{lookup: os.LookupEnv}
+	env.apply(&config)
+	if err := errors.Join(env.errs...); err != nil {
+		return Config{}, nil, err
+	}
+
+	// Flags are parsed again on top of the loaded values, so only the flags
+	// actually given override them.
+	flags = newFlagSet(&config, &config_path)
+	flags.SetOutput(io.Discard)
+	if err := flags.Parse(args); err != nil {
+		return Config{}, nil, err
+	}
+
+	if err := config.Validate(); err != nil {
+		return Config{}, nil, err
+	}
+	return config, flags.Args(), nil
+}
+
+// PostgresFromEnv overrides base with the connection variables starting with
+// prefix, e.g. PG_HOST or TEST_PG_HOST.
+func PostgresFromEnv(prefix string, base PostgresConfig) (PostgresConfig, error) {
+	env := envLoader{lookup: os.LookupEnv}
+	env.postgres(prefix, &base)
+	return base, errors.Join(env.errs...)
+}
+
+func loadFile(config *Config, path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("unable to open config file: %w", err)
+	}
+	defer file.Close()
+
+	decoder := yaml.NewDecoder(file)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(config); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("unable to parse config file %s: %w", path, err)
+	}
+	return nil
+}
+
+func newFlagSet(config *Config, config_path *string) *flag.FlagSet {
+	flags := flag.NewFlagSet("task_tracker", flag.ContinueOnError)
+	flags.StringVar(config_path, "config", *config_path, "path to a YAML config file")
+	flags.StringVar(&config.Server.Host, "http-host", config.Server.Host, "address to listen on")
+	flags.IntVar(&config.Server.Port, "http-port", config.Server.Port, "port to listen on")
+	flags.DurationVar(&config.Server.ReadTimeout, "http-read-timeout", config.Server.ReadTimeout, "HTTP read timeout")
+	flags.DurationVar(&config.Server.WriteTimeout, "http-write-timeout", config.Server.WriteTimeout, "HTTP write timeout")
+	flags.DurationVar(&config.Server.ShutdownTimeout, "shutdown-timeout", config.Server.ShutdownTimeout, "time to drain in-flight requests on shutdown")
+	flags.IntVar(&config.Server.MaxBodyBytes, "http-max-body-bytes", config.Server.MaxBodyBytes, "maximum size of a request body")
+	flags.BoolVar(&config.Server.StrictJSON, "http-strict-json", config.Server.StrictJSON, "reject request bodies with unknown fields")
+	flags.StringVar(&config.Postgres.Host, "pg-host", config.Postgres.Host, "Postgres host")
+	flags.IntVar(&config.Postgres.Port, "pg-port", config.Postgres.Port, "Postgres port")
+	flags.StringVar(&config.Postgres.Database, "pg-database", config.Postgres.Database, "Postgres database")
+	flags.StringVar(&config.Postgres.User, "pg-user", config.Postgres.User, "Postgres user")
+	flags.StringVar(&config.Log.Level, "log-level", config.Log.Level, "log level")
+	flags.StringVar(&config.Log.Format, "log-format", config.Log.Format, "log format, json or text")
+	flags.IntVar(&config.Pagination.UsersPerPage, "users-per-page", config.Pagination.UsersPerPage, "default page size of GET /users")
+	flags.StringVar(&config.Tracing.Exporter, "tracing-exporter", config.Tracing.Exporter, "trace exporter, none, stdout or otlp")
+	flags.StringVar(&config.Tracing.OTLPEndpoint, "tracing-otlp-endpoint", config.Tracing.OTLPEndpoint, "OTLP/HTTP collector host:port")
+	return flags
+}
+
+// envLoader collects parse errors so all bad variables are reported together.
+// Empty variables are treated as unset.
+type envLoader struct {
+	lookup func(name string) (string, bool)
+	errs   []error
+}
+
+func (l *envLoader) apply(config *Config) {
+	l.string("HTTP_HOST", &config.Server.Host)
+	l.int("HTTP_PORT", &config.Server.Port)
+	l.duration("HTTP_READ_TIMEOUT", &config.Server.ReadTimeout)
+	l.duration("HTTP_WRITE_TIMEOUT", &config.Server.WriteTimeout)
+	l.duration("HTTP_SHUTDOWN_TIMEOUT", &config.Server.ShutdownTimeout)
+	l.int("HTTP_MAX_BODY_BYTES", &config.Server.MaxBodyBytes)
+	l.bool("HTTP_STRICT_JSON", &config.Server.StrictJSON)
+
+	l.postgres("PG_", &config.Postgres)
+
+	l.string("LOG_LEVEL", &config.Log.Level)
+	l.string("LOG_FORMAT", &config.Log.Format)
+
+	l.string("JWT_SECRET", &config.Auth.JWTSecret)
+	l.duration("JWT_ACCESS_TOKEN_TTL", &config.Auth.AccessTokenTTL)
+	l.duration("JWT_REFRESH_TOKEN_TTL", &config.Auth.RefreshTokenTTL)
+
+	l.string("PEOPLE_INFO_URL", &config.PeopleInfo.URL)
+	l.duration("PEOPLE_INFO_TIMEOUT", &config.PeopleInfo.Timeout)
+	l.int("PEOPLE_INFO_MAX_RETRIES", &config.PeopleInfo.MaxRetries)
+	l.duration("PEOPLE_INFO_RETRY_DELAY", &config.PeopleInfo.RetryDelay)
+
+	l.int("USERS_PER_PAGE", &config.Pagination.UsersPerPage)
+	l.int("MAX_USERS_PER_PAGE", &config.Pagination.MaxUsersPerPage)
+	l.int("AUDIT_EVENTS_PER_PAGE", &config.Pagination.AuditEventsPerPage)
+
+	l.string("TRACING_EXPORTER", &config.Tracing.Exporter)
+	l.string("TRACING_OTLP_ENDPOINT", &config.Tracing.OTLPEndpoint)
+	l.bool("TRACING_OTLP_INSECURE", &config.Tracing.OTLPInsecure)
+	l.float("TRACING_SAMPLE_RATIO", &config.Tracing.SampleRatio)
+	l.string("TRACING_SERVICE_NAME", &config.Tracing.ServiceName)
+
+	l.int("PASSPORT_SERIE_DIGITS", &config.Passport.SerieDigits)
+	l.int("PASSPORT_NUMBER_DIGITS", &config.Passport.NumberDigits)
+
+	l.int("RETENTION_DELETED_USERS_DAYS", &config.Retention.DeletedUsersDays)
+	l.duration("RETENTION_INTERVAL", &config.Retention.Interval)
+
+	l.duration("IDEMPOTENCY_TTL", &config.Idempotency.TTL)
+}
+
+func (l *envLoader) postgres(prefix string, config *PostgresConfig) {
+	l.string(prefix+"HOST", &config.Host)
+	l.int(prefix+"PORT", &config.Port)
+	l.string(prefix+"DATABASE", &config.Database)
+	l.string(prefix+"USER", &config.User)
+	l.string(prefix+"PASSWORD", &config.Password)
+}
+
+func (l *envLoader) string(name string, target *string) {
+	if value, ok := l.lookup(name); ok && value != "" {
+		*target = value
+	}
+}
+
+func (l *envLoader) int(name string, target *int) {
+	value, ok := l.lookup(name)
+	if !ok || value == "" {
+		return
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		l.errs = append(l.errs, fmt.Errorf("%s must be an integer, got %q", name, value))
+		return
+	}
+	*target = parsed
+}
+
+func (l *envLoader) duration(name string, target *time.Duration) {
+	value, ok := l.lookup(name)
+	if !ok || value == "" {
+		return
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		l.errs = append(l.errs, fmt.Errorf("%s must be a duration like 15s, got %q", name, value))
+		return
+	}
+	*target = parsed
+}
+
+func (l *envLoader) bool(name string, target *bool) {
+	value, ok := l.lookup(name)
+	if !ok || value == "" {
+		return
+	}
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		l.errs = append(l.errs, fmt.Errorf("%s must be true or false, got %q", name, value))
+		return
+	}
+	*target = parsed
+}
+
+func (l *envLoader) float(name string, target *float64) {
+	value, ok := l.lookup(name)
+	if !ok || value == "" {
+		return
+	}
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		l.errs = append(l.errs, fmt.Errorf("%s must be a number, got %q", name, value))
+		return
+	}
+	*target = parsed
+}
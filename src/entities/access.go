@@ -0,0 +1,24 @@
+package entities
+
+const (
+	RoleAdmin   = "admin"
+	RoleManager = "manager"
+	RoleMember  = "member"
+)
+
+type UserAccess struct {
+	UserId    int
+	Role      string
+	ManagerId *int
+}
+
+type SetUserRoleRequest struct {
+	Role      string `json:"role" validate:"required,oneof=admin manager member"`
+	ManagerId *int   `json:"managerId"`
+}
+
+type SetUserRoleResponse struct {
+	UserId    int    `json:"userId"`
+	Role      string `json:"role"`
+	ManagerId *int   `json:"managerId"`
+}
@@ -1,5 +1,17 @@
 package entities
 
-type ErrorResponse struct {
-	Error string `json:"error"`
+type InvalidParam struct {
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+}
+
+// ProblemResponse is an RFC 7807 problem document. Code is a stable machine
+// readable error code, see api_errors.
+type ProblemResponse struct {
+	Type          string         `json:"type"`
+	Title         string         `json:"title"`
+	Status        int            `json:"status"`
+	Detail        string         `json:"detail,omitempty"`
+	Code          string         `json:"code"`
+	InvalidParams []InvalidParam `json:"invalid-params,omitempty"`
 }
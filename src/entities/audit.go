@@ -0,0 +1,57 @@
+package entities
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	AuditEntityUser = "user"
+	AuditEntityTask = "task"
+
+	AuditActionCreate  = "create"
+	AuditActionUpdate  = "update"
+	AuditActionDelete  = "delete"
+	AuditActionRestore = "restore"
+	AuditActionPurge   = "purge"
+	AuditActionSetRole = "set_role"
+	AuditActionFinish  = "finish"
+	AuditActionReopen  = "reopen"
+	AuditActionPause   = "pause"
+	AuditActionResume  = "resume"
+)
+
+// AuditChange holds the JSON encoded values of a field before and after a
+// mutation. Before is null for created fields and After for removed ones.
+type AuditChange struct {
+	Before json.RawMessage `json:"before"`
+	After  json.RawMessage `json:"after"`
+}
+
+type AuditEvent struct {
+	EventId   int                    `json:"eventId"`
+	ActorId   *int                   `json:"actorId"`
+	Action    string                 `json:"action"`
+	Entity    string                 `json:"entity"`
+	EntityId  int                    `json:"entityId"`
+	Changes   map[string]AuditChange `json:"changes"`
+	RequestId string                 `json:"requestId,omitempty"`
+	CreatedAt time.Time              `json:"createdAt"`
+}
+
+type AuditEventsFilter struct {
+	Entity   string
+	EntityId *int
+	ActorId  *int
+	Action   string
+	DateFrom *time.Time
+	DateTo   *time.Time
+	Offset   int
+	Limit    int
+}
+
+type GetAuditEventsResponse struct {
+	Events   []AuditEvent `json:"events"`
+	Page     int          `json:"page"`
+	LastPage int          `json:"lastPage"`
+}
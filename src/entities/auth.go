@@ -0,0 +1,31 @@
+package entities
+
+import "time"
+
+type LoginRequest struct {
+	PassportNumber string `json:"passportNumber" validate:"required"`
+	Password       string `json:"password" validate:"required"`
+}
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refreshToken" validate:"required"`
+}
+
+type TokenResponse struct {
+	AccessToken  string `json:"accessToken"`
+	RefreshToken string `json:"refreshToken"`
+	TokenType    string `json:"tokenType"`
+	ExpiresIn    int    `json:"expiresIn"`
+}
+
+type UserCredentials struct {
+	UserId       int
+	PasswordHash string
+}
+
+type RefreshToken struct {
+	TokenId   string
+	UserId    int
+	ExpiresAt time.Time
+	RevokedAt *time.Time
+}
@@ -0,0 +1,16 @@
+package entities
+
+import "time"
+
+// IdempotencyRecord is the response stored for an Idempotency-Key. Keys are
+// scoped to the user sending them. StatusCode is 0 while the first request
+// with the key is still running.
+type IdempotencyRecord struct {
+	UserId      int
+	Key         string
+	Fingerprint string
+	StatusCode  int
+	Headers     map[string]string
+	Body        []byte
+	ExpiresAt   time.Time
+}
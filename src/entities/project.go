@@ -0,0 +1,18 @@
+package entities
+
+type Project struct {
+	ProjectId   int    `json:"projectId"`
+	ProjectName string `json:"projectName"`
+}
+
+type CreateProjectRequest struct {
+	ProjectName string `json:"projectName" validate:"required"`
+}
+
+type UpdateProjectRequest struct {
+	ProjectName *string `json:"projectName" validate:"required"`
+}
+
+type GetProjectsResponse struct {
+	Projects []Project `json:"projects"`
+}
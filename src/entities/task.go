@@ -3,17 +3,46 @@ package entities
 import "time"
 
 type CreateTaskRequest struct {
-	TaskName string `json:"taskName" validate:"required"`
-	UserId   int    `json:"userId" validate:"required"`
+	TaskName  string `json:"taskName" validate:"required,task_name"`
+	UserId    int    `json:"userId"`
+	ProjectId *int   `json:"projectId"`
 }
 
 type CreateTaskResponse struct {
 	TaskId    int       `json:"taskId"`
 	TaskName  string    `json:"taskName"`
 	UserId    int       `json:"userId"`
+	ProjectId *int      `json:"projectId"`
 	CreatedAt time.Time `json:"createdAt"`
+	Version   int       `json:"-"`
 }
 
 type FinishTaskRequest struct {
 	TaskId int `json:"taskId" validate:"required"`
 }
+
+type Task struct {
+	TaskId    int        `json:"taskId"`
+	UserId    int        `json:"userId"`
+	ProjectId *int       `json:"projectId"`
+	TaskName  string     `json:"taskName"`
+	StartTime time.Time  `json:"startTime"`
+	EndTime   *time.Time `json:"endTime"`
+	Version   int        `json:"-"`
+}
+
+type UpdateTaskRequest struct {
+	TaskName *string `json:"taskName" validate:"required,task_name"`
+}
+
+type TasksFilter struct {
+	UserId     *int
+	ProjectId  *int
+	IsFinished *bool
+	DateFrom   *time.Time
+	DateTo     *time.Time
+}
+
+type GetTasksResponse struct {
+	Tasks []Task `json:"tasks"`
+}
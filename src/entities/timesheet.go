@@ -0,0 +1,58 @@
+package entities
+
+import "time"
+
+// TimesheetPeriod is a range of whole days [From, To) in Location.
+type TimesheetPeriod struct {
+	From     time.Time
+	To       time.Time
+	Location *time.Location
+}
+
+type TaskIntervalsFilter struct {
+	UserIds []int
+	From    time.Time
+	To      time.Time
+}
+
+// TaskInterval is a single worked interval. Intervals that are still open end
+// at the current time.
+type TaskInterval struct {
+	TaskId    int
+	UserId    int
+	TaskName  string
+	StartTime time.Time
+	EndTime   time.Time
+}
+
+type TimesheetTask struct {
+	TaskId       int    `json:"taskId"`
+	TaskName     string `json:"taskName"`
+	Minutes      []int  `json:"minutes"`
+	TotalMinutes int    `json:"totalMinutes"`
+}
+
+type Timesheet struct {
+	UserId       int             `json:"userId"`
+	Timezone     string          `json:"timezone"`
+	Days         []string        `json:"days"`
+	Tasks        []TimesheetTask `json:"tasks"`
+	DayTotals    []int           `json:"dayTotals"`
+	TotalMinutes int             `json:"totalMinutes"`
+}
+
+// TimesheetRow is a line of an exported timesheet. The row with a nil TaskId
+// holds the day totals of the user.
+type TimesheetRow struct {
+	UserId       int
+	TaskId       *int
+	TaskName     string
+	Minutes      []int
+	TotalMinutes int
+}
+
+type TeamTimesheet struct {
+	Timezone   string      `json:"timezone"`
+	Days       []string    `json:"days"`
+	Timesheets []Timesheet `json:"timesheets"`
+}
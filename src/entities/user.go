@@ -1,50 +1,161 @@
 package entities
 
+import "time"
+
 type User struct {
 	Id             int
-	PassportSerie  int
-	PassportNumber int
+	PassportSerie  string
+	PassportNumber string
 	Surname        string
 	Name           string
+	Patronymic     string
+	Address        string
+	CreatedAt      time.Time
+	Version        int `json:"-"`
 }
 
 type UserCreateRequest struct {
-	PassportNumber string `json:"passportNumber" validate:"required"`
+	PassportNumber string `json:"passportNumber" validate:"required,passport"`
 	Name           string `json:"name"`
 	Surname        string `json:"surname"`
+	Patronymic     string `json:"patronymic"`
+	Address        string `json:"address"`
+	Password       string `json:"password"`
 }
 
 type UserCreateResponse struct {
-	Id             int    `json:"id"`
-	PassportSerie  int    `json:"passportSerie"`
-	PassportNumber int    `json:"passportNumber"`
-	Surname        string `json:"surname"`
-	Name           string `json:"name"`
+	Id             int       `json:"id"`
+	PassportSerie  string    `json:"passportSerie"`
+	PassportNumber string    `json:"passportNumber"`
+	Surname        string    `json:"surname"`
+	Name           string    `json:"name"`
+	Patronymic     string    `json:"patronymic"`
+	Address        string    `json:"address"`
+	CreatedAt      time.Time `json:"-"`
+	Version        int       `json:"-"`
 }
 
 type UserUpdateRequest struct {
-	PassportNumber *string `json:"passportNumber"`
+	PassportNumber *string `json:"passportNumber" validate:"omitempty,passport"`
 	Surname        *string `json:"surname"`
 	Name           *string `json:"name"`
+	Patronymic     *string `json:"patronymic"`
+	Address        *string `json:"address"`
 }
 
 type UserUpdateRepo struct {
-	PassportSerie  *int
-	PassportNumber *int
+	PassportSerie  *string
+	PassportNumber *string
 	Surname        *string
 	Name           *string
+	Patronymic     *string
+	Address        *string
 }
 
 type UserUpdateResponse struct {
-	Id             int    `json:"id"`
-	PassportSerie  int    `json:"passportSerie"`
-	PassportNumber int    `json:"passportNumber"`
-	Surname        string `json:"surname"`
-	Name           string `json:"name"`
+	Id             int       `json:"id"`
+	PassportSerie  string    `json:"passportSerie"`
+	PassportNumber string    `json:"passportNumber"`
+	Surname        string    `json:"surname"`
+	Name           string    `json:"name"`
+	Patronymic     string    `json:"patronymic"`
+	Address        string    `json:"address"`
+	CreatedAt      time.Time `json:"-"`
+	Version        int       `json:"-"`
+}
+
+type GetUserResponse struct {
+	Id             int       `json:"id"`
+	PassportSerie  string    `json:"passportSerie"`
+	PassportNumber string    `json:"passportNumber"`
+	Surname        string    `json:"surname"`
+	Name           string    `json:"name"`
+	Patronymic     string    `json:"patronymic"`
+	Address        string    `json:"address"`
+	CreatedAt      time.Time `json:"-"`
+	Version        int       `json:"-"`
+}
+
+// Columns GET /users can be sorted by.
+const (
+	UsersSortId        = "id"
+	UsersSortSurname   = "surname"
+	UsersSortName      = "name"
+	UsersSortCreatedAt = "createdAt"
+)
+
+type UsersFilter struct {
+	SurnamePrefix string     `json:"surname,omitempty"`
+	NamePrefix    string     `json:"name,omitempty"`
+	PassportSerie string     `json:"passportSerie,omitempty"`
+	CreatedFrom   *time.Time `json:"createdFrom,omitempty"`
+	CreatedTo     *time.Time `json:"createdTo,omitempty"`
+}
+
+// UserPosition is the place of a user in a sorted list. Key is the value of
+// the sort column, empty when sorting by id, which always breaks ties.
+type UserPosition struct {
+	Key string `json:"key,omitempty"`
+	Id  int    `json:"id"`
+}
+
+func UserPositionOf(user User, sort string) UserPosition {
+	position := UserPosition{Id: user.Id}
+	switch sort {
+	case UsersSortSurname:
+		position.Key = user.Surname
+	case UsersSortName:
+		position.Key = user.Name
+	case UsersSortCreatedAt:
+		position.Key = user.CreatedAt.UTC().Format(time.RFC3339Nano)
+	}
+	return position
+}
+
+// UsersQuery selects active users matching Filter, sorted by Sort and
+// starting after the After position. A Limit of 0 returns all of them.
+type UsersQuery struct {
+	Filter     UsersFilter
+	Sort       string
+	Descending bool
+	After      *UserPosition
+	Limit      int
+}
+
+// UsersPageRequest asks for the page of users next to Position, the page
+// before it when Backward is set. The next and prev cursors of GET /users
+// are encoded page requests without the limit.
+type UsersPageRequest struct {
+	Filter     UsersFilter   `json:"filter"`
+	Sort       string        `json:"sort"`
+	Descending bool          `json:"desc,omitempty"`
+	Position   *UserPosition `json:"position,omitempty"`
+	Backward   bool          `json:"backward,omitempty"`
+	Limit      int           `json:"-"`
+}
+
+type UsersPage struct {
+	Users []User
+	Next  *UsersPageRequest
+	Prev  *UsersPageRequest
+}
+
+// UserListItem is a user in the GET /users list, which unlike a single user
+// shows when they were created.
+type UserListItem struct {
+	Id             int       `json:"id"`
+	PassportSerie  string    `json:"passportSerie"`
+	PassportNumber string    `json:"passportNumber"`
+	Surname        string    `json:"surname"`
+	Name           string    `json:"name"`
+	Patronymic     string    `json:"patronymic"`
+	Address        string    `json:"address"`
+	CreatedAt      time.Time `json:"createdAt"`
+	Version        int       `json:"-"`
 }
 
 type GetUsersResponse struct {
-	Users    []User `json:"users"`
-	Page     int    `json:"page"`
-	LastPage int    `json:"lastPage"`
+	Users []UserListItem `json:"users"`
+	Next  string         `json:"next,omitempty"`
+	Prev  string         `json:"prev,omitempty"`
 }
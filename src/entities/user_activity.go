@@ -3,11 +3,22 @@ package entities
 import "time"
 
 type UserActivityTask struct {
-	TaskID     int    `json:"task_id"`
-	TaskName   string `json:"task_name"`
-	Hours      int    `json:"hours"`
-	Minutes    int    `json:"minutes"`
-	IsFinished bool   `json:"is_finished"`
+	TaskID      int     `json:"task_id"`
+	TaskName    string  `json:"task_name"`
+	ProjectId   *int    `json:"project_id"`
+	ProjectName *string `json:"project_name"`
+	Hours       int     `json:"hours"`
+	Minutes     int     `json:"minutes"`
+	IsFinished  bool    `json:"is_finished"`
+	IsPaused    bool    `json:"is_paused"`
+}
+
+type UserActivityProject struct {
+	ProjectId   *int               `json:"project_id"`
+	ProjectName *string            `json:"project_name"`
+	Hours       int                `json:"hours"`
+	Minutes     int                `json:"minutes"`
+	Tasks       []UserActivityTask `json:"tasks"`
 }
 
 type UserActivityRequest struct {
@@ -17,6 +28,7 @@ type UserActivityRequest struct {
 }
 
 type UserActivityResponse struct {
-	UserId int                `json:"userId"`
-	Tasks  []UserActivityTask `json:"tasks"`
+	UserId   int                   `json:"userId"`
+	Tasks    []UserActivityTask    `json:"tasks"`
+	Projects []UserActivityProject `json:"projects,omitempty"`
 }
@@ -0,0 +1,17 @@
+package entities
+
+// VersionPrecondition holds the versions listed in an If-Match header. It is
+// nil when a request has no precondition or accepts any version.
+type VersionPrecondition []int
+
+func (p VersionPrecondition) Allows(version int) bool {
+	if p == nil {
+		return true
+	}
+	for _, allowed_version := range p {
+		if allowed_version == version {
+			return true
+		}
+	}
+	return false
+}
@@ -1,13 +1,51 @@
 package api_errors
 
+import (
+	"errors"
+	"net/http"
+	"task_tracker/src/entities"
+	"task_tracker/src/errors/repo_errors"
+)
+
+// Stable error codes returned in the code member of problem responses.
+// Clients may rely on them, so they must never change meaning.
+const (
+	CodeValidationFailed   = "validation_failed"
+	CodeNotFound           = "not_found"
+	CodeConflict           = "conflict"
+	CodeUnauthorized       = "unauthorized"
+	CodeForbidden          = "forbidden"
+	CodeNotAcceptable      = "not_acceptable"
+	CodePayloadTooLarge    = "payload_too_large"
+	CodePreconditionFailed = "precondition_failed"
+	CodeUnprocessable      = "unprocessable_entity"
+	CodeInternal           = "internal"
+)
+
+// Problem is implemented by every API error and tells how it is reported as
+// an RFC 7807 problem.
+type Problem interface {
+	error
+	Status() int
+	Code() string
+	Title() string
+	ProblemDetail() string
+}
+
 type InternalServerError struct{}
 
 func (e InternalServerError) Error() string {
 	return "Internal server error"
 }
 
+func (e InternalServerError) Status() int           { return http.StatusInternalServerError }
+func (e InternalServerError) Code() string          { return CodeInternal }
+func (e InternalServerError) Title() string         { return "Internal Server Error" }
+func (e InternalServerError) ProblemDetail() string { return "" }
+
 type BadRequestError struct {
-	Detail string
+	Detail        string
+	InvalidParams []entities.InvalidParam
 }
 
 func (e BadRequestError) Error() string {
@@ -17,3 +55,161 @@ func (e BadRequestError) Error() string {
 	}
 	return message
 }
+
+func (e BadRequestError) Status() int           { return http.StatusBadRequest }
+func (e BadRequestError) Code() string          { return CodeValidationFailed }
+func (e BadRequestError) Title() string         { return "Bad Request" }
+func (e BadRequestError) ProblemDetail() string { return e.Detail }
+
+func (e BadRequestError) InvalidParameters() []entities.InvalidParam {
+	return e.InvalidParams
+}
+
+type NotFoundError struct {
+	Detail string
+}
+
+func (e NotFoundError) Error() string {
+	message := "Not Found"
+	if e.Detail != "" {
+		message += ": " + e.Detail
+	}
+	return message
+}
+
+func (e NotFoundError) Status() int           { return http.StatusNotFound }
+func (e NotFoundError) Code() string          { return CodeNotFound }
+func (e NotFoundError) Title() string         { return "Not Found" }
+func (e NotFoundError) ProblemDetail() string { return e.Detail }
+
+type ConflictError struct {
+	Detail string
+}
+
+func (e ConflictError) Error() string {
+	message := "Conflict"
+	if e.Detail != "" {
+		message += ": " + e.Detail
+	}
+	return message
+}
+
+func (e ConflictError) Status() int           { return http.StatusConflict }
+func (e ConflictError) Code() string          { return CodeConflict }
+func (e ConflictError) Title() string         { return "Conflict" }
+func (e ConflictError) ProblemDetail() string { return e.Detail }
+
+type UnauthorizedError struct {
+	Detail string
+}
+
+func (e UnauthorizedError) Error() string {
+	message := "Unauthorized"
+	if e.Detail != "" {
+		message += ": " + e.Detail
+	}
+	return message
+}
+
+func (e UnauthorizedError) Status() int           { return http.StatusUnauthorized }
+func (e UnauthorizedError) Code() string          { return CodeUnauthorized }
+func (e UnauthorizedError) Title() string         { return "Unauthorized" }
+func (e UnauthorizedError) ProblemDetail() string { return e.Detail }
+
+type ForbiddenError struct {
+	Detail string
+}
+
+func (e ForbiddenError) Error() string {
+	message := "Forbidden"
+	if e.Detail != "" {
+		message += ": " + e.Detail
+	}
+	return message
+}
+
+func (e ForbiddenError) Status() int           { return http.StatusForbidden }
+func (e ForbiddenError) Code() string          { return CodeForbidden }
+func (e ForbiddenError) Title() string         { return "Forbidden" }
+func (e ForbiddenError) ProblemDetail() string { return e.Detail }
+
+type NotAcceptableError struct {
+	Detail string
+}
+
+func (e NotAcceptableError) Error() string {
+	message := "Not Acceptable"
+	if e.Detail != "" {
+		message += ": " + e.Detail
+	}
+	return message
+}
+
+func (e NotAcceptableError) Status() int           { return http.StatusNotAcceptable }
+func (e NotAcceptableError) Code() string          { return CodeNotAcceptable }
+func (e NotAcceptableError) Title() string         { return "Not Acceptable" }
+func (e NotAcceptableError) ProblemDetail() string { return e.Detail }
+
+type PayloadTooLargeError struct {
+	Detail string
+}
+
+func (e PayloadTooLargeError) Error() string {
+	message := "Payload Too Large"
+	if e.Detail != "" {
+		message += ": " + e.Detail
+	}
+	return message
+}
+
+func (e PayloadTooLargeError) Status() int           { return http.StatusRequestEntityTooLarge }
+func (e PayloadTooLargeError) Code() string          { return CodePayloadTooLarge }
+func (e PayloadTooLargeError) Title() string         { return "Payload Too Large" }
+func (e PayloadTooLargeError) ProblemDetail() string { return e.Detail }
+
+type PreconditionFailedError struct {
+	Detail string
+}
+
+func (e PreconditionFailedError) Error() string {
+	message := "Precondition Failed"
+	if e.Detail != "" {
+		message += ": " + e.Detail
+	}
+	return message
+}
+
+func (e PreconditionFailedError) Status() int           { return http.StatusPreconditionFailed }
+func (e PreconditionFailedError) Code() string          { return CodePreconditionFailed }
+func (e PreconditionFailedError) Title() string         { return "Precondition Failed" }
+func (e PreconditionFailedError) ProblemDetail() string { return e.Detail }
+
+type UnprocessableEntityError struct {
+	Detail string
+}
+
+func (e UnprocessableEntityError) Error() string {
+	message := "Unprocessable Entity"
+	if e.Detail != "" {
+		message += ": " + e.Detail
+	}
+	return message
+}
+
+func (e UnprocessableEntityError) Status() int           { return http.StatusUnprocessableEntity }
+func (e UnprocessableEntityError) Code() string          { return CodeUnprocessable }
+func (e UnprocessableEntityError) Title() string         { return "Unprocessable Entity" }
+func (e UnprocessableEntityError) ProblemDetail() string { return e.Detail }
+
+// FromRepoError translates a repository error into the API error with the
+// matching code: missing objects are not found, duplicates and objects in the
+// wrong state are conflicts and anything else is internal.
+func FromRepoError(err error, detail string) error {
+	if errors.Is(err, repo_errors.ObjectNotFoundError{}) {
+		return &NotFoundError{Detail: detail}
+	}
+	if errors.Is(err, repo_errors.ObjectAlreadyExistsError{}) || errors.Is(err, repo_errors.InvalidStateError{}) {
+		return &ConflictError{Detail: detail}
+	}
+	return &InternalServerError{}
+}
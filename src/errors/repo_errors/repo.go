@@ -1,5 +1,7 @@
 package repo_errors
 
+import "fmt"
+
 type OperationError struct{}
 
 func (e OperationError) Error() string {
@@ -17,3 +19,18 @@ type ObjectAlreadyExistsError struct{}
 func (e ObjectAlreadyExistsError) Error() string {
 	return "Object already exists"
 }
+
+type InvalidStateError struct{}
+
+func (e InvalidStateError) Error() string {
+	return "Object is in invalid state for this operation"
+}
+
+type SchemaVersionError struct {
+	Current  int
+	Expected int
+}
+
+func (e SchemaVersionError) Error() string {
+	return fmt.Sprintf("Schema version is %d, expected %d", e.Current, e.Expected)
+}
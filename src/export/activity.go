@@ -0,0 +1,52 @@
+package export
+
+import (
+	"io"
+	"strconv"
+	"task_tracker/src/entities"
+)
+
+var activity_columns = []string{
+	"task_id", "task_name", "project_id", "project_name", "hours", "minutes", "is_finished", "is_paused",
+}
+
+type ActivityEncoder struct {
+	rows *rowEncoder
+}
+
+func NewActivityEncoder(w io.Writer, format Format) *ActivityEncoder {
+	return &ActivityEncoder{rows: newRowEncoder(w, format, activity_columns)}
+}
+
+func (e *ActivityEncoder) Encode(task entities.UserActivityTask) error {
+	values := []string{
+		strconv.Itoa(task.TaskID),
+		task.TaskName,
+		formatOptionalInt(task.ProjectId),
+		formatOptionalString(task.ProjectName),
+		strconv.Itoa(task.Hours),
+		strconv.Itoa(task.Minutes),
+		strconv.FormatBool(task.IsFinished),
+		strconv.FormatBool(task.IsPaused),
+	}
+	return e.rows.encode(values, task)
+}
+
+// Close writes the CSV header if there were no rows and flushes the output.
+func (e *ActivityEncoder) Close() error {
+	return e.rows.close()
+}
+
+func formatOptionalInt(value *int) string {
+	if value == nil {
+		return ""
+	}
+	return strconv.Itoa(*value)
+}
+
+func formatOptionalString(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
@@ -0,0 +1,84 @@
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strings"
+)
+
+// utf8_bom lets spreadsheet applications detect the encoding of CSV files.
+const utf8_bom = "\ufeff"
+
+// rowEncoder writes rows as CSV or JSON Lines. Nothing is written until the
+// first row or close, so callers can still report errors that happen before
+// any output.
+type rowEncoder struct {
+	w            io.Writer
+	format       Format
+	columns      []string
+	csv_writer   *csv.Writer
+	json_encoder *json.Encoder
+	started      bool
+}
+
+func newRowEncoder(w io.Writer, format Format, columns []string) *rowEncoder {
+	encoder := &rowEncoder{w: w, format: format, columns: columns}
+	if format == FormatCSV {
+		encoder.csv_writer = csv.NewWriter(w)
+		encoder.csv_writer.UseCRLF = true
+	} else {
+		encoder.json_encoder = json.NewEncoder(w)
+	}
+	return encoder
+}
+
+func (e *rowEncoder) start() error {
+	if e.started {
+		return nil
+	}
+	e.started = true
+	if e.format != FormatCSV {
+		return nil
+	}
+	if _, err := io.WriteString(e.w, utf8_bom); err != nil {
+		return err
+	}
+	return e.csv_writer.Write(e.columns)
+}
+
+// encode writes values as a CSV record or object as a JSON line.
+func (e *rowEncoder) encode(values []string, object interface{}) error {
+	if err := e.start(); err != nil {
+		return err
+	}
+	if e.format != FormatCSV {
+		return e.json_encoder.Encode(object)
+	}
+
+	record := make([]string, len(values))
+	for i, value := range values {
+		record[i] = escapeFormula(value)
+	}
+	return e.csv_writer.Write(record)
+}
+
+func (e *rowEncoder) close() error {
+	if err := e.start(); err != nil {
+		return err
+	}
+	if e.csv_writer != nil {
+		e.csv_writer.Flush()
+		return e.csv_writer.Error()
+	}
+	return nil
+}
+
+// escapeFormula prevents spreadsheet applications from evaluating user input
+// such as task names as formulas.
+func escapeFormula(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
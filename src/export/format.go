@@ -0,0 +1,70 @@
+package export
+
+import (
+	"mime"
+	"strings"
+)
+
+type Format string
+
+const (
+	FormatJSON  Format = "json"
+	FormatCSV   Format = "csv"
+	FormatJSONL Format = "jsonl"
+)
+
+var media_types = map[string]Format{
+	"application/json":      FormatJSON,
+	"text/csv":              FormatCSV,
+	"application/x-ndjson":  FormatJSONL,
+	"application/jsonl":     FormatJSONL,
+	"application/jsonlines": FormatJSONL,
+}
+
+// ParseFormat parses the value of a format query parameter.
+func ParseFormat(name string) (Format, bool) {
+	switch Format(strings.ToLower(name)) {
+	case FormatJSON:
+		return FormatJSON, true
+	case FormatCSV:
+		return FormatCSV, true
+	case FormatJSONL, "ndjson":
+		return FormatJSONL, true
+	}
+	return "", false
+}
+
+// FormatFromAccept picks the first supported media type of an Accept header.
+// Media ranges with q=0 are skipped and wildcards select JSON.
+func FormatFromAccept(accept string) (Format, bool) {
+	if strings.TrimSpace(accept) == "" {
+		return FormatJSON, true
+	}
+	for _, media_range := range strings.Split(accept, ",") {
+		media_type, params, err := mime.ParseMediaType(strings.TrimSpace(media_range))
+		if err != nil || params["q"] == "0" {
+			continue
+		}
+		if format, ok := media_types[media_type]; ok {
+			return format, true
+		}
+		if media_type == "*/*" || media_type == "application/*" {
+			return FormatJSON, true
+		}
+	}
+	return "", false
+}
+
+func ContentType(format Format) string {
+	switch format {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatJSONL:
+		return "application/x-ndjson"
+	}
+	return "application/json"
+}
+
+func FileExtension(format Format) string {
+	return string(format)
+}
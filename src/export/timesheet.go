@@ -0,0 +1,50 @@
+package export
+
+import (
+	"io"
+	"strconv"
+	"task_tracker/src/entities"
+)
+
+type timesheetLine struct {
+	UserId       int            `json:"userId"`
+	TaskId       *int           `json:"taskId"`
+	TaskName     string         `json:"taskName"`
+	Minutes      map[string]int `json:"minutes"`
+	TotalMinutes int            `json:"totalMinutes"`
+}
+
+// TimesheetEncoder writes one row per user and task with a column per day.
+type TimesheetEncoder struct {
+	rows *rowEncoder
+	days []string
+}
+
+func NewTimesheetEncoder(w io.Writer, format Format, days []string) *TimesheetEncoder {
+	columns := []string{"user_id", "task_id", "task_name"}
+	columns = append(columns, days...)
+	columns = append(columns, "total_minutes")
+	return &TimesheetEncoder{rows: newRowEncoder(w, format, columns), days: days}
+}
+
+func (e *TimesheetEncoder) Encode(row entities.TimesheetRow) error {
+	values := []string{strconv.Itoa(row.UserId), formatOptionalInt(row.TaskId), row.TaskName}
+	line := timesheetLine{
+		UserId:       row.UserId,
+		TaskId:       row.TaskId,
+		TaskName:     row.TaskName,
+		Minutes:      map[string]int{},
+		TotalMinutes: row.TotalMinutes,
+	}
+	for i, day := range e.days {
+		values = append(values, strconv.Itoa(row.Minutes[i]))
+		line.Minutes[day] = row.Minutes[i]
+	}
+	values = append(values, strconv.Itoa(row.TotalMinutes))
+	return e.rows.encode(values, line)
+}
+
+// Close writes the CSV header if there were no rows and flushes the output.
+func (e *TimesheetEncoder) Close() error {
+	return e.rows.close()
+}
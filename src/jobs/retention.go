@@ -0,0 +1,47 @@
+package jobs
+
+import (
+	"context"
+	"task_tracker/src/config"
+	"task_tracker/src/repository"
+	"task_tracker/src/services"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// RunRetention purges deleted users and expired idempotency keys on every
+// tick until ctx is done. Deleted users are kept when their retention is
+// disabled.
+func RunRetention(
+	ctx context.Context,
+	repo repository.RetentionRepository,
+	retention_config config.RetentionConfig,
+	log *logrus.Logger,
+) {
+	ticker := time.NewTicker(retention_config.Interval)
+	defer ticker.Stop()
+	for {
+		if retention_config.DeletedUsersDays > 0 {
+			purged, err := services.PurgeDeletedUsers(ctx, repo, retention_config.DeletedUsersDays, time.Now())
+			if err != nil {
+				log.Error("Error purging deleted users: ", err)
+			} else if purged > 0 {
+				log.Infof("Purged %d deleted users", purged)
+			}
+		}
+
+		purged, err := services.PurgeExpiredIdempotencyKeys(ctx, repo, time.Now())
+		if err != nil {
+			log.Error("Error purging idempotency keys: ", err)
+		} else if purged > 0 {
+			log.Infof("Purged %d expired idempotency keys", purged)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
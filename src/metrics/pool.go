@@ -0,0 +1,81 @@
+package metrics
+
+import (
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	pool_acquire_count = prometheus.NewDesc(
+		namespace+"_db_pool_acquire_total",
+		"Successful connection acquires from the pool.",
+		nil, nil,
+	)
+	pool_acquire_duration = prometheus.NewDesc(
+		namespace+"_db_pool_acquire_wait_seconds_total",
+		"Total time spent waiting for a connection from the pool.",
+		nil, nil,
+	)
+	pool_empty_acquire_count = prometheus.NewDesc(
+		namespace+"_db_pool_empty_acquire_total",
+		"Acquires that had to wait because the pool was empty.",
+		nil, nil,
+	)
+	pool_canceled_acquire_count = prometheus.NewDesc(
+		namespace+"_db_pool_canceled_acquire_total",
+		"Acquires canceled by their context while waiting.",
+		nil, nil,
+	)
+	pool_acquired_conns = prometheus.NewDesc(
+		namespace+"_db_pool_acquired_connections",
+		"Connections currently in use.",
+		nil, nil,
+	)
+	pool_idle_conns = prometheus.NewDesc(
+		namespace+"_db_pool_idle_connections",
+		"Idle connections in the pool.",
+		nil, nil,
+	)
+	pool_total_conns = prometheus.NewDesc(
+		namespace+"_db_pool_connections",
+		"Connections in the pool, including ones being established.",
+		nil, nil,
+	)
+	pool_max_conns = prometheus.NewDesc(
+		namespace+"_db_pool_max_connections",
+		"Maximum size of the pool.",
+		nil, nil,
+	)
+)
+
+// PoolCollector reads pgxpool statistics on every scrape.
+type PoolCollector struct {
+	pool *pgxpool.Pool
+}
+
+func NewPoolCollector(pool *pgxpool.Pool) *PoolCollector {
+	return &PoolCollector{pool: pool}
+}
+
+func (c *PoolCollector) Describe(descs chan<- *prometheus.Desc) {
+	descs <- pool_acquire_count
+	descs <- pool_acquire_duration
+	descs <- pool_empty_acquire_count
+	descs <- pool_canceled_acquire_count
+	descs <- pool_acquired_conns
+	descs <- pool_idle_conns
+	descs <- pool_total_conns
+	descs <- pool_max_conns
+}
+
+func (c *PoolCollector) Collect(metrics chan<- prometheus.Metric) {
+	stat := c.pool.Stat()
+	metrics <- prometheus.MustNewConstMetric(pool_acquire_count, prometheus.CounterValue, float64(stat.AcquireCount()))
+	metrics <- prometheus.MustNewConstMetric(pool_acquire_duration, prometheus.CounterValue, stat.AcquireDuration().Seconds())
+	metrics <- prometheus.MustNewConstMetric(pool_empty_acquire_count, prometheus.CounterValue, float64(stat.EmptyAcquireCount()))
+	metrics <- prometheus.MustNewConstMetric(pool_canceled_acquire_count, prometheus.CounterValue, float64(stat.CanceledAcquireCount()))
+	metrics <- prometheus.MustNewConstMetric(pool_acquired_conns, prometheus.GaugeValue, float64(stat.AcquiredConns()))
+	metrics <- prometheus.MustNewConstMetric(pool_idle_conns, prometheus.GaugeValue, float64(stat.IdleConns()))
+	metrics <- prometheus.MustNewConstMetric(pool_total_conns, prometheus.GaugeValue, float64(stat.TotalConns()))
+	metrics <- prometheus.MustNewConstMetric(pool_max_conns, prometheus.GaugeValue, float64(stat.MaxConns()))
+}
@@ -0,0 +1,102 @@
+package passport
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"task_tracker/src/config"
+)
+
+var format atomic.Pointer[config.PassportConfig]
+
+func init() {
+	default_format := config.Default().Passport
+	format.Store(&default_format)
+}
+
+// SetFormat sets the number of digits Parse expects in a serie and a number.
+func SetFormat(passport_config config.PassportConfig) {
+	format.Store(&passport_config)
+}
+
+type ParseError struct {
+	Detail string
+}
+
+func (e ParseError) Error() string {
+	return e.Detail
+}
+
+// Parse splits a passport written as a serie followed by a number into its
+// digit strings, keeping leading zeros. Parts may be divided with spaces or
+// dashes and the serie may be split itself, as in "12 34 567890". Without
+// separators the value is split by the configured serie length.
+func Parse(value string) (serie string, number string, err error) {
+	current_format := format.Load()
+
+	parts, err := splitParts(value)
+	if err != nil {
+		return "", "", err
+	}
+
+	if len(parts) == 1 {
+		digits := parts[0]
+		if len(digits) != current_format.SerieDigits+current_format.NumberDigits {
+			return "", "", ParseError{Detail: fmt.Sprintf(
+				"must have %d digits when written without separators, got %d",
+				current_format.SerieDigits+current_format.NumberDigits, len(digits),
+			)}
+		}
+		return digits[:current_format.SerieDigits], digits[current_format.SerieDigits:], nil
+	}
+
+	serie = strings.Join(parts[:len(parts)-1], "")
+	number = parts[len(parts)-1]
+	if len(serie) != current_format.SerieDigits {
+		return "", "", ParseError{Detail: fmt.Sprintf("serie must have %d digits, got %d", current_format.SerieDigits, len(serie))}
+	}
+	if len(number) != current_format.NumberDigits {
+		return "", "", ParseError{Detail: fmt.Sprintf("number must have %d digits, got %d", current_format.NumberDigits, len(number))}
+	}
+	return serie, number, nil
+}
+
+// ParseSerie normalizes a serie alone the way Parse does, so it can be
+// compared with stored ones.
+func ParseSerie(value string) (string, error) {
+	parts, err := splitParts(value)
+	if err != nil {
+		return "", err
+	}
+	serie := strings.Join(parts, "")
+	if serie_digits := format.Load().SerieDigits; len(serie) != serie_digits {
+		return "", ParseError{Detail: fmt.Sprintf("serie must have %d digits, got %d", serie_digits, len(serie))}
+	}
+	return serie, nil
+}
+
+// splitParts returns the digit groups of value.
+func splitParts(value string) ([]string, error) {
+	value = strings.TrimSpace(value)
+	if strings.HasPrefix(value, "-") || strings.HasSuffix(value, "-") {
+		return nil, ParseError{Detail: "must start and end with a digit"}
+	}
+	for _, char := range value {
+		if !isDigit(char) && !isSeparator(char) {
+			return nil, ParseError{Detail: fmt.Sprintf("must contain only digits, spaces and dashes, got %q", char)}
+		}
+	}
+	parts := strings.FieldsFunc(value, isSeparator)
+	if len(parts) == 0 {
+		return nil, ParseError{Detail: "must not be empty"}
+	}
+	return parts, nil
+}
+
+func isDigit(char rune) bool {
+	return char >= '0' && char <= '9'
+}
+
+func isSeparator(char rune) bool {
+	return char == ' ' || char == '-' || char == '\t'
+}
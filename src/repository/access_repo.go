@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"task_tracker/src/entities"
+	"task_tracker/src/errors/repo_errors"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+)
+
+func (r *PostgresRepository) GetUserAccess(ctx context.Context, user_id int) (entities.UserAccess, error) {
+	conn, release, err := r.acquire(ctx)
+	if err != nil {
+		r.logger(ctx).Error("Error with acquiring connection:", err)
+		return entities.UserAccess{}, repo_errors.OperationError{}
+	}
+	defer release()
+
+	var access entities.UserAccess
+	err = conn.QueryRow(
+		ctx,
+		`SELECT user_id, role, manager_id
+		FROM users
+		WHERE user_id=$1 AND deleted_at IS NULL`,
+		user_id,
+	).Scan(&access.UserId, &access.Role, &access.ManagerId)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return entities.UserAccess{}, repo_errors.ObjectNotFoundError{}
+		}
+		r.logger(ctx).Error("Error getting user access: ", err)
+		return entities.UserAccess{}, repo_errors.OperationError{}
+	}
+	return access, nil
+}
+
+func (r *PostgresRepository) SetUserRole(ctx context.Context, access entities.UserAccess) error {
+	return r.inTx(ctx, func(tx querier) error {
+		var old_access entities.UserAccess
+		err := tx.QueryRow(
+			ctx,
+			`SELECT user_id, role, manager_id
+			FROM users
+			WHERE user_id=$1 AND deleted_at IS NULL
+			FOR UPDATE`,
+			access.UserId,
+		).Scan(&old_access.UserId, &old_access.Role, &old_access.ManagerId)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				r.logger(ctx).Errorf("error: user not found. Detail: user_id=%d", access.UserId)
+				return repo_errors.ObjectNotFoundError{}
+			}
+			r.logger(ctx).Error("Error setting user role: ", err)
+			return repo_errors.OperationError{}
+		}
+
+		_, err = tx.Exec(
+			ctx,
+			`UPDATE users 
+			SET role=$1, manager_id=$2
+			WHERE user_id=$3`,
+			access.Role, access.ManagerId, access.UserId,
+		)
+		if err != nil {
+			var pg_err *pgconn.PgError
+			if errors.As(err, &pg_err) && pg_err.Code == "23503" {
+				r.logger(ctx).Errorf("error: %s. Detail: %s", pg_err.Error(), pg_err.Detail)
+				return repo_errors.ObjectNotFoundError{}
+			}
+			r.logger(ctx).Error("Error setting user role: ", err)
+			return repo_errors.OperationError{}
+		}
+
+		changes := auditChanges(accessAuditFields(old_access), accessAuditFields(access))
+		return r.insertAuditEvent(ctx, tx, newAuditEvent(ctx, entities.AuditActionSetRole, entities.AuditEntityUser, access.UserId, changes))
+	})
+}
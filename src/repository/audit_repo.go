@@ -0,0 +1,311 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"strings"
+	"task_tracker/src/auth"
+	"task_tracker/src/entities"
+	"task_tracker/src/errors/repo_errors"
+	"task_tracker/src/utils"
+)
+
+func userAuditFields(user entities.User) map[string]interface{} {
+	return map[string]interface{}{
+		"passportSerie":  user.PassportSerie,
+		"passportNumber": user.PassportNumber,
+		"surname":        user.Surname,
+		"name":           user.Name,
+		"patronymic":     user.Patronymic,
+		"address":        user.Address,
+	}
+}
+
+func accessAuditFields(access entities.UserAccess) map[string]interface{} {
+	return map[string]interface{}{
+		"role":      access.Role,
+		"managerId": access.ManagerId,
+	}
+}
+
+func taskAuditFields(task entities.Task) map[string]interface{} {
+	return map[string]interface{}{
+		"userId":    task.UserId,
+		"projectId": task.ProjectId,
+		"taskName":  task.TaskName,
+		"startTime": task.StartTime,
+		"endTime":   task.EndTime,
+	}
+}
+
+// pausedAuditChanges describes pausing or resuming a task, which only
+// changes its intervals.
+func pausedAuditChanges(paused bool) map[string]entities.AuditChange {
+	return auditChanges(map[string]interface{}{"paused": !paused}, map[string]interface{}{"paused": paused})
+}
+
+// auditChanges returns the fields whose JSON encoding differs between before
+// and after. A nil map stands for an object that does not exist.
+func auditChanges(before map[string]interface{}, after map[string]interface{}) map[string]entities.AuditChange {
+	changes := map[string]entities.AuditChange{}
+	for _, fields := range []map[string]interface{}{before, after} {
+		for name := range fields {
+			if _, seen := changes[name]; seen {
+				continue
+			}
+			before_value := auditValue(before, name)
+			after_value := auditValue(after, name)
+			if !bytes.Equal(before_value, after_value) {
+				changes[name] = entities.AuditChange{Before: before_value, After: after_value}
+			}
+		}
+	}
+	return changes
+}
+
+var redacted_audit_value = json.RawMessage(`"[redacted]"`)
+
+// redactUserAuditChanges replaces the personal data in the changes of a user
+// event, keeping whether a value was set. Every field of userAuditFields is
+// personal data. It reports whether anything was replaced.
+func redactUserAuditChanges(changes map[string]entities.AuditChange) (map[string]entities.AuditChange, bool) {
+	redacted := maps.Clone(changes)
+	found := false
+	for name := range userAuditFields(entities.User{}) {
+		change, ok := changes[name]
+		if !ok {
+			continue
+		}
+		redacted[name] = entities.AuditChange{Before: redactAuditValue(change.Before), After: redactAuditValue(change.After)}
+		found = true
+	}
+	return redacted, found
+}
+
+func redactAuditValue(value json.RawMessage) json.RawMessage {
+	if value == nil || string(value) == "null" || string(value) == `""` {
+		return value
+	}
+	return redacted_audit_value
+}
+
+func auditValue(fields map[string]interface{}, name string) json.RawMessage {
+	value, ok := fields[name]
+	if !ok {
+		return json.RawMessage("null")
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return json.RawMessage("null")
+	}
+	return encoded
+}
+
+// newAuditEvent takes the actor and request id from ctx. Events recorded
+// outside of a request, like retention purges, have neither.
+func newAuditEvent(
+	ctx context.Context,
+	action string,
+	entity string,
+	entity_id int,
+	changes map[string]entities.AuditChange,
+) entities.AuditEvent {
+	event := entities.AuditEvent{
+		Action:    action,
+		Entity:    entity,
+		EntityId:  entity_id,
+		Changes:   changes,
+		RequestId: utils.RequestIdFromContext(ctx),
+	}
+	if actor_id, ok := auth.UserIdFromContext(ctx); ok {
+		event.ActorId = &actor_id
+	}
+	return event
+}
+
+func (r *PostgresRepository) insertAuditEvent(ctx context.Context, tx querier, event entities.AuditEvent) error {
+	changes, err := json.Marshal(event.Changes)
+	if err != nil {
+		r.logger(ctx).Error("Error encoding audit changes:", err)
+		return repo_errors.OperationError{}
+	}
+	var request_id *string
+	if event.RequestId != "" {
+		request_id = &event.RequestId
+	}
+
+	_, err = tx.Exec(
+		ctx,
+		`INSERT INTO audit_events (actor_id, action, entity, entity_id, changes, request_id)
+		VALUES ($1, $2, $3, $4, $5::JSONB, $6)`,
+		event.ActorId, event.Action, event.Entity, event.EntityId, string(changes), request_id,
+	)
+	if err != nil {
+		r.logger(ctx).Error("Error saving audit event:", err)
+		return repo_errors.OperationError{}
+	}
+	return nil
+}
+
+// redactUserAuditEvents removes the personal data of purged users from their
+// audit events. This is the only change the audit_events_append_only trigger
+// lets through, and only after the setting below is turned on for tx.
+func (r *PostgresRepository) redactUserAuditEvents(ctx context.Context, tx querier, user_ids []int) error {
+	if len(user_ids) == 0 {
+		return nil
+	}
+	_, err := tx.Exec(ctx, `SELECT set_config('task_tracker.redact_audit_events', 'on', true)`)
+	if err != nil {
+		r.logger(ctx).Error("Error enabling audit redaction:", err)
+		return repo_errors.OperationError{}
+	}
+
+	rows, err := tx.Query(
+		ctx,
+		`SELECT event_id, changes FROM audit_events WHERE entity=$1 AND entity_id = ANY($2::INTEGER[])`,
+		entities.AuditEntityUser, user_ids,
+	)
+	if err != nil {
+		r.logger(ctx).Error("Error getting audit events to redact:", err)
+		return repo_errors.OperationError{}
+	}
+	events_changes := map[int]map[string]entities.AuditChange{}
+	for rows.Next() {
+		var event_id int
+		var changes []byte
+		if err = rows.Scan(&event_id, &changes); err != nil {
+			break
+		}
+		var decoded map[string]entities.AuditChange
+		if err = json.Unmarshal(changes, &decoded); err != nil {
+			break
+		}
+		events_changes[event_id] = decoded
+	}
+	rows.Close()
+	if err == nil {
+		err = rows.Err()
+	}
+	if err != nil {
+		r.logger(ctx).Error("Error getting audit events to redact:", err)
+		return repo_errors.OperationError{}
+	}
+
+	for event_id, changes := range events_changes {
+		redacted, found := redactUserAuditChanges(changes)
+		if !found {
+			continue
+		}
+		encoded, err := json.Marshal(redacted)
+		if err != nil {
+			r.logger(ctx).Error("Error encoding audit changes:", err)
+			return repo_errors.OperationError{}
+		}
+		_, err = tx.Exec(ctx, `UPDATE audit_events SET changes=$1::JSONB WHERE event_id=$2`, string(encoded), event_id)
+		if err != nil {
+			r.logger(ctx).Error("Error redacting audit event:", err)
+			return repo_errors.OperationError{}
+		}
+	}
+	return nil
+}
+
+func (r *PostgresRepository) GetAuditEvents(
+	ctx context.Context,
+	filter entities.AuditEventsFilter,
+) ([]entities.AuditEvent, int, error) {
+	conn, release, err := r.acquire(ctx)
+	if err != nil {
+		r.logger(ctx).Error("Error with acquiring connection:", err)
+		return nil, 0, repo_errors.OperationError{}
+	}
+	defer release()
+
+	where_clauses := []string{"true"}
+	args := []interface{}{}
+	add_clause := func(clause string, value interface{}) {
+		args = append(args, value)
+		where_clauses = append(where_clauses, fmt.Sprintf(clause, len(args)))
+	}
+	if filter.Entity != "" {
+		add_clause("entity=$%d", filter.Entity)
+	}
+	if filter.EntityId != nil {
+		add_clause("entity_id=$%d", *filter.EntityId)
+	}
+	if filter.ActorId != nil {
+		add_clause("actor_id=$%d", *filter.ActorId)
+	}
+	if filter.Action != "" {
+		add_clause("action=$%d", filter.Action)
+	}
+	if filter.DateFrom != nil {
+		add_clause("created_at>=$%d::TIMESTAMPTZ", *filter.DateFrom)
+	}
+	if filter.DateTo != nil {
+		add_clause("created_at<=$%d::TIMESTAMPTZ", *filter.DateTo)
+	}
+
+	var events_count int
+	err = conn.QueryRow(
+		ctx,
+		fmt.Sprintf(`SELECT COUNT(*) FROM audit_events WHERE %s`, strings.Join(where_clauses, " AND ")),
+		args...,
+	).Scan(&events_count)
+	if err != nil {
+		r.logger(ctx).Error("Error counting audit events:", err)
+		return nil, 0, repo_errors.OperationError{}
+	}
+
+	args = append(args, filter.Limit, filter.Offset)
+	rows, err := conn.Query(
+		ctx,
+		fmt.Sprintf(
+			`SELECT event_id, actor_id, action, entity, entity_id, changes, COALESCE(request_id, ''), created_at
+			FROM audit_events
+			WHERE %s
+			ORDER BY event_id DESC
+			LIMIT $%d
+			OFFSET $%d`,
+			strings.Join(where_clauses, " AND "), len(args)-1, len(args),
+		),
+		args...,
+	)
+	if err != nil {
+		r.logger(ctx).Error("Error getting audit events:", err)
+		return nil, 0, repo_errors.OperationError{}
+	}
+	defer rows.Close()
+
+	events := []entities.AuditEvent{}
+	for rows.Next() {
+		var event entities.AuditEvent
+		var changes []byte
+		err = rows.Scan(
+			&event.EventId,
+			&event.ActorId,
+			&event.Action,
+			&event.Entity,
+			&event.EntityId,
+			&changes,
+			&event.RequestId,
+			&event.CreatedAt,
+		)
+		if err == nil {
+			err = json.Unmarshal(changes, &event.Changes)
+		}
+		if err != nil {
+			r.logger(ctx).Error("Error scanning audit event:", err)
+			return nil, 0, repo_errors.OperationError{}
+		}
+		events = append(events, event)
+	}
+	if err := rows.Err(); err != nil {
+		r.logger(ctx).Error("Error getting audit events:", err)
+		return nil, 0, repo_errors.OperationError{}
+	}
+	return events, events_count, nil
+}
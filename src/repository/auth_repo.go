@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"task_tracker/src/entities"
+	"task_tracker/src/errors/repo_errors"
+
+	"github.com/jackc/pgx/v4"
+)
+
+func (r *PostgresRepository) SetUserPassword(
+	ctx context.Context,
+	user_id int,
+	password_hash string,
+) error {
+	conn, release, err := r.acquire(ctx)
+	if err != nil {
+		r.logger(ctx).Error("Error with acquiring connection:", err)
+		return repo_errors.OperationError{}
+	}
+	defer release()
+
+	command_tag, err := conn.Exec(
+		ctx,
+		`UPDATE users 
+		SET password_hash=$1
+		WHERE user_id=$2 AND deleted_at IS NULL`,
+		password_hash, user_id,
+	)
+	if err != nil {
+		r.logger(ctx).Error("Error setting user password: ", err)
+		return repo_errors.OperationError{}
+	}
+	if command_tag.RowsAffected() == 0 {
+		r.logger(ctx).Errorf("error: user not found. Detail: user_id=%d", user_id)
+		return repo_errors.ObjectNotFoundError{}
+	}
+	return nil
+}
+
+func (r *PostgresRepository) GetUserCredentials(
+	ctx context.Context,
+	passport_serie string,
+	passport_number string,
+) (entities.UserCredentials, error) {
+	conn, release, err := r.acquire(ctx)
+	if err != nil {
+		r.logger(ctx).Error("Error with acquiring connection:", err)
+		return entities.UserCredentials{}, repo_errors.OperationError{}
+	}
+	defer release()
+
+	var credentials entities.UserCredentials
+	err = conn.QueryRow(
+		ctx,
+		`SELECT user_id, COALESCE(password_hash, '')
+		FROM users
+		WHERE passport_serie=$1 AND passport_number=$2 AND deleted_at IS NULL`,
+		passport_serie, passport_number,
+	).Scan(&credentials.UserId, &credentials.PasswordHash)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return entities.UserCredentials{}, repo_errors.ObjectNotFoundError{}
+		}
+		r.logger(ctx).Error("Error getting user credentials: ", err)
+		return entities.UserCredentials{}, repo_errors.OperationError{}
+	}
+	return credentials, nil
+}
+
+func (r *PostgresRepository) SaveRefreshToken(ctx context.Context, token entities.RefreshToken) error {
+	conn, release, err := r.acquire(ctx)
+	if err != nil {
+		r.logger(ctx).Error("Error with acquiring connection:", err)
+		return repo_errors.OperationError{}
+	}
+	defer release()
+
+	_, err = conn.Exec(
+		ctx,
+		`INSERT INTO refresh_tokens (token_id, user_id, expires_at)
+		VALUES ($1, $2, $3)`,
+		token.TokenId, token.UserId, token.ExpiresAt,
+	)
+	if err != nil {
+		r.logger(ctx).Error("Error saving refresh token: ", err)
+		return repo_errors.OperationError{}
+	}
+	return nil
+}
+
+func (r *PostgresRepository) GetRefreshToken(ctx context.Context, token_id string) (entities.RefreshToken, error) {
+	conn, release, err := r.acquire(ctx)
+	if err != nil {
+		r.logger(ctx).Error("Error with acquiring connection:", err)
+		return entities.RefreshToken{}, repo_errors.OperationError{}
+	}
+	defer release()
+
+	var token entities.RefreshToken
+	err = conn.QueryRow(
+		ctx,
+		`SELECT token_id, user_id, expires_at, revoked_at
+		FROM refresh_tokens
+		WHERE token_id=$1`,
+		token_id,
+	).Scan(&token.TokenId, &token.UserId, &token.ExpiresAt, &token.RevokedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return entities.RefreshToken{}, repo_errors.ObjectNotFoundError{}
+		}
+		r.logger(ctx).Error("Error getting refresh token: ", err)
+		return entities.RefreshToken{}, repo_errors.OperationError{}
+	}
+	return token, nil
+}
+
+// RevokeRefreshToken reports whether the token was revoked by this call. Of
+// concurrent calls for the same token only one revokes it, the others wait
+// for the row lock and then find it revoked.
+func (r *PostgresRepository) RevokeRefreshToken(ctx context.Context, token_id string) (bool, error) {
+	conn, release, err := r.acquire(ctx)
+	if err != nil {
+		r.logger(ctx).Error("Error with acquiring connection:", err)
+		return false, repo_errors.OperationError{}
+	}
+	defer release()
+
+	command_tag, err := conn.Exec(
+		ctx,
+		`UPDATE refresh_tokens 
+		SET revoked_at=current_timestamp
+		WHERE token_id=$1 AND revoked_at IS NULL`,
+		token_id,
+	)
+	if err != nil {
+		r.logger(ctx).Error("Error revoking refresh token: ", err)
+		return false, repo_errors.OperationError{}
+	}
+	return command_tag.RowsAffected() == 1, nil
+}
@@ -0,0 +1,28 @@
+package repository
+
+import (
+	"context"
+	"task_tracker/src/errors/repo_errors"
+)
+
+func (r *PostgresRepository) Ping(ctx context.Context) error {
+	if err := r.pool.Ping(ctx); err != nil {
+		r.logger(ctx).Error("Error pinging database: ", err)
+		return repo_errors.OperationError{}
+	}
+	return nil
+}
+
+func (r *PostgresRepository) CheckSchemaVersion(ctx context.Context) error {
+	return CheckSchemaVersion(ctx, r.pool, r.log)
+}
+
+// The memory backend has no connection or schema to check.
+
+func (r *MemoryRepository) Ping(ctx context.Context) error {
+	return nil
+}
+
+func (r *MemoryRepository) CheckSchemaVersion(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"task_tracker/src/entities"
+	"task_tracker/src/errors/repo_errors"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+)
+
+// ReserveIdempotencyKey stores record as pending unless an unexpired record
+// with the same key exists. It returns the stored record and whether it was
+// reserved by this call.
+func (r *PostgresRepository) ReserveIdempotencyKey(
+	ctx context.Context,
+	record entities.IdempotencyRecord,
+) (entities.IdempotencyRecord, bool, error) {
+	conn, release, err := r.acquire(ctx)
+	if err != nil {
+		r.logger(ctx).Error("Error with acquiring connection:", err)
+		return entities.IdempotencyRecord{}, false, repo_errors.OperationError{}
+	}
+	defer release()
+
+	command_tag, err := conn.Exec(
+		ctx,
+		`INSERT INTO idempotency_keys (user_id, idempotency_key, fingerprint, expires_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id, idempotency_key) DO UPDATE
+		SET fingerprint=EXCLUDED.fingerprint, status_code=NULL, headers='{}', body=NULL, expires_at=EXCLUDED.expires_at
+		WHERE idempotency_keys.expires_at<=current_timestamp`,
+		record.UserId, record.Key, record.Fingerprint, record.ExpiresAt,
+	)
+	if err != nil {
+		r.logger(ctx).Error("Error reserving idempotency key: ", err)
+		return entities.IdempotencyRecord{}, false, repo_errors.OperationError{}
+	}
+	if command_tag.RowsAffected() == 1 {
+		return record, true, nil
+	}
+
+	var stored entities.IdempotencyRecord
+	var status_code *int
+	var headers []byte
+	err = conn.QueryRow(
+		ctx,
+		`SELECT user_id, idempotency_key, fingerprint, status_code, headers, body, expires_at
+		FROM idempotency_keys
+		WHERE user_id=$1 AND idempotency_key=$2`,
+		record.UserId, record.Key,
+	).Scan(
+		&stored.UserId,
+		&stored.Key,
+		&stored.Fingerprint,
+		&status_code,
+		&headers,
+		&stored.Body,
+		&stored.ExpiresAt,
+	)
+	if err == nil {
+		err = json.Unmarshal(headers, &stored.Headers)
+	}
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			// Purged between the two statements.
+			return entities.IdempotencyRecord{}, false, repo_errors.InvalidStateError{}
+		}
+		r.logger(ctx).Error("Error getting idempotency key: ", err)
+		return entities.IdempotencyRecord{}, false, repo_errors.OperationError{}
+	}
+	if status_code != nil {
+		stored.StatusCode = *status_code
+	}
+	return stored, false, nil
+}
+
+// SaveIdempotentResponse completes a pending record with the response.
+func (r *PostgresRepository) SaveIdempotentResponse(ctx context.Context, record entities.IdempotencyRecord) error {
+	conn, release, err := r.acquire(ctx)
+	if err != nil {
+		r.logger(ctx).Error("Error with acquiring connection:", err)
+		return repo_errors.OperationError{}
+	}
+	defer release()
+
+	headers, err := json.Marshal(record.Headers)
+	if err != nil {
+		r.logger(ctx).Error("Error encoding idempotent response headers:", err)
+		return repo_errors.OperationError{}
+	}
+	command_tag, err := conn.Exec(
+		ctx,
+		`UPDATE idempotency_keys
+		SET status_code=$1, headers=$2::JSONB, body=$3
+		WHERE user_id=$4 AND idempotency_key=$5 AND status_code IS NULL`,
+		record.StatusCode, string(headers), record.Body, record.UserId, record.Key,
+	)
+	if err != nil {
+		r.logger(ctx).Error("Error saving idempotent response: ", err)
+		return repo_errors.OperationError{}
+	}
+	if command_tag.RowsAffected() == 0 {
+		return repo_errors.ObjectNotFoundError{}
+	}
+	return nil
+}
+
+// ReleaseIdempotencyKey deletes a pending record so the key can be retried.
+func (r *PostgresRepository) ReleaseIdempotencyKey(ctx context.Context, user_id int, key string) error {
+	conn, release, err := r.acquire(ctx)
+	if err != nil {
+		r.logger(ctx).Error("Error with acquiring connection:", err)
+		return repo_errors.OperationError{}
+	}
+	defer release()
+
+	_, err = conn.Exec(
+		ctx,
+		`DELETE FROM idempotency_keys
+		WHERE user_id=$1 AND idempotency_key=$2 AND status_code IS NULL`,
+		user_id, key,
+	)
+	if err != nil {
+		r.logger(ctx).Error("Error releasing idempotency key: ", err)
+		return repo_errors.OperationError{}
+	}
+	return nil
+}
+
+func (r *PostgresRepository) PurgeExpiredIdempotencyKeys(ctx context.Context, now time.Time) (int, error) {
+	conn, release, err := r.acquire(ctx)
+	if err != nil {
+		r.logger(ctx).Error("Error with acquiring connection:", err)
+		return 0, repo_errors.OperationError{}
+	}
+	defer release()
+
+	command_tag, err := conn.Exec(ctx, `DELETE FROM idempotency_keys WHERE expires_at<=$1`, now)
+	if err != nil {
+		r.logger(ctx).Error("Error purging idempotency keys: ", err)
+		return 0, repo_errors.OperationError{}
+	}
+	return int(command_tag.RowsAffected()), nil
+}
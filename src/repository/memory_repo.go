@@ -0,0 +1,1052 @@
+package repository
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"task_tracker/src/entities"
+	"task_tracker/src/errors/repo_errors"
+	"time"
+)
+
+type memoryInterval struct {
+	start_time time.Time
+	end_time   *time.Time
+}
+
+type memoryTask struct {
+	task      entities.Task
+	intervals []memoryInterval
+}
+
+type memoryTxContextKey struct{}
+
+type memoryIdempotencyKey struct {
+	user_id int
+	key     string
+}
+
+type MemoryRepository struct {
+	mu              sync.RWMutex
+	tx_mu           sync.Mutex
+	users           map[int]entities.User
+	deleted_users   map[int]time.Time
+	passwords       map[int]string
+	access          map[int]entities.UserAccess
+	tasks           map[int]*memoryTask
+	projects        map[int]entities.Project
+	refresh_tokens  map[string]entities.RefreshToken
+	audit_events    []entities.AuditEvent
+	idempotency     map[memoryIdempotencyKey]entities.IdempotencyRecord
+	next_user_id    int
+	next_task_id    int
+	next_project_id int
+
+	// Now is used instead of time.Now so that tests can control timestamps.
+	Now func() time.Time
+}
+
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{
+		users:           map[int]entities.User{},
+		deleted_users:   map[int]time.Time{},
+		passwords:       map[int]string{},
+		access:          map[int]entities.UserAccess{},
+		tasks:           map[int]*memoryTask{},
+		projects:        map[int]entities.Project{},
+		refresh_tokens:  map[string]entities.RefreshToken{},
+		idempotency:     map[memoryIdempotencyKey]entities.IdempotencyRecord{},
+		next_user_id:    1,
+		next_task_id:    1,
+		next_project_id: 1,
+		Now:             time.Now,
+	}
+}
+
+// WithTx runs fn and puts the data back as it was when fn fails. Units of
+// work are serialized with each other, but writes made outside of them while
+// fn runs are lost on rollback too. Like Postgres sequences, ids are not
+// given back. fn is run again when it fails with a serialization failure or
+// a deadlock, as with Postgres.
+func (r *MemoryRepository) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if ctx.Value(memoryTxContextKey{}) != nil {
+		return fn(ctx)
+	}
+	r.tx_mu.Lock()
+	defer r.tx_mu.Unlock()
+
+	return retryTx(ctx, nil, func() (bool, error) {
+		r.mu.RLock()
+		restore := r.snapshot()
+		r.mu.RUnlock()
+
+		if err := fn(context.WithValue(ctx, memoryTxContextKey{}, true)); err != nil {
+			r.mu.Lock()
+			restore()
+			r.mu.Unlock()
+			return isRetryableTxError(err), err
+		}
+		return false, nil
+	})
+}
+
+// snapshot copies the data and returns a function restoring it.
+func (r *MemoryRepository) snapshot() func() {
+	users := maps.Clone(r.users)
+	deleted_users := maps.Clone(r.deleted_users)
+	passwords := maps.Clone(r.passwords)
+	access := maps.Clone(r.access)
+	projects := maps.Clone(r.projects)
+	refresh_tokens := maps.Clone(r.refresh_tokens)
+	tasks := make(map[int]*memoryTask, len(r.tasks))
+	for task_id, task := range r.tasks {
+		tasks[task_id] = &memoryTask{task: task.task, intervals: slices.Clone(task.intervals)}
+	}
+	audit_events := slices.Clone(r.audit_events)
+
+	return func() {
+		r.users = users
+		r.deleted_users = deleted_users
+		r.passwords = passwords
+		r.access = access
+		r.projects = projects
+		r.refresh_tokens = refresh_tokens
+		r.tasks = tasks
+		r.audit_events = audit_events
+	}
+}
+
+func (r *MemoryRepository) recordAuditEvent(
+	ctx context.Context,
+	action string,
+	entity string,
+	entity_id int,
+	changes map[string]entities.AuditChange,
+) {
+	event := newAuditEvent(ctx, action, entity, entity_id, changes)
+	event.EventId = len(r.audit_events) + 1
+	event.CreatedAt = r.Now()
+	r.audit_events = append(r.audit_events, event)
+}
+
+// userActive reports whether the user exists and is not soft deleted.
+func (r *MemoryRepository) userActive(user_id int) bool {
+	_, exists := r.users[user_id]
+	_, deleted := r.deleted_users[user_id]
+	return exists && !deleted
+}
+
+// passportTaken mimics the unique index on passports of users that are not
+// deleted.
+func (r *MemoryRepository) passportTaken(passport_serie string, passport_number string, except_user_id int) bool {
+	for _, user := range r.users {
+		if user.Id != except_user_id && r.userActive(user.Id) &&
+			user.PassportSerie == passport_serie &&
+			user.PassportNumber == passport_number {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *MemoryRepository) CreateUser(ctx context.Context, user entities.User) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.passportTaken(user.PassportSerie, user.PassportNumber, 0) {
+		return 0, repo_errors.ObjectAlreadyExistsError{}
+	}
+
+	user.Id = r.next_user_id
+	user.Version = 1
+	user.CreatedAt = r.Now()
+	r.next_user_id++
+	r.users[user.Id] = user
+	r.access[user.Id] = entities.UserAccess{UserId: user.Id, Role: entities.RoleMember}
+	r.recordAuditEvent(ctx, entities.AuditActionCreate, entities.AuditEntityUser, user.Id, auditChanges(nil, userAuditFields(user)))
+	return user.Id, nil
+}
+
+func (r *MemoryRepository) UpdateUser(
+	ctx context.Context,
+	user entities.UserUpdateRepo,
+	user_id int,
+) (entities.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if user.PassportSerie == nil && user.PassportNumber == nil && user.Surname == nil &&
+		user.Name == nil && user.Patronymic == nil && user.Address == nil {
+		return entities.User{}, fmt.Errorf("No fields to update")
+	}
+
+	if !r.userActive(user_id) {
+		return entities.User{}, repo_errors.ObjectNotFoundError{}
+	}
+	updated_user := r.users[user_id]
+	if user.PassportSerie != nil {
+		updated_user.PassportSerie = *user.PassportSerie
+	}
+	if user.PassportNumber != nil {
+		updated_user.PassportNumber = *user.PassportNumber
+	}
+	if user.Surname != nil {
+		updated_user.Surname = *user.Surname
+	}
+	if user.Name != nil {
+		updated_user.Name = *user.Name
+	}
+	if user.Patronymic != nil {
+		updated_user.Patronymic = *user.Patronymic
+	}
+	if user.Address != nil {
+		updated_user.Address = *user.Address
+	}
+
+	if r.passportTaken(updated_user.PassportSerie, updated_user.PassportNumber, user_id) {
+		return entities.User{}, repo_errors.ObjectAlreadyExistsError{}
+	}
+	updated_user.Version++
+	changes := auditChanges(userAuditFields(r.users[user_id]), userAuditFields(updated_user))
+	r.users[user_id] = updated_user
+	r.recordAuditEvent(ctx, entities.AuditActionUpdate, entities.AuditEntityUser, user_id, changes)
+	return updated_user, nil
+}
+
+func (r *MemoryRepository) DeleteUser(ctx context.Context, user_id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.userActive(user_id) {
+		return repo_errors.ObjectNotFoundError{}
+	}
+	now := r.Now()
+	r.deleted_users[user_id] = now
+	r.bumpUserVersion(user_id)
+	// Sorted like the archived tasks of the Postgres repository.
+	task_ids := []int{}
+	for task_id, task := range r.tasks {
+		if task.task.UserId == user_id && task.task.EndTime == nil {
+			task_ids = append(task_ids, task_id)
+		}
+	}
+	slices.Sort(task_ids)
+	for _, task_id := range task_ids {
+		task := r.tasks[task_id]
+		old_task := task.task
+		task.task.EndTime = &now
+		task.task.Version++
+		r.closeOpenInterval(task, now)
+		changes := auditChanges(taskAuditFields(old_task), taskAuditFields(task.task))
+		r.recordAuditEvent(ctx, entities.AuditActionFinish, entities.AuditEntityTask, task_id, changes)
+	}
+	for token_id, token := range r.refresh_tokens {
+		if token.UserId == user_id && token.RevokedAt == nil {
+			token.RevokedAt = &now
+			r.refresh_tokens[token_id] = token
+		}
+	}
+	changes := auditChanges(nil, map[string]interface{}{"deletedAt": now})
+	r.recordAuditEvent(ctx, entities.AuditActionDelete, entities.AuditEntityUser, user_id, changes)
+	return nil
+}
+
+func (r *MemoryRepository) RestoreUser(ctx context.Context, user_id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[user_id]
+	if !ok {
+		return repo_errors.ObjectNotFoundError{}
+	}
+	deleted_at, deleted := r.deleted_users[user_id]
+	if !deleted {
+		return repo_errors.InvalidStateError{}
+	}
+	if r.passportTaken(user.PassportSerie, user.PassportNumber, user_id) {
+		return repo_errors.ObjectAlreadyExistsError{}
+	}
+	delete(r.deleted_users, user_id)
+	r.bumpUserVersion(user_id)
+	changes := auditChanges(map[string]interface{}{"deletedAt": deleted_at}, nil)
+	r.recordAuditEvent(ctx, entities.AuditActionRestore, entities.AuditEntityUser, user_id, changes)
+	return nil
+}
+
+func (r *MemoryRepository) bumpUserVersion(user_id int) {
+	user := r.users[user_id]
+	user.Version++
+	r.users[user_id] = user
+}
+
+func (r *MemoryRepository) PurgeDeletedUsers(ctx context.Context, deleted_before time.Time) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	purged := 0
+	for user_id, deleted_at := range r.deleted_users {
+		if !deleted_at.Before(deleted_before) {
+			continue
+		}
+		r.purgeUser(user_id)
+		r.redactUserAuditEvents(user_id)
+		r.recordAuditEvent(ctx, entities.AuditActionPurge, entities.AuditEntityUser, user_id, map[string]entities.AuditChange{})
+		purged++
+	}
+	return purged, nil
+}
+
+func (r *MemoryRepository) redactUserAuditEvents(user_id int) {
+	for i, event := range r.audit_events {
+		if event.Entity == entities.AuditEntityUser && event.EntityId == user_id {
+			r.audit_events[i].Changes, _ = redactUserAuditChanges(event.Changes)
+		}
+	}
+}
+
+func (r *MemoryRepository) purgeUser(user_id int) {
+	for task_id, task := range r.tasks {
+		if task.task.UserId == user_id {
+			delete(r.tasks, task_id)
+		}
+	}
+	delete(r.deleted_users, user_id)
+	delete(r.users, user_id)
+	delete(r.passwords, user_id)
+	delete(r.access, user_id)
+	// Mimics manager_id ON DELETE SET NULL.
+	for id, access := range r.access {
+		if access.ManagerId != nil && *access.ManagerId == user_id {
+			access.ManagerId = nil
+			r.access[id] = access
+		}
+	}
+	for token_id, token := range r.refresh_tokens {
+		if token.UserId == user_id {
+			delete(r.refresh_tokens, token_id)
+		}
+	}
+}
+
+func (r *MemoryRepository) GetUser(ctx context.Context, user_id int) (entities.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if !r.userActive(user_id) {
+		return entities.User{}, repo_errors.ObjectNotFoundError{}
+	}
+	return r.users[user_id], nil
+}
+
+func (r *MemoryRepository) GetUserVersion(ctx context.Context, user_id int) (int, error) {
+	user, err := r.GetUser(ctx, user_id)
+	return user.Version, err
+}
+
+// compareUserPosition orders user against position the way the
+// (column, user_id) comparisons of the Postgres query do.
+func compareUserPosition(user entities.User, sort string, position entities.UserPosition) int {
+	key_order := 0
+	switch sort {
+	case entities.UsersSortSurname:
+		key_order = strings.Compare(user.Surname, position.Key)
+	case entities.UsersSortName:
+		key_order = strings.Compare(user.Name, position.Key)
+	case entities.UsersSortCreatedAt:
+		created_at, _ := time.Parse(time.RFC3339Nano, position.Key)
+		key_order = user.CreatedAt.Compare(created_at)
+	}
+	if key_order != 0 {
+		return key_order
+	}
+	return cmp.Compare(user.Id, position.Id)
+}
+
+func (r *MemoryRepository) GetUsers(ctx context.Context, query entities.UsersQuery) ([]entities.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	switch query.Sort {
+	case entities.UsersSortId, entities.UsersSortSurname, entities.UsersSortName, entities.UsersSortCreatedAt:
+	default:
+		return nil, repo_errors.OperationError{}
+	}
+	direction := 1
+	if query.Descending {
+		direction = -1
+	}
+
+	filter := query.Filter
+	users := []entities.User{}
+	for _, user := range r.users {
+		if !r.userActive(user.Id) ||
+			!strings.HasPrefix(user.Surname, filter.SurnamePrefix) ||
+			!strings.HasPrefix(user.Name, filter.NamePrefix) ||
+			filter.PassportSerie != "" && user.PassportSerie != filter.PassportSerie ||
+			filter.CreatedFrom != nil && user.CreatedAt.Before(*filter.CreatedFrom) ||
+			filter.CreatedTo != nil && user.CreatedAt.After(*filter.CreatedTo) {
+			continue
+		}
+		if query.After != nil && compareUserPosition(user, query.Sort, *query.After)*direction <= 0 {
+			continue
+		}
+		users = append(users, user)
+	}
+	slices.SortFunc(users, func(a entities.User, b entities.User) int {
+		return compareUserPosition(a, query.Sort, entities.UserPositionOf(b, query.Sort)) * direction
+	})
+
+	if query.Limit > 0 && query.Limit < len(users) {
+		users = users[:query.Limit]
+	}
+	return users, nil
+}
+
+func (r *MemoryRepository) GetUserActivity(
+	ctx context.Context,
+	filters entities.UserActivityRequest,
+) ([]entities.UserActivityTask, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	now := r.Now()
+	type activity struct {
+		task    entities.UserActivityTask
+		seconds float64
+	}
+
+	activities := []activity{}
+	for _, task := range r.tasks {
+		if task.task.UserId != filters.UserId {
+			continue
+		}
+		if filters.DateFrom != nil && task.task.StartTime.Before(*filters.DateFrom) {
+			continue
+		}
+		if filters.DateTo != nil && task.task.StartTime.After(*filters.DateTo) {
+			continue
+		}
+
+		var seconds float64
+		is_running := false
+		for _, interval := range task.intervals {
+			end_time := now
+			if interval.end_time != nil {
+				end_time = *interval.end_time
+			} else {
+				is_running = true
+			}
+			seconds += end_time.Sub(interval.start_time).Seconds()
+		}
+
+		var project_name *string
+		if task.task.ProjectId != nil {
+			name := r.projects[*task.task.ProjectId].ProjectName
+			project_name = &name
+		}
+
+		activities = append(activities, activity{
+			task: entities.UserActivityTask{
+				TaskID:      task.task.TaskId,
+				TaskName:    task.task.TaskName,
+				ProjectId:   task.task.ProjectId,
+				ProjectName: project_name,
+				Hours:       int(seconds / 3600),
+				Minutes:     int(seconds/60) % 60,
+				IsFinished:  task.task.EndTime != nil,
+				IsPaused:    task.task.EndTime == nil && !is_running,
+			},
+			seconds: seconds,
+		})
+	}
+	sort.SliceStable(activities, func(i, j int) bool {
+		if activities[i].seconds == activities[j].seconds {
+			return activities[i].task.TaskID < activities[j].task.TaskID
+		}
+		return activities[i].seconds > activities[j].seconds
+	})
+
+	tasks := []entities.UserActivityTask{}
+	for _, activity := range activities {
+		tasks = append(tasks, activity.task)
+	}
+	return tasks, nil
+}
+
+func (r *MemoryRepository) StreamUserActivity(
+	ctx context.Context,
+	filters entities.UserActivityRequest,
+	fn func(task entities.UserActivityTask) error,
+) error {
+	tasks, err := r.GetUserActivity(ctx, filters)
+	if err != nil {
+		return err
+	}
+	for _, task := range tasks {
+		if err := fn(task); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *MemoryRepository) CreateTask(
+	ctx context.Context,
+	task entities.CreateTaskRequest,
+) (*entities.CreateTaskResponse, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Mimics the tasks.user_id and tasks.project_id foreign keys. Deleted
+	// users keep their tasks but get no new ones.
+	if !r.userActive(task.UserId) {
+		return nil, repo_errors.ObjectNotFoundError{}
+	}
+	if task.ProjectId != nil {
+		if _, ok := r.projects[*task.ProjectId]; !ok {
+			return nil, repo_errors.ObjectNotFoundError{}
+		}
+	}
+
+	now := r.Now()
+	created_task := &memoryTask{
+		task: entities.Task{
+			TaskId:    r.next_task_id,
+			UserId:    task.UserId,
+			ProjectId: task.ProjectId,
+			TaskName:  task.TaskName,
+			StartTime: now,
+			Version:   1,
+		},
+		intervals: []memoryInterval{{start_time: now}},
+	}
+	r.next_task_id++
+	r.tasks[created_task.task.TaskId] = created_task
+	r.recordAuditEvent(
+		ctx,
+		entities.AuditActionCreate,
+		entities.AuditEntityTask,
+		created_task.task.TaskId,
+		auditChanges(nil, taskAuditFields(created_task.task)),
+	)
+
+	return &entities.CreateTaskResponse{
+		TaskId:    created_task.task.TaskId,
+		TaskName:  created_task.task.TaskName,
+		UserId:    created_task.task.UserId,
+		ProjectId: created_task.task.ProjectId,
+		CreatedAt: created_task.task.StartTime,
+		Version:   created_task.task.Version,
+	}, nil
+}
+
+func (r *MemoryRepository) closeOpenInterval(task *memoryTask, end_time time.Time) bool {
+	for i := range task.intervals {
+		if task.intervals[i].end_time == nil {
+			task.intervals[i].end_time = &end_time
+			return true
+		}
+	}
+	return false
+}
+
+func (r *MemoryRepository) FinishTask(ctx context.Context, task_id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	task, ok := r.tasks[task_id]
+	if !ok {
+		return repo_errors.ObjectNotFoundError{}
+	}
+	if task.task.EndTime != nil {
+		return repo_errors.InvalidStateError{}
+	}
+	old_task := task.task
+	now := r.Now()
+	task.task.EndTime = &now
+	task.task.Version++
+	r.closeOpenInterval(task, now)
+	changes := auditChanges(taskAuditFields(old_task), taskAuditFields(task.task))
+	r.recordAuditEvent(ctx, entities.AuditActionFinish, entities.AuditEntityTask, task_id, changes)
+	return nil
+}
+
+func (r *MemoryRepository) PauseTask(ctx context.Context, task_id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	task, ok := r.tasks[task_id]
+	if !ok {
+		return repo_errors.ObjectNotFoundError{}
+	}
+	if !r.closeOpenInterval(task, r.Now()) {
+		return repo_errors.InvalidStateError{}
+	}
+	task.task.Version++
+	r.recordAuditEvent(ctx, entities.AuditActionPause, entities.AuditEntityTask, task_id, pausedAuditChanges(true))
+	return nil
+}
+
+func (r *MemoryRepository) ResumeTask(ctx context.Context, task_id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	task, ok := r.tasks[task_id]
+	if !ok {
+		return repo_errors.ObjectNotFoundError{}
+	}
+	if task.task.EndTime != nil {
+		return repo_errors.InvalidStateError{}
+	}
+	for _, interval := range task.intervals {
+		if interval.end_time == nil {
+			return repo_errors.InvalidStateError{}
+		}
+	}
+	task.intervals = append(task.intervals, memoryInterval{start_time: r.Now()})
+	task.task.Version++
+	r.recordAuditEvent(ctx, entities.AuditActionResume, entities.AuditEntityTask, task_id, pausedAuditChanges(false))
+	return nil
+}
+
+func (r *MemoryRepository) ReopenTask(ctx context.Context, task_id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	task, ok := r.tasks[task_id]
+	if !ok {
+		return repo_errors.ObjectNotFoundError{}
+	}
+	if task.task.EndTime == nil {
+		return repo_errors.InvalidStateError{}
+	}
+	old_task := task.task
+	task.task.EndTime = nil
+	task.task.Version++
+	task.intervals = append(task.intervals, memoryInterval{start_time: r.Now()})
+	changes := auditChanges(taskAuditFields(old_task), taskAuditFields(task.task))
+	r.recordAuditEvent(ctx, entities.AuditActionReopen, entities.AuditEntityTask, task_id, changes)
+	return nil
+}
+
+func (r *MemoryRepository) GetTask(ctx context.Context, task_id int) (entities.Task, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	task, ok := r.tasks[task_id]
+	if !ok {
+		return entities.Task{}, repo_errors.ObjectNotFoundError{}
+	}
+	return task.task, nil
+}
+
+func (r *MemoryRepository) GetTaskVersion(ctx context.Context, task_id int) (int, error) {
+	task, err := r.GetTask(ctx, task_id)
+	return task.Version, err
+}
+
+func (r *MemoryRepository) GetTasks(ctx context.Context, filters entities.TasksFilter) ([]entities.Task, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tasks := []entities.Task{}
+	for _, task := range r.tasks {
+		if filters.UserId != nil && task.task.UserId != *filters.UserId {
+			continue
+		}
+		if filters.ProjectId != nil && (task.task.ProjectId == nil || *task.task.ProjectId != *filters.ProjectId) {
+			continue
+		}
+		if filters.IsFinished != nil && (task.task.EndTime != nil) != *filters.IsFinished {
+			continue
+		}
+		if filters.DateFrom != nil && task.task.StartTime.Before(*filters.DateFrom) {
+			continue
+		}
+		if filters.DateTo != nil && task.task.StartTime.After(*filters.DateTo) {
+			continue
+		}
+		tasks = append(tasks, task.task)
+	}
+	sort.Slice(tasks, func(i, j int) bool {
+		return tasks[i].TaskId < tasks[j].TaskId
+	})
+	return tasks, nil
+}
+
+func (r *MemoryRepository) UpdateTask(
+	ctx context.Context,
+	task entities.UpdateTaskRequest,
+	task_id int,
+) (entities.Task, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	updated_task, ok := r.tasks[task_id]
+	if !ok {
+		return entities.Task{}, repo_errors.ObjectNotFoundError{}
+	}
+	old_task := updated_task.task
+	updated_task.task.TaskName = *task.TaskName
+	updated_task.task.Version++
+	changes := auditChanges(taskAuditFields(old_task), taskAuditFields(updated_task.task))
+	r.recordAuditEvent(ctx, entities.AuditActionUpdate, entities.AuditEntityTask, task_id, changes)
+	return updated_task.task, nil
+}
+
+func (r *MemoryRepository) DeleteTask(ctx context.Context, task_id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	task, ok := r.tasks[task_id]
+	if !ok {
+		return repo_errors.ObjectNotFoundError{}
+	}
+	delete(r.tasks, task_id)
+	r.recordAuditEvent(ctx, entities.AuditActionDelete, entities.AuditEntityTask, task_id, auditChanges(taskAuditFields(task.task), nil))
+	return nil
+}
+
+func (r *MemoryRepository) SetUserPassword(ctx context.Context, user_id int, password_hash string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.userActive(user_id) {
+		return repo_errors.ObjectNotFoundError{}
+	}
+	r.passwords[user_id] = password_hash
+	return nil
+}
+
+func (r *MemoryRepository) GetUserCredentials(
+	ctx context.Context,
+	passport_serie string,
+	passport_number string,
+) (entities.UserCredentials, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, user := range r.users {
+		if r.userActive(user.Id) && user.PassportSerie == passport_serie && user.PassportNumber == passport_number {
+			return entities.UserCredentials{UserId: user.Id, PasswordHash: r.passwords[user.Id]}, nil
+		}
+	}
+	return entities.UserCredentials{}, repo_errors.ObjectNotFoundError{}
+}
+
+func (r *MemoryRepository) SaveRefreshToken(ctx context.Context, token entities.RefreshToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.users[token.UserId]; !ok {
+		return repo_errors.OperationError{}
+	}
+	r.refresh_tokens[token.TokenId] = token
+	return nil
+}
+
+func (r *MemoryRepository) GetRefreshToken(ctx context.Context, token_id string) (entities.RefreshToken, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	token, ok := r.refresh_tokens[token_id]
+	if !ok {
+		return entities.RefreshToken{}, repo_errors.ObjectNotFoundError{}
+	}
+	return token, nil
+}
+
+func (r *MemoryRepository) RevokeRefreshToken(ctx context.Context, token_id string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	token, ok := r.refresh_tokens[token_id]
+	if !ok || token.RevokedAt != nil {
+		return false, nil
+	}
+	now := r.Now()
+	token.RevokedAt = &now
+	r.refresh_tokens[token_id] = token
+	return true, nil
+}
+
+func (r *MemoryRepository) GetUserAccess(ctx context.Context, user_id int) (entities.UserAccess, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	access, ok := r.access[user_id]
+	if !ok || !r.userActive(user_id) {
+		return entities.UserAccess{}, repo_errors.ObjectNotFoundError{}
+	}
+	return access, nil
+}
+
+func (r *MemoryRepository) SetUserRole(ctx context.Context, access entities.UserAccess) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.userActive(access.UserId) {
+		return repo_errors.ObjectNotFoundError{}
+	}
+	// Mimics the users.manager_id foreign key.
+	if access.ManagerId != nil {
+		if !r.userActive(*access.ManagerId) {
+			return repo_errors.ObjectNotFoundError{}
+		}
+	}
+	changes := auditChanges(accessAuditFields(r.access[access.UserId]), accessAuditFields(access))
+	r.access[access.UserId] = access
+	r.recordAuditEvent(ctx, entities.AuditActionSetRole, entities.AuditEntityUser, access.UserId, changes)
+	return nil
+}
+
+func (r *MemoryRepository) projectNameTaken(project_name string, except_project_id int) bool {
+	for _, project := range r.projects {
+		if project.ProjectId != except_project_id && project.ProjectName == project_name {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *MemoryRepository) CreateProject(
+	ctx context.Context,
+	project entities.CreateProjectRequest,
+) (entities.Project, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.projectNameTaken(project.ProjectName, 0) {
+		return entities.Project{}, repo_errors.ObjectAlreadyExistsError{}
+	}
+	created_project := entities.Project{ProjectId: r.next_project_id, ProjectName: project.ProjectName}
+	r.next_project_id++
+	r.projects[created_project.ProjectId] = created_project
+	return created_project, nil
+}
+
+func (r *MemoryRepository) GetProject(ctx context.Context, project_id int) (entities.Project, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	project, ok := r.projects[project_id]
+	if !ok {
+		return entities.Project{}, repo_errors.ObjectNotFoundError{}
+	}
+	return project, nil
+}
+
+func (r *MemoryRepository) GetProjects(ctx context.Context) ([]entities.Project, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	projects := []entities.Project{}
+	for _, project := range r.projects {
+		projects = append(projects, project)
+	}
+	sort.Slice(projects, func(i, j int) bool {
+		return projects[i].ProjectId < projects[j].ProjectId
+	})
+	return projects, nil
+}
+
+func (r *MemoryRepository) UpdateProject(
+	ctx context.Context,
+	project entities.UpdateProjectRequest,
+	project_id int,
+) (entities.Project, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	updated_project, ok := r.projects[project_id]
+	if !ok {
+		return entities.Project{}, repo_errors.ObjectNotFoundError{}
+	}
+	if r.projectNameTaken(*project.ProjectName, project_id) {
+		return entities.Project{}, repo_errors.ObjectAlreadyExistsError{}
+	}
+	updated_project.ProjectName = *project.ProjectName
+	r.projects[project_id] = updated_project
+	return updated_project, nil
+}
+
+func (r *MemoryRepository) DeleteProject(ctx context.Context, project_id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.projects[project_id]; !ok {
+		return repo_errors.ObjectNotFoundError{}
+	}
+	delete(r.projects, project_id)
+	// Mimics tasks.project_id ON DELETE SET NULL.
+	for _, task := range r.tasks {
+		if task.task.ProjectId != nil && *task.task.ProjectId == project_id {
+			task.task.ProjectId = nil
+		}
+	}
+	return nil
+}
+
+func (r *MemoryRepository) GetTaskIntervals(
+	ctx context.Context,
+	filters entities.TaskIntervalsFilter,
+) ([]entities.TaskInterval, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	user_ids := map[int]bool{}
+	for _, user_id := range filters.UserIds {
+		user_ids[user_id] = true
+	}
+
+	now := r.Now()
+	intervals := []entities.TaskInterval{}
+	for _, task := range r.tasks {
+		if filters.UserIds != nil && !user_ids[task.task.UserId] {
+			continue
+		}
+		for _, interval := range task.intervals {
+			end_time := now
+			if interval.end_time != nil {
+				end_time = *interval.end_time
+			}
+			if !interval.start_time.Before(filters.To) || !end_time.After(filters.From) {
+				continue
+			}
+			intervals = append(intervals, entities.TaskInterval{
+				TaskId:    task.task.TaskId,
+				UserId:    task.task.UserId,
+				TaskName:  task.task.TaskName,
+				StartTime: interval.start_time,
+				EndTime:   end_time,
+			})
+		}
+	}
+	sort.Slice(intervals, func(i, j int) bool {
+		if intervals[i].UserId != intervals[j].UserId {
+			return intervals[i].UserId < intervals[j].UserId
+		}
+		if intervals[i].TaskId != intervals[j].TaskId {
+			return intervals[i].TaskId < intervals[j].TaskId
+		}
+		return intervals[i].StartTime.Before(intervals[j].StartTime)
+	})
+	return intervals, nil
+}
+
+func (r *MemoryRepository) StreamTaskIntervals(
+	ctx context.Context,
+	filters entities.TaskIntervalsFilter,
+	fn func(interval entities.TaskInterval) error,
+) error {
+	intervals, err := r.GetTaskIntervals(ctx, filters)
+	if err != nil {
+		return err
+	}
+	for _, interval := range intervals {
+		if err := fn(interval); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *MemoryRepository) GetAuditEvents(
+	ctx context.Context,
+	filter entities.AuditEventsFilter,
+) ([]entities.AuditEvent, int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	matched := []entities.AuditEvent{}
+	for i := len(r.audit_events) - 1; i >= 0; i-- {
+		event := r.audit_events[i]
+		if filter.Entity != "" && event.Entity != filter.Entity {
+			continue
+		}
+		if filter.EntityId != nil && event.EntityId != *filter.EntityId {
+			continue
+		}
+		if filter.ActorId != nil && (event.ActorId == nil || *event.ActorId != *filter.ActorId) {
+			continue
+		}
+		if filter.Action != "" && event.Action != filter.Action {
+			continue
+		}
+		if filter.DateFrom != nil && event.CreatedAt.Before(*filter.DateFrom) {
+			continue
+		}
+		if filter.DateTo != nil && event.CreatedAt.After(*filter.DateTo) {
+			continue
+		}
+		matched = append(matched, event)
+	}
+
+	start := min(filter.Offset, len(matched))
+	end := min(start+filter.Limit, len(matched))
+	return matched[start:end], len(matched), nil
+}
+
+func (r *MemoryRepository) ReserveIdempotencyKey(
+	ctx context.Context,
+	record entities.IdempotencyRecord,
+) (entities.IdempotencyRecord, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := memoryIdempotencyKey{user_id: record.UserId, key: record.Key}
+	if stored, ok := r.idempotency[key]; ok && stored.ExpiresAt.After(r.Now()) {
+		return stored, false, nil
+	}
+	record.StatusCode = 0
+	record.Headers = nil
+	record.Body = nil
+	r.idempotency[key] = record
+	return record, true, nil
+}
+
+func (r *MemoryRepository) SaveIdempotentResponse(ctx context.Context, record entities.IdempotencyRecord) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := memoryIdempotencyKey{user_id: record.UserId, key: record.Key}
+	stored, ok := r.idempotency[key]
+	if !ok || stored.StatusCode != 0 {
+		return repo_errors.ObjectNotFoundError{}
+	}
+	stored.StatusCode = record.StatusCode
+	stored.Headers = maps.Clone(record.Headers)
+	stored.Body = slices.Clone(record.Body)
+	r.idempotency[key] = stored
+	return nil
+}
+
+func (r *MemoryRepository) ReleaseIdempotencyKey(ctx context.Context, user_id int, key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	memory_key := memoryIdempotencyKey{user_id: user_id, key: key}
+	if stored, ok := r.idempotency[memory_key]; ok && stored.StatusCode == 0 {
+		delete(r.idempotency, memory_key)
+	}
+	return nil
+}
+
+func (r *MemoryRepository) PurgeExpiredIdempotencyKeys(ctx context.Context, now time.Time) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	purged := 0
+	for key, stored := range r.idempotency {
+		if !stored.ExpiresAt.After(now) {
+			delete(r.idempotency, key)
+			purged++
+		}
+	}
+	return purged, nil
+}
@@ -0,0 +1,361 @@
+package repository
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"task_tracker/src/errors/repo_errors"
+	"time"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/sirupsen/logrus"
+)
+
+//go:embed migrations/*.sql
+var migrations_fs embed.FS
+
+// Arbitrary key for pg_advisory_lock so that concurrent runs do not apply
+// the same migration twice.
+const migrations_lock_id = 20240709
+
+type Migration struct {
+	Version   int
+	Name      string
+	AppliedAt *time.Time
+	up_sql    string
+	down_sql  string
+}
+
+func loadMigrations() ([]Migration, error) {
+	files, err := migrations_fs.ReadDir("migrations")
+	if err != nil {
+		return nil, err
+	}
+
+	migrations_by_version := map[int]*Migration{}
+	for _, file := range files {
+		file_name := file.Name()
+
+		var direction string
+		if strings.HasSuffix(file_name, ".up.sql") {
+			direction = "up"
+		} else if strings.HasSuffix(file_name, ".down.sql") {
+			direction = "down"
+		} else {
+			return nil, fmt.Errorf("migration %s must end with .up.sql or .down.sql", file_name)
+		}
+
+		base_name := strings.TrimSuffix(file_name, "."+direction+".sql")
+		version_part, name, found := strings.Cut(base_name, "_")
+		if !found {
+			return nil, fmt.Errorf("migration %s must be named <version>_<name>", file_name)
+		}
+		version, err := strconv.Atoi(version_part)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s has invalid version", file_name)
+		}
+
+		sql, err := migrations_fs.ReadFile(path.Join("migrations", file_name))
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := migrations_by_version[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: name}
+			migrations_by_version[version] = migration
+		}
+		if direction == "up" {
+			migration.up_sql = string(sql)
+		} else {
+			migration.down_sql = string(sql)
+		}
+	}
+
+	migrations := []Migration{}
+	for _, migration := range migrations_by_version {
+		if migration.up_sql == "" || migration.down_sql == "" {
+			return nil, fmt.Errorf("migration %d must have both up and down files", migration.Version)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	for i, migration := range migrations {
+		if migration.Version != i+1 {
+			return nil, fmt.Errorf("migration versions must be sequential, expected %d, got %d", i+1, migration.Version)
+		}
+	}
+	return migrations, nil
+}
+
+func ExpectedSchemaVersion() int {
+	migrations, err := loadMigrations()
+	if err != nil {
+		return 0
+	}
+	return len(migrations)
+}
+
+func GetMigrations(
+	ctx context.Context,
+	pool *pgxpool.Pool,
+	log *logrus.Logger,
+) ([]Migration, error) {
+	migrations, err := loadMigrations()
+	if err != nil {
+		log.Error("Error loading migrations: ", err)
+		return nil, repo_errors.OperationError{}
+	}
+
+	conn, err := pool.Acquire(ctx)
+	if err != nil {
+		log.Error("Error with acquiring connection:", err)
+		return nil, repo_errors.OperationError{}
+	}
+	defer conn.Release()
+
+	applied, err := getAppliedMigrations(ctx, conn)
+	if err != nil {
+		log.Error("Error getting applied migrations: ", err)
+		return nil, repo_errors.OperationError{}
+	}
+
+	for i := range migrations {
+		if applied_at, ok := applied[migrations[i].Version]; ok {
+			migrations[i].AppliedAt = &applied_at
+		}
+	}
+	return migrations, nil
+}
+
+func GetSchemaVersion(
+	ctx context.Context,
+	pool *pgxpool.Pool,
+	log *logrus.Logger,
+) (int, error) {
+	conn, err := pool.Acquire(ctx)
+	if err != nil {
+		log.Error("Error with acquiring connection:", err)
+		return 0, repo_errors.OperationError{}
+	}
+	defer conn.Release()
+
+	applied, err := getAppliedMigrations(ctx, conn)
+	if err != nil {
+		log.Error("Error getting applied migrations: ", err)
+		return 0, repo_errors.OperationError{}
+	}
+
+	version := 0
+	for applied_version := range applied {
+		if applied_version > version {
+			version = applied_version
+		}
+	}
+	return version, nil
+}
+
+func CheckSchemaVersion(
+	ctx context.Context,
+	pool *pgxpool.Pool,
+	log *logrus.Logger,
+) error {
+	version, err := GetSchemaVersion(ctx, pool, log)
+	if err != nil {
+		return err
+	}
+	expected_version := ExpectedSchemaVersion()
+	if version != expected_version {
+		return repo_errors.SchemaVersionError{Current: version, Expected: expected_version}
+	}
+	return nil
+}
+
+func MigrateUp(
+	ctx context.Context,
+	pool *pgxpool.Pool,
+	log *logrus.Logger,
+) error {
+	migrations, err := loadMigrations()
+	if err != nil {
+		log.Error("Error loading migrations: ", err)
+		return repo_errors.OperationError{}
+	}
+
+	conn, err := acquireMigrationsLock(ctx, pool, log)
+	if err != nil {
+		return err
+	}
+	defer releaseMigrationsLock(ctx, conn, log)
+
+	applied, err := getAppliedMigrations(ctx, conn)
+	if err != nil {
+		log.Error("Error getting applied migrations: ", err)
+		return repo_errors.OperationError{}
+	}
+
+	for _, migration := range migrations {
+		if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+		err = applyMigration(
+			ctx,
+			conn,
+			migration.up_sql,
+			`INSERT INTO schema_migrations (version, name) VALUES ($1, $2)`,
+			migration.Version, migration.Name,
+		)
+		if err != nil {
+			log.Errorf("Error applying migration %d_%s: %s", migration.Version, migration.Name, err)
+			return repo_errors.OperationError{}
+		}
+		log.Infof("Applied migration %d_%s", migration.Version, migration.Name)
+	}
+	return nil
+}
+
+func MigrateDown(
+	ctx context.Context,
+	pool *pgxpool.Pool,
+	log *logrus.Logger,
+	steps int,
+) error {
+	migrations, err := loadMigrations()
+	if err != nil {
+		log.Error("Error loading migrations: ", err)
+		return repo_errors.OperationError{}
+	}
+
+	conn, err := acquireMigrationsLock(ctx, pool, log)
+	if err != nil {
+		return err
+	}
+	defer releaseMigrationsLock(ctx, conn, log)
+
+	applied, err := getAppliedMigrations(ctx, conn)
+	if err != nil {
+		log.Error("Error getting applied migrations: ", err)
+		return repo_errors.OperationError{}
+	}
+
+	for i := len(migrations) - 1; i >= 0 && steps > 0; i-- {
+		migration := migrations[i]
+		if _, ok := applied[migration.Version]; !ok {
+			continue
+		}
+		err = applyMigration(
+			ctx,
+			conn,
+			migration.down_sql,
+			`DELETE FROM schema_migrations WHERE version=$1`,
+			migration.Version,
+		)
+		if err != nil {
+			log.Errorf("Error reverting migration %d_%s: %s", migration.Version, migration.Name, err)
+			return repo_errors.OperationError{}
+		}
+		log.Infof("Reverted migration %d_%s", migration.Version, migration.Name)
+		steps--
+	}
+	return nil
+}
+
+func acquireMigrationsLock(
+	ctx context.Context,
+	pool *pgxpool.Pool,
+	log *logrus.Logger,
+) (*pgxpool.Conn, error) {
+	conn, err := pool.Acquire(ctx)
+	if err != nil {
+		log.Error("Error with acquiring connection:", err)
+		return nil, repo_errors.OperationError{}
+	}
+
+	_, err = conn.Exec(ctx, `SELECT pg_advisory_lock($1)`, migrations_lock_id)
+	if err != nil {
+		conn.Release()
+		log.Error("Error acquiring migrations lock: ", err)
+		return nil, repo_errors.OperationError{}
+	}
+
+	_, err = conn.Exec(
+		ctx,
+		`CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INTEGER PRIMARY KEY,
+			name VARCHAR(255) NOT NULL,
+			applied_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)`,
+	)
+	if err != nil {
+		releaseMigrationsLock(ctx, conn, log)
+		log.Error("Error creating schema_migrations table: ", err)
+		return nil, repo_errors.OperationError{}
+	}
+	return conn, nil
+}
+
+func releaseMigrationsLock(ctx context.Context, conn *pgxpool.Conn, log *logrus.Logger) {
+	_, err := conn.Exec(ctx, `SELECT pg_advisory_unlock($1)`, migrations_lock_id)
+	if err != nil {
+		log.Error("Error releasing migrations lock: ", err)
+	}
+	conn.Release()
+}
+
+func applyMigration(
+	ctx context.Context,
+	conn *pgxpool.Conn,
+	migration_sql string,
+	tracking_sql string,
+	tracking_args ...interface{},
+) error {
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err = tx.Exec(ctx, migration_sql); err != nil {
+		return err
+	}
+	if _, err = tx.Exec(ctx, tracking_sql, tracking_args...); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func getAppliedMigrations(ctx context.Context, conn *pgxpool.Conn) (map[int]time.Time, error) {
+	applied := map[int]time.Time{}
+
+	var table_exists bool
+	err := conn.QueryRow(
+		ctx,
+		`SELECT to_regclass('schema_migrations') IS NOT NULL`,
+	).Scan(&table_exists)
+	if err != nil || !table_exists {
+		return applied, err
+	}
+
+	rows, err := conn.Query(ctx, `SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return applied, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var version int
+		var applied_at time.Time
+		if err = rows.Scan(&version, &applied_at); err != nil {
+			return applied, err
+		}
+		applied[version] = applied_at
+	}
+	return applied, rows.Err()
+}
@@ -1,2 +1 @@
-DROP TABLE IF EXISTS tasks;
 DROP TABLE IF EXISTS users;
@@ -0,0 +1,11 @@
+-- IF NOT EXISTS adopts databases created by the former schema.sql, which
+-- had the same tables.
+CREATE TABLE IF NOT EXISTS users (
+    user_id SERIAL PRIMARY KEY,
+    passport_serie INTEGER NOT NULL,
+    passport_number INTEGER NOT NULL,
+    surname VARCHAR(255),
+    name VARCHAR(255),
+
+    UNIQUE (passport_serie, passport_number)
+);
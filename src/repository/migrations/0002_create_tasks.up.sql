@@ -0,0 +1,8 @@
+-- See 0001_create_users for IF NOT EXISTS.
+CREATE TABLE IF NOT EXISTS tasks (
+    task_id SERIAL PRIMARY KEY,
+    user_id INTEGER REFERENCES users(user_id),
+    task_name VARCHAR(255) NOT NULL,
+    start_time TIMESTAMPTZ  NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    end_time TIMESTAMPTZ
+);
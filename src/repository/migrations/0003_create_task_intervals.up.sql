@@ -0,0 +1,12 @@
+CREATE TABLE task_intervals (
+    interval_id SERIAL PRIMARY KEY,
+    task_id INTEGER NOT NULL REFERENCES tasks(task_id) ON DELETE CASCADE,
+    start_time TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    end_time TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX task_intervals_open_idx ON task_intervals (task_id) WHERE end_time IS NULL;
+
+-- Tasks created before intervals existed ran as a single interval.
+INSERT INTO task_intervals (task_id, start_time, end_time)
+SELECT task_id, start_time, end_time FROM tasks;
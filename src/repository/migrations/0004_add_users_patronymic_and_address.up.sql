@@ -0,0 +1,2 @@
+ALTER TABLE users ADD COLUMN patronymic VARCHAR(255);
+ALTER TABLE users ADD COLUMN address VARCHAR(255);
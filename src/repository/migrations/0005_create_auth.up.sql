@@ -0,0 +1,8 @@
+ALTER TABLE users ADD COLUMN password_hash VARCHAR(255);
+
+CREATE TABLE refresh_tokens (
+    token_id VARCHAR(64) PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    expires_at TIMESTAMPTZ NOT NULL,
+    revoked_at TIMESTAMPTZ
+);
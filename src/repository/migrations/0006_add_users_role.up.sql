@@ -0,0 +1,3 @@
+ALTER TABLE users ADD COLUMN role VARCHAR(16) NOT NULL DEFAULT 'member'
+    CHECK (role IN ('admin', 'manager', 'member'));
+ALTER TABLE users ADD COLUMN manager_id INTEGER REFERENCES users(user_id) ON DELETE SET NULL;
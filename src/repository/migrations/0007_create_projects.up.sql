@@ -0,0 +1,8 @@
+CREATE TABLE projects (
+    project_id SERIAL PRIMARY KEY,
+    project_name VARCHAR(255) NOT NULL UNIQUE
+);
+
+ALTER TABLE tasks ADD COLUMN project_id INTEGER REFERENCES projects(project_id) ON DELETE SET NULL;
+
+CREATE INDEX tasks_project_id_idx ON tasks (project_id);
@@ -0,0 +1,4 @@
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_passport_digits;
+ALTER TABLE users
+    ALTER COLUMN passport_serie TYPE INTEGER USING passport_serie::integer,
+    ALTER COLUMN passport_number TYPE INTEGER USING passport_number::integer;
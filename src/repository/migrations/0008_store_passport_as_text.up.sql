@@ -0,0 +1,9 @@
+-- Restores leading zeros lost by the integer columns. Negative values fail
+-- the check and have to be fixed by hand.
+ALTER TABLE users
+    ALTER COLUMN passport_serie TYPE VARCHAR(16)
+        USING lpad(passport_serie::text, greatest(4, length(passport_serie::text)), '0'),
+    ALTER COLUMN passport_number TYPE VARCHAR(16)
+        USING lpad(passport_number::text, greatest(6, length(passport_number::text)), '0');
+ALTER TABLE users ADD CONSTRAINT users_passport_digits
+    CHECK (passport_serie ~ '^[0-9]+$' AND passport_number ~ '^[0-9]+$');
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS users_passport_active_idx;
+ALTER TABLE users ADD CONSTRAINT users_passport_serie_passport_number_key
+    UNIQUE (passport_serie, passport_number);
+ALTER TABLE users DROP COLUMN IF EXISTS deleted_at;
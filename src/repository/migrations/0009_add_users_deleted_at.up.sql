@@ -0,0 +1,4 @@
+ALTER TABLE users ADD COLUMN deleted_at TIMESTAMPTZ;
+ALTER TABLE users DROP CONSTRAINT users_passport_serie_passport_number_key;
+CREATE UNIQUE INDEX users_passport_active_idx ON users (passport_serie, passport_number)
+    WHERE deleted_at IS NULL;
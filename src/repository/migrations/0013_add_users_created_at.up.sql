@@ -0,0 +1,9 @@
+-- Existing users get the time of the migration as their creation time.
+ALTER TABLE users ADD COLUMN created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP;
+
+-- Indexes GET /users sorts by. Names use the C collation so they are ordered
+-- by code point and prefix filters can use the index. NULL names are sorted
+-- as empty strings, since keyset pagination compares (column, user_id) pairs.
+CREATE INDEX users_surname_idx ON users ((COALESCE(surname, '') COLLATE "C"), user_id) WHERE deleted_at IS NULL;
+CREATE INDEX users_name_idx ON users ((COALESCE(name, '') COLLATE "C"), user_id) WHERE deleted_at IS NULL;
+CREATE INDEX users_created_at_idx ON users (created_at, user_id) WHERE deleted_at IS NULL;
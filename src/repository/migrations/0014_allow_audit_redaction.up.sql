@@ -0,0 +1,15 @@
+-- Purging a user redacts their personal data from the changes of their audit
+-- events. Nothing else about an event may change, and only transactions that
+-- turn on task_tracker.redact_audit_events may do it.
+CREATE OR REPLACE FUNCTION audit_events_append_only() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'UPDATE'
+        AND current_setting('task_tracker.redact_audit_events', true) = 'on'
+        AND (NEW.event_id, NEW.actor_id, NEW.action, NEW.entity, NEW.entity_id, NEW.request_id, NEW.created_at)
+            IS NOT DISTINCT FROM (OLD.event_id, OLD.actor_id, OLD.action, OLD.entity, OLD.entity_id, OLD.request_id, OLD.created_at)
+    THEN
+        RETURN NEW;
+    END IF;
+    RAISE EXCEPTION 'audit_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
@@ -1,33 +1,25 @@
 package repository
 
 import (
-	"fmt"
-	"os"
-
 	"context"
+	"task_tracker/src/config"
 
+	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
-	"github.com/joho/godotenv"
 	"github.com/sirupsen/logrus"
 )
 
-func GetPostgresPool(ctx context.Context, log *logrus.Logger) *pgxpool.Pool {
-	godotenv.Load()
-
-	postgresUrl := fmt.Sprintf(
-		"postgres://%s:%s@%s:%s/%s",
-		os.Getenv("PG_USER"),
-		os.Getenv("PG_PASSWORD"),
-		os.Getenv("PG_HOST"),
-		os.Getenv("PG_PORT"),
-		os.Getenv("PG_DATABASE"),
-	)
-	config, err := pgxpool.ParseConfig(postgresUrl)
+// GetPostgresPool uses ctx only for the initial ping, the pool lives until
+// it is closed.
+func GetPostgresPool(ctx context.Context, postgres_config config.PostgresConfig, log *logrus.Logger) *pgxpool.Pool {
+	pool_config, err := pgxpool.ParseConfig(postgres_config.URL())
 	if err != nil {
-		log.Error("Error with parsing config", err)
+		log.Fatal("Error with parsing config", err)
 	}
+	pool_config.ConnConfig.Logger = queryTracer{}
+	pool_config.ConnConfig.LogLevel = pgx.LogLevelInfo
 
-	pool, err := pgxpool.ConnectConfig(context.Background(), config)
+	pool, err := pgxpool.ConnectConfig(context.Background(), pool_config)
 	if err != nil {
 		log.Fatal("Error with connecting to DB", err)
 	}
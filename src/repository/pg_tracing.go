@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"task_tracker/src/tracing"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// queryTracer turns the statements pgx logs after they complete into client
+// spans, backdated by the reported duration. pgx v4 has no tracer hooks, but
+// its logger receives the query context, so spans nest under the caller.
+// Arguments are left out since they may hold personal data.
+type queryTracer struct{}
+
+var traced_operations = map[string]bool{
+	"Query":     true,
+	"Exec":      true,
+	"CopyFrom":  true,
+	"SendBatch": true,
+}
+
+func (t queryTracer) Log(ctx context.Context, level pgx.LogLevel, msg string, data map[string]interface{}) {
+	if !traced_operations[msg] || !trace.SpanFromContext(ctx).IsRecording() {
+		return
+	}
+	duration, _ := data["time"].(time.Duration)
+	end_time := time.Now()
+
+	attributes := []attribute.KeyValue{attribute.String("db.system", "postgresql")}
+	if sql, ok := data["sql"].(string); ok {
+		attributes = append(attributes, attribute.String("db.statement", sql))
+	}
+	if table_name, ok := data["tableName"]; ok {
+		attributes = append(attributes, attribute.String("db.sql.table", fmt.Sprint(table_name)))
+	}
+	if row_count, ok := data["rowCount"].(int); ok {
+		attributes = append(attributes, attribute.Int("db.row_count", row_count))
+	}
+
+	_, span := tracing.Tracer().Start(
+		ctx,
+		"pgx."+msg,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithTimestamp(end_time.Add(-duration)),
+		trace.WithAttributes(attributes...),
+	)
+	if err, ok := data["err"].(error); ok {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End(trace.WithTimestamp(end_time))
+}
@@ -0,0 +1,170 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"task_tracker/src/entities"
+	"task_tracker/src/errors/repo_errors"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+)
+
+func (r *PostgresRepository) CreateProject(
+	ctx context.Context,
+	project entities.CreateProjectRequest,
+) (entities.Project, error) {
+	conn, release, err := r.acquire(ctx)
+	if err != nil {
+		r.logger(ctx).Error("Error with acquiring connection:", err)
+		return entities.Project{}, repo_errors.OperationError{}
+	}
+	defer release()
+
+	var created_project entities.Project
+	err = conn.QueryRow(
+		ctx,
+		`INSERT INTO projects (project_name)
+		VALUES ($1)
+		RETURNING project_id, project_name`,
+		project.ProjectName,
+	).Scan(&created_project.ProjectId, &created_project.ProjectName)
+	if err != nil {
+		var pg_err *pgconn.PgError
+		if errors.As(err, &pg_err) && pg_err.Code == "23505" {
+			r.logger(ctx).Errorf("error: %s. Detail: %s", pg_err.Error(), pg_err.Detail)
+			return entities.Project{}, repo_errors.ObjectAlreadyExistsError{}
+		}
+		r.logger(ctx).Error("Error creating project: ", err)
+		return entities.Project{}, repo_errors.OperationError{}
+	}
+	return created_project, nil
+}
+
+func (r *PostgresRepository) GetProject(
+	ctx context.Context,
+	project_id int,
+) (entities.Project, error) {
+	conn, release, err := r.acquire(ctx)
+	if err != nil {
+		r.logger(ctx).Error("Error with acquiring connection:", err)
+		return entities.Project{}, repo_errors.OperationError{}
+	}
+	defer release()
+
+	var project entities.Project
+	err = conn.QueryRow(
+		ctx,
+		`SELECT project_id, project_name
+		FROM projects
+		WHERE project_id=$1`,
+		project_id,
+	).Scan(&project.ProjectId, &project.ProjectName)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			r.logger(ctx).Errorf("error: %s. Detail: %s=%d", err.Error(), "project_id", project_id)
+			return entities.Project{}, repo_errors.ObjectNotFoundError{}
+		}
+		r.logger(ctx).Error("Error getting project: ", err)
+		return entities.Project{}, repo_errors.OperationError{}
+	}
+	return project, nil
+}
+
+func (r *PostgresRepository) GetProjects(ctx context.Context) ([]entities.Project, error) {
+	conn, release, err := r.acquire(ctx)
+
+	projects := []entities.Project{}
+	if err != nil {
+		r.logger(ctx).Error("Error with acquiring connection:", err)
+		return projects, repo_errors.OperationError{}
+	}
+	defer release()
+
+	rows, err := conn.Query(
+		ctx,
+		`SELECT project_id, project_name
+		FROM projects
+		ORDER BY project_id`,
+	)
+	if err != nil {
+		r.logger(ctx).Error("Error getting projects:", err)
+		return projects, repo_errors.OperationError{}
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var project entities.Project
+		if err := rows.Scan(&project.ProjectId, &project.ProjectName); err != nil {
+			r.logger(ctx).Error("Error scanning project:", err)
+			return projects, repo_errors.OperationError{}
+		}
+		projects = append(projects, project)
+	}
+	return projects, nil
+}
+
+func (r *PostgresRepository) UpdateProject(
+	ctx context.Context,
+	project entities.UpdateProjectRequest,
+	project_id int,
+) (entities.Project, error) {
+	conn, release, err := r.acquire(ctx)
+	if err != nil {
+		r.logger(ctx).Error("Error with acquiring connection:", err)
+		return entities.Project{}, repo_errors.OperationError{}
+	}
+	defer release()
+
+	var updated_project entities.Project
+	err = conn.QueryRow(
+		ctx,
+		`UPDATE projects
+		SET project_name=$1
+		WHERE project_id=$2
+		RETURNING project_id, project_name`,
+		*project.ProjectName, project_id,
+	).Scan(&updated_project.ProjectId, &updated_project.ProjectName)
+	if err != nil {
+		var pg_err *pgconn.PgError
+		if errors.As(err, &pg_err) && pg_err.Code == "23505" {
+			r.logger(ctx).Errorf("error: %s. Detail: %s", pg_err.Error(), pg_err.Detail)
+			return entities.Project{}, repo_errors.ObjectAlreadyExistsError{}
+		}
+		if errors.Is(err, pgx.ErrNoRows) {
+			r.logger(ctx).Errorf("error: %s. Detail: %s=%d", err.Error(), "project_id", project_id)
+			return entities.Project{}, repo_errors.ObjectNotFoundError{}
+		}
+		r.logger(ctx).Errorf("Error updating project: %s", err)
+		return entities.Project{}, repo_errors.OperationError{}
+	}
+	return updated_project, nil
+}
+
+func (r *PostgresRepository) DeleteProject(
+	ctx context.Context,
+	project_id int,
+) error {
+	conn, release, err := r.acquire(ctx)
+	if err != nil {
+		r.logger(ctx).Error("Error with acquiring connection:", err)
+		return repo_errors.OperationError{}
+	}
+	defer release()
+
+	command_tag, err := conn.Exec(
+		ctx,
+		`DELETE FROM projects
+		WHERE project_id=$1`,
+		project_id,
+	)
+	if err != nil {
+		r.logger(ctx).Error("Error deleting project:", err)
+		return repo_errors.OperationError{}
+	}
+	if command_tag.RowsAffected() == 0 {
+		r.logger(ctx).Errorf("error: project not found. Detail: project_id=%d", project_id)
+		return repo_errors.ObjectNotFoundError{}
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+package repository
+
+import (
+	"context"
+	"task_tracker/src/entities"
+	"task_tracker/src/utils"
+	"time"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/sirupsen/logrus"
+)
+
+// UnitOfWork groups repository calls made with the ctx passed to fn into one
+// transaction.
+type UnitOfWork interface {
+	WithTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type AccessRepository interface {
+	GetUserAccess(ctx context.Context, user_id int) (entities.UserAccess, error)
+	SetUserRole(ctx context.Context, access entities.UserAccess) error
+}
+
+type ProjectRepository interface {
+	AccessRepository
+	CreateProject(ctx context.Context, project entities.CreateProjectRequest) (entities.Project, error)
+	GetProject(ctx context.Context, project_id int) (entities.Project, error)
+	GetProjects(ctx context.Context) ([]entities.Project, error)
+	UpdateProject(ctx context.Context, project entities.UpdateProjectRequest, project_id int) (entities.Project, error)
+	DeleteProject(ctx context.Context, project_id int) error
+}
+
+type UserRepository interface {
+	AccessRepository
+	UnitOfWork
+	CreateUser(ctx context.Context, user entities.User) (int, error)
+	UpdateUser(ctx context.Context, user entities.UserUpdateRepo, user_id int) (entities.User, error)
+	DeleteUser(ctx context.Context, user_id int) error
+	RestoreUser(ctx context.Context, user_id int) error
+	GetUser(ctx context.Context, user_id int) (entities.User, error)
+	GetUserVersion(ctx context.Context, user_id int) (int, error)
+	PurgeDeletedUsers(ctx context.Context, deleted_before time.Time) (int, error)
+	GetUsers(ctx context.Context, query entities.UsersQuery) ([]entities.User, error)
+	GetUserActivity(ctx context.Context, filters entities.UserActivityRequest) ([]entities.UserActivityTask, error)
+	StreamUserActivity(
+		ctx context.Context,
+		filters entities.UserActivityRequest,
+		fn func(task entities.UserActivityTask) error,
+	) error
+	SetUserPassword(ctx context.Context, user_id int, password_hash string) error
+}
+
+type TaskRepository interface {
+	AccessRepository
+	UnitOfWork
+	GetProject(ctx context.Context, project_id int) (entities.Project, error)
+	CreateTask(ctx context.Context, task entities.CreateTaskRequest) (*entities.CreateTaskResponse, error)
+	FinishTask(ctx context.Context, task_id int) error
+	PauseTask(ctx context.Context, task_id int) error
+	ResumeTask(ctx context.Context, task_id int) error
+	ReopenTask(ctx context.Context, task_id int) error
+	GetTask(ctx context.Context, task_id int) (entities.Task, error)
+	GetTaskVersion(ctx context.Context, task_id int) (int, error)
+	GetTasks(ctx context.Context, filters entities.TasksFilter) ([]entities.Task, error)
+	UpdateTask(ctx context.Context, task entities.UpdateTaskRequest, task_id int) (entities.Task, error)
+	DeleteTask(ctx context.Context, task_id int) error
+}
+
+type TimesheetRepository interface {
+	AccessRepository
+	GetUsers(ctx context.Context, query entities.UsersQuery) ([]entities.User, error)
+	GetTaskIntervals(ctx context.Context, filters entities.TaskIntervalsFilter) ([]entities.TaskInterval, error)
+	StreamTaskIntervals(
+		ctx context.Context,
+		filters entities.TaskIntervalsFilter,
+		fn func(interval entities.TaskInterval) error,
+	) error
+}
+
+type AuthRepository interface {
+	UnitOfWork
+	GetUserCredentials(ctx context.Context, passport_serie string, passport_number string) (entities.UserCredentials, error)
+	SaveRefreshToken(ctx context.Context, token entities.RefreshToken) error
+	GetRefreshToken(ctx context.Context, token_id string) (entities.RefreshToken, error)
+	RevokeRefreshToken(ctx context.Context, token_id string) (bool, error)
+}
+
+type AuditRepository interface {
+	AccessRepository
+	GetAuditEvents(ctx context.Context, filter entities.AuditEventsFilter) ([]entities.AuditEvent, int, error)
+}
+
+type IdempotencyRepository interface {
+	ReserveIdempotencyKey(ctx context.Context, record entities.IdempotencyRecord) (entities.IdempotencyRecord, bool, error)
+	SaveIdempotentResponse(ctx context.Context, record entities.IdempotencyRecord) error
+	ReleaseIdempotencyKey(ctx context.Context, user_id int, key string) error
+	PurgeExpiredIdempotencyKeys(ctx context.Context, now time.Time) (int, error)
+}
+
+// RetentionRepository is used by the background job purging expired data.
+type RetentionRepository interface {
+	UserRepository
+	IdempotencyRepository
+}
+
+type HealthRepository interface {
+	Ping(ctx context.Context) error
+	CheckSchemaVersion(ctx context.Context) error
+}
+
+type PostgresRepository struct {
+	pool *pgxpool.Pool
+	log  *logrus.Logger
+}
+
+func NewPostgresRepository(pool *pgxpool.Pool, log *logrus.Logger) *PostgresRepository {
+	return &PostgresRepository{pool: pool, log: log}
+}
+
+// logger returns the request logger carrying the request id, user id and
+// route when called while handling a request.
+func (r *PostgresRepository) logger(ctx context.Context) *logrus.Entry {
+	return utils.LoggerFromContext(ctx, r.log)
+}
+
+var (
+	_ UserRepository      = (*PostgresRepository)(nil)
+	_ TaskRepository      = (*PostgresRepository)(nil)
+	_ AuthRepository      = (*PostgresRepository)(nil)
+	_ ProjectRepository   = (*PostgresRepository)(nil)
+	_ TimesheetRepository = (*PostgresRepository)(nil)
+	_ HealthRepository    = (*PostgresRepository)(nil)
+	_ AuditRepository     = (*PostgresRepository)(nil)
+	_ RetentionRepository = (*PostgresRepository)(nil)
+	_ UserRepository      = (*MemoryRepository)(nil)
+	_ TaskRepository      = (*MemoryRepository)(nil)
+	_ AuthRepository      = (*MemoryRepository)(nil)
+	_ ProjectRepository   = (*MemoryRepository)(nil)
+	_ TimesheetRepository = (*MemoryRepository)(nil)
+	_ HealthRepository    = (*MemoryRepository)(nil)
+	_ AuditRepository     = (*MemoryRepository)(nil)
+	_ RetentionRepository = (*MemoryRepository)(nil)
+)
@@ -3,81 +3,477 @@ package repository
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strings"
 	"task_tracker/src/entities"
 	"task_tracker/src/errors/repo_errors"
 
 	"github.com/jackc/pgconn"
-	"github.com/jackc/pgx/v4/pgxpool"
-	"github.com/sirupsen/logrus"
+	"github.com/jackc/pgx/v4"
 )
 
-func CreateTask(
+func (r *PostgresRepository) CreateTask(
 	ctx context.Context,
-	pool *pgxpool.Pool,
-	log *logrus.Logger,
 	task entities.CreateTaskRequest,
 ) (*entities.CreateTaskResponse, error) {
-	conn, err := pool.Acquire(ctx)
+	var created_task entities.CreateTaskResponse
+
+	err := r.inTx(ctx, func(tx querier) error {
+		err_create := tx.QueryRow(
+			ctx,
+			`WITH created AS (
+				INSERT INTO tasks (user_id, task_name, project_id) 
+				SELECT $1::INTEGER, $2::VARCHAR, $3::INTEGER
+				WHERE EXISTS (SELECT 1 FROM users WHERE user_id=$1 AND deleted_at IS NULL)
+				RETURNING user_id, task_name, project_id, task_id, start_time, version
+			), opened AS (
+				INSERT INTO task_intervals (task_id, start_time)
+				SELECT task_id, start_time FROM created
+			)
+			SELECT user_id, task_name, project_id, task_id, start_time, version FROM created`,
+			task.UserId, task.TaskName, task.ProjectId,
+		).Scan(
+			&created_task.UserId,
+			&created_task.TaskName,
+			&created_task.ProjectId,
+			&created_task.TaskId,
+			&created_task.CreatedAt,
+			&created_task.Version,
+		)
+
+		if err_create != nil {
+			var pg_err *pgconn.PgError
+			if errors.As(err_create, &pg_err) && pg_err.Code == "23503" {
+				r.logger(ctx).Errorf("error: %s. Detail: %s", pg_err.Error(), pg_err.Detail)
+				return repo_errors.ObjectNotFoundError{}
+			}
+			// No row is inserted for missing or deleted users.
+			if errors.Is(err_create, pgx.ErrNoRows) {
+				r.logger(ctx).Errorf("error: user not found. Detail: user_id=%d", task.UserId)
+				return repo_errors.ObjectNotFoundError{}
+			}
+			r.logger(ctx).Error("Error creating task: ", err_create)
+			return repo_errors.OperationError{}
+		}
+
+		changes := auditChanges(nil, taskAuditFields(entities.Task{
+			TaskId:    created_task.TaskId,
+			UserId:    created_task.UserId,
+			ProjectId: created_task.ProjectId,
+			TaskName:  created_task.TaskName,
+			StartTime: created_task.CreatedAt,
+		}))
+		return r.insertAuditEvent(ctx, tx, newAuditEvent(ctx, entities.AuditActionCreate, entities.AuditEntityTask, created_task.TaskId, changes))
+	})
 	if err != nil {
-		log.Error("Error with acquiring connection:", err)
-		return nil, repo_errors.OperationError{}
+		return nil, err
 	}
-	defer conn.Release()
-
-	var created_task entities.CreateTaskResponse
+	return &created_task, nil
+}
 
-	err_create := conn.QueryRow(
+// lockTask reads a task and locks the row until the end of tx.
+func (r *PostgresRepository) lockTask(
+	ctx context.Context,
+	tx querier,
+	task_id int,
+) (entities.Task, error) {
+	var task entities.Task
+	err := tx.QueryRow(
 		ctx,
-		`INSERT INTO tasks (user_id, task_name) 
-		VALUES ($1, $2) 
-		RETURNING user_id, task_name, task_id, start_time`,
-		task.UserId, task.TaskName,
+		`SELECT task_id, user_id, project_id, task_name, start_time, end_time, version
+		FROM tasks
+		WHERE task_id=$1
+		FOR UPDATE`,
+		task_id,
 	).Scan(
-		&created_task.UserId,
-		&created_task.TaskName,
-		&created_task.TaskId,
-		&created_task.CreatedAt,
+		&task.TaskId,
+		&task.UserId,
+		&task.ProjectId,
+		&task.TaskName,
+		&task.StartTime,
+		&task.EndTime,
+		&task.Version,
 	)
-
-	if err_create != nil {
-		var pg_err *pgconn.PgError
-		if errors.As(err_create, &pg_err) {
-			if pg_err.Code == "23503" {
-				log.Errorf("error: %s. Detail: %s", pg_err.Error(), pg_err.Detail)
-				return nil, repo_errors.ObjectNotFoundError{}
-			}
-		} else {
-			log.Error("Error creating task: ", err_create)
-			return nil, repo_errors.OperationError{}
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			r.logger(ctx).Errorf("error: %s. Detail: %s=%d", err.Error(), "task_id", task_id)
+			return entities.Task{}, repo_errors.ObjectNotFoundError{}
 		}
+		r.logger(ctx).Error("Error getting task: ", err)
+		return entities.Task{}, repo_errors.OperationError{}
 	}
-	return &created_task, nil
+	return task, nil
 }
 
-func FinishTask(
+func (r *PostgresRepository) FinishTask(
 	ctx context.Context,
-	pool *pgxpool.Pool,
-	log *logrus.Logger,
 	task_id int,
 ) error {
-	conn, err := pool.Acquire(ctx)
+	return r.inTx(ctx, func(tx querier) error {
+		task, err := r.lockTask(ctx, tx, task_id)
+		if err != nil {
+			return err
+		}
+		if task.EndTime != nil {
+			return repo_errors.InvalidStateError{}
+		}
+
+		finished_task := task
+		err = tx.QueryRow(
+			ctx,
+			`WITH finished AS (
+				UPDATE tasks 
+				SET end_time=current_timestamp, version=version+1
+				WHERE task_id=$1
+				RETURNING task_id, end_time, version
+			), closed AS (
+				UPDATE task_intervals
+				SET end_time=finished.end_time
+				FROM finished
+				WHERE task_intervals.task_id=finished.task_id AND task_intervals.end_time IS NULL
+			)
+			SELECT end_time, version FROM finished`,
+			task_id,
+		).Scan(&finished_task.EndTime, &finished_task.Version)
+		if err != nil {
+			r.logger(ctx).Error("Error finishing task: ", err)
+			return repo_errors.OperationError{}
+		}
+
+		changes := auditChanges(taskAuditFields(task), taskAuditFields(finished_task))
+		return r.insertAuditEvent(ctx, tx, newAuditEvent(ctx, entities.AuditActionFinish, entities.AuditEntityTask, task_id, changes))
+	})
+}
+
+func (r *PostgresRepository) PauseTask(
+	ctx context.Context,
+	task_id int,
+) error {
+	return r.inTx(ctx, func(tx querier) error {
+		if _, err := r.lockTask(ctx, tx, task_id); err != nil {
+			return err
+		}
+
+		command_tag, err := tx.Exec(
+			ctx,
+			`UPDATE task_intervals 
+			SET end_time=current_timestamp
+			WHERE task_id=$1 AND end_time IS NULL`,
+			task_id,
+		)
+		if err != nil {
+			r.logger(ctx).Error("Error pausing task: ", err)
+			return repo_errors.OperationError{}
+		}
+		if command_tag.RowsAffected() == 0 {
+			r.logger(ctx).Errorf("error: task is not running. Detail: task_id=%d", task_id)
+			return repo_errors.InvalidStateError{}
+		}
+		if err = r.bumpTaskVersion(ctx, tx, task_id); err != nil {
+			return err
+		}
+		return r.insertAuditEvent(ctx, tx, newAuditEvent(ctx, entities.AuditActionPause, entities.AuditEntityTask, task_id, pausedAuditChanges(true)))
+	})
+}
+
+func (r *PostgresRepository) ResumeTask(
+	ctx context.Context,
+	task_id int,
+) error {
+	return r.inTx(ctx, func(tx querier) error {
+		task, err := r.lockTask(ctx, tx, task_id)
+		if err != nil {
+			return err
+		}
+		if task.EndTime != nil {
+			r.logger(ctx).Errorf("error: task is finished. Detail: task_id=%d", task_id)
+			return repo_errors.InvalidStateError{}
+		}
+
+		_, err = tx.Exec(
+			ctx,
+			`INSERT INTO task_intervals (task_id) 
+			VALUES ($1)`,
+			task_id,
+		)
+		if err != nil {
+			var pg_err *pgconn.PgError
+			if errors.As(err, &pg_err) && pg_err.Code == "23505" {
+				r.logger(ctx).Errorf("error: %s. Detail: %s", pg_err.Error(), pg_err.Detail)
+				return repo_errors.InvalidStateError{}
+			}
+			r.logger(ctx).Error("Error resuming task: ", err)
+			return repo_errors.OperationError{}
+		}
+		if err = r.bumpTaskVersion(ctx, tx, task_id); err != nil {
+			return err
+		}
+		return r.insertAuditEvent(ctx, tx, newAuditEvent(ctx, entities.AuditActionResume, entities.AuditEntityTask, task_id, pausedAuditChanges(false)))
+	})
+}
+
+// bumpTaskVersion marks a change that is stored outside of the tasks row,
+// like pausing a task, so that ETags of the task go stale.
+func (r *PostgresRepository) bumpTaskVersion(ctx context.Context, tx querier, task_id int) error {
+	_, err := tx.Exec(ctx, `UPDATE tasks SET version=version+1 WHERE task_id=$1`, task_id)
 	if err != nil {
-		log.Error("Error with acquiring connection:", err)
+		r.logger(ctx).Error("Error updating task version: ", err)
 		return repo_errors.OperationError{}
 	}
-	defer conn.Release()
+	return nil
+}
+
+func (r *PostgresRepository) GetTask(
+	ctx context.Context,
+	task_id int,
+) (entities.Task, error) {
+	conn, release, err := r.acquire(ctx)
+	if err != nil {
+		r.logger(ctx).Error("Error with acquiring connection:", err)
+		return entities.Task{}, repo_errors.OperationError{}
+	}
+	defer release()
 
-	_, err = conn.Exec(
+	var task entities.Task
+	err = conn.QueryRow(
 		ctx,
-		`UPDATE tasks 
-		SET end_time=current_timestamp
+		`SELECT task_id, user_id, project_id, task_name, start_time, end_time, version
+		FROM tasks
 		WHERE task_id=$1`,
 		task_id,
+	).Scan(
+		&task.TaskId,
+		&task.UserId,
+		&task.ProjectId,
+		&task.TaskName,
+		&task.StartTime,
+		&task.EndTime,
+		&task.Version,
 	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			r.logger(ctx).Errorf("error: %s. Detail: %s=%d", err.Error(), "task_id", task_id)
+			return entities.Task{}, repo_errors.ObjectNotFoundError{}
+		}
+		r.logger(ctx).Error("Error getting task: ", err)
+		return entities.Task{}, repo_errors.OperationError{}
+	}
+	return task, nil
+}
 
+// GetTaskVersion returns the version of a task. Inside a unit of work the row
+// stays locked until it ends.
+func (r *PostgresRepository) GetTaskVersion(
+	ctx context.Context,
+	task_id int,
+) (int, error) {
+	conn, release, err := r.acquire(ctx)
 	if err != nil {
-		log.Error("Error finishing task: ", err)
-		return repo_errors.OperationError{}
+		r.logger(ctx).Error("Error with acquiring connection:", err)
+		return 0, repo_errors.OperationError{}
 	}
-	return nil
+	defer release()
+
+	var version int
+	err = conn.QueryRow(
+		ctx,
+		`SELECT version FROM tasks WHERE task_id=$1 FOR UPDATE`,
+		task_id,
+	).Scan(&version)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			r.logger(ctx).Errorf("error: %s. Detail: %s=%d", err.Error(), "task_id", task_id)
+			return 0, repo_errors.ObjectNotFoundError{}
+		}
+		r.logger(ctx).Error("Error getting task version: ", err)
+		return 0, repo_errors.OperationError{}
+	}
+	return version, nil
+}
+
+func (r *PostgresRepository) GetTasks(
+	ctx context.Context,
+	filters entities.TasksFilter,
+) ([]entities.Task, error) {
+	conn, release, err := r.acquire(ctx)
+
+	tasks := []entities.Task{}
+	if err != nil {
+		r.logger(ctx).Error("Error with acquiring connection:", err)
+		return tasks, repo_errors.OperationError{}
+	}
+	defer release()
+
+	where_clauses := []string{"TRUE"}
+	args := []interface{}{}
+	argID := 1
+
+	if filters.UserId != nil {
+		where_clauses = append(where_clauses, fmt.Sprintf("user_id=$%d", argID))
+		args = append(args, *filters.UserId)
+		argID++
+	}
+	if filters.ProjectId != nil {
+		where_clauses = append(where_clauses, fmt.Sprintf("project_id=$%d", argID))
+		args = append(args, *filters.ProjectId)
+		argID++
+	}
+	if filters.IsFinished != nil {
+		if *filters.IsFinished {
+			where_clauses = append(where_clauses, "end_time IS NOT NULL")
+		} else {
+			where_clauses = append(where_clauses, "end_time IS NULL")
+		}
+	}
+	if filters.DateFrom != nil {
+		where_clauses = append(where_clauses, fmt.Sprintf("start_time>=$%d::TIMESTAMPTZ", argID))
+		args = append(args, *filters.DateFrom)
+		argID++
+	}
+	if filters.DateTo != nil {
+		where_clauses = append(where_clauses, fmt.Sprintf("start_time<=$%d::TIMESTAMPTZ", argID))
+		args = append(args, *filters.DateTo)
+		argID++
+	}
+
+	where_query := strings.Join(where_clauses, " AND ")
+	query := fmt.Sprintf(
+		`SELECT task_id, user_id, project_id, task_name, start_time, end_time, version
+		FROM tasks
+		WHERE %s
+		ORDER BY task_id;`,
+		where_query,
+	)
+
+	rows, err := conn.Query(ctx, query, args...)
+	if err != nil {
+		r.logger(ctx).Error("Error getting tasks:", err)
+		return tasks, repo_errors.OperationError{}
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var task entities.Task
+		err = rows.Scan(
+			&task.TaskId,
+			&task.UserId,
+			&task.ProjectId,
+			&task.TaskName,
+			&task.StartTime,
+			&task.EndTime,
+			&task.Version,
+		)
+		if err != nil {
+			r.logger(ctx).Error("Error scanning task:", err)
+			return tasks, repo_errors.OperationError{}
+		}
+		tasks = append(tasks, task)
+	}
+	return tasks, nil
+}
+
+func (r *PostgresRepository) UpdateTask(
+	ctx context.Context,
+	task entities.UpdateTaskRequest,
+	task_id int,
+) (entities.Task, error) {
+	var updated_task entities.Task
+	err := r.inTx(ctx, func(tx querier) error {
+		old_task, err := r.lockTask(ctx, tx, task_id)
+		if err != nil {
+			return err
+		}
+
+		err = tx.QueryRow(
+			ctx,
+			`UPDATE tasks 
+			SET task_name=$1, version=version+1
+			WHERE task_id=$2
+			RETURNING task_id, user_id, project_id, task_name, start_time, end_time, version`,
+			*task.TaskName, task_id,
+		).Scan(
+			&updated_task.TaskId,
+			&updated_task.UserId,
+			&updated_task.ProjectId,
+			&updated_task.TaskName,
+			&updated_task.StartTime,
+			&updated_task.EndTime,
+			&updated_task.Version,
+		)
+		if err != nil {
+			r.logger(ctx).Errorf("Error updating task: %s", err)
+			return repo_errors.OperationError{}
+		}
+
+		changes := auditChanges(taskAuditFields(old_task), taskAuditFields(updated_task))
+		return r.insertAuditEvent(ctx, tx, newAuditEvent(ctx, entities.AuditActionUpdate, entities.AuditEntityTask, task_id, changes))
+	})
+	if err != nil {
+		return entities.Task{}, err
+	}
+	return updated_task, nil
+}
+
+func (r *PostgresRepository) DeleteTask(
+	ctx context.Context,
+	task_id int,
+) error {
+	return r.inTx(ctx, func(tx querier) error {
+		task, err := r.lockTask(ctx, tx, task_id)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(
+			ctx,
+			`DELETE FROM tasks 
+			WHERE task_id=$1`,
+			task_id,
+		)
+		if err != nil {
+			r.logger(ctx).Error("Error deleting task:", err)
+			return repo_errors.OperationError{}
+		}
+
+		changes := auditChanges(taskAuditFields(task), nil)
+		return r.insertAuditEvent(ctx, tx, newAuditEvent(ctx, entities.AuditActionDelete, entities.AuditEntityTask, task_id, changes))
+	})
+}
+
+func (r *PostgresRepository) ReopenTask(
+	ctx context.Context,
+	task_id int,
+) error {
+	return r.inTx(ctx, func(tx querier) error {
+		task, err := r.lockTask(ctx, tx, task_id)
+		if err != nil {
+			return err
+		}
+		if task.EndTime == nil {
+			r.logger(ctx).Errorf("error: task is not finished. Detail: task_id=%d", task_id)
+			return repo_errors.InvalidStateError{}
+		}
+
+		_, err = tx.Exec(
+			ctx,
+			`WITH reopened AS (
+				UPDATE tasks 
+				SET end_time=NULL, version=version+1
+				WHERE task_id=$1
+				RETURNING task_id
+			)
+			INSERT INTO task_intervals (task_id)
+			SELECT task_id FROM reopened`,
+			task_id,
+		)
+		if err != nil {
+			r.logger(ctx).Error("Error reopening task: ", err)
+			return repo_errors.OperationError{}
+		}
+
+		reopened_task := task
+		reopened_task.EndTime = nil
+		changes := auditChanges(taskAuditFields(task), taskAuditFields(reopened_task))
+		return r.insertAuditEvent(ctx, tx, newAuditEvent(ctx, entities.AuditActionReopen, entities.AuditEntityTask, task_id, changes))
+	})
 }
@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"context"
+	"task_tracker/src/entities"
+	"task_tracker/src/errors/repo_errors"
+)
+
+func (r *PostgresRepository) GetTaskIntervals(
+	ctx context.Context,
+	filters entities.TaskIntervalsFilter,
+) ([]entities.TaskInterval, error) {
+	intervals := []entities.TaskInterval{}
+	err := r.StreamTaskIntervals(ctx, filters, func(interval entities.TaskInterval) error {
+		intervals = append(intervals, interval)
+		return nil
+	})
+	return intervals, err
+}
+
+// StreamTaskIntervals calls fn for every interval ordered by user, task and
+// start time. Errors returned by fn are passed through.
+func (r *PostgresRepository) StreamTaskIntervals(
+	ctx context.Context,
+	filters entities.TaskIntervalsFilter,
+	fn func(interval entities.TaskInterval) error,
+) error {
+	conn, release, err := r.acquire(ctx)
+	if err != nil {
+		r.logger(ctx).Error("Error with acquiring connection:", err)
+		return repo_errors.OperationError{}
+	}
+	defer release()
+
+	// A nil user list means all users.
+	rows, err := conn.Query(
+		ctx,
+		`SELECT 
+			tasks.task_id,
+			tasks.user_id,
+			tasks.task_name,
+			task_intervals.start_time,
+			COALESCE(task_intervals.end_time, current_timestamp)
+		FROM task_intervals
+		JOIN tasks USING (task_id)
+		WHERE task_intervals.start_time < $2::TIMESTAMPTZ
+			AND COALESCE(task_intervals.end_time, current_timestamp) > $1::TIMESTAMPTZ
+			AND ($3::INTEGER[] IS NULL OR tasks.user_id = ANY($3::INTEGER[]))
+		ORDER BY tasks.user_id, tasks.task_id, task_intervals.start_time`,
+		filters.From, filters.To, filters.UserIds,
+	)
+	if err != nil {
+		r.logger(ctx).Error("Error getting task intervals:", err)
+		return repo_errors.OperationError{}
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var interval entities.TaskInterval
+		err = rows.Scan(
+			&interval.TaskId,
+			&interval.UserId,
+			&interval.TaskName,
+			&interval.StartTime,
+			&interval.EndTime,
+		)
+		if err != nil {
+			r.logger(ctx).Error("Error scanning task interval:", err)
+			return repo_errors.OperationError{}
+		}
+		if err := fn(interval); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		r.logger(ctx).Error("Error getting task intervals:", err)
+		return repo_errors.OperationError{}
+	}
+	return nil
+}
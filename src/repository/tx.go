@@ -0,0 +1,167 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"task_tracker/src/errors/repo_errors"
+	"time"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	max_tx_attempts = 3
+	tx_retry_delay  = 20 * time.Millisecond
+)
+
+// querier is implemented by pool connections and by unitOfWork, so
+// repository functions run the same statements inside and outside WithTx.
+type querier interface {
+	Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
+}
+
+type txContextKey struct{}
+
+// unitOfWork is the transaction of a WithTx call. Repository functions
+// replace driver errors with repo_errors, so it remembers on its own whether
+// a statement failed in a way that makes retrying the transaction worthwhile.
+type unitOfWork struct {
+	tx        pgx.Tx
+	retryable bool
+}
+
+func (u *unitOfWork) check(err error) error {
+	if isRetryableTxError(err) {
+		u.retryable = true
+	}
+	return err
+}
+
+func (u *unitOfWork) Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error) {
+	command_tag, err := u.tx.Exec(ctx, sql, args...)
+	return command_tag, u.check(err)
+}
+
+func (u *unitOfWork) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	rows, err := u.tx.Query(ctx, sql, args...)
+	if err != nil {
+		return rows, u.check(err)
+	}
+	return unitOfWorkRows{Rows: rows, work: u}, nil
+}
+
+func (u *unitOfWork) QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row {
+	return unitOfWorkRow{row: u.tx.QueryRow(ctx, sql, args...), work: u}
+}
+
+type unitOfWorkRows struct {
+	pgx.Rows
+	work *unitOfWork
+}
+
+func (r unitOfWorkRows) Err() error {
+	return r.work.check(r.Rows.Err())
+}
+
+type unitOfWorkRow struct {
+	row  pgx.Row
+	work *unitOfWork
+}
+
+func (r unitOfWorkRow) Scan(dest ...interface{}) error {
+	return r.work.check(r.row.Scan(dest...))
+}
+
+// isRetryableTxError reports serialization failures and deadlocks, after
+// which the whole transaction can succeed when run again.
+func isRetryableTxError(err error) bool {
+	var pg_err *pgconn.PgError
+	if !errors.As(err, &pg_err) {
+		return false
+	}
+	return pg_err.Code == "40001" || pg_err.Code == "40P01"
+}
+
+func unitOfWorkFromContext(ctx context.Context) (*unitOfWork, bool) {
+	work, ok := ctx.Value(txContextKey{}).(*unitOfWork)
+	return work, ok
+}
+
+// WithTx runs fn in a transaction. Repository functions called with the ctx
+// passed to fn use that transaction instead of a connection of their own, and
+// nested WithTx calls join it. fn is run again when the transaction fails on
+// a serialization failure or a deadlock, so it must not have side effects
+// outside of the database.
+func (r *PostgresRepository) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := unitOfWorkFromContext(ctx); ok {
+		return fn(ctx)
+	}
+	return retryTx(ctx, r.logger(ctx), func() (bool, error) {
+		return r.runTx(ctx, fn)
+	})
+}
+
+// retryTx calls run until it succeeds, fails with an error it does not
+// report as retryable or max_tx_attempts is reached.
+func retryTx(ctx context.Context, log *logrus.Entry, run func() (bool, error)) error {
+	for attempt := 1; ; attempt++ {
+		retryable, err := run()
+		if err == nil || !retryable || attempt == max_tx_attempts {
+			return err
+		}
+		if log != nil {
+			log.Warnf("Transaction conflicted with a concurrent one, retrying. Attempt %d of %d", attempt+1, max_tx_attempts)
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(tx_retry_delay * time.Duration(attempt)):
+		}
+	}
+}
+
+func (r *PostgresRepository) runTx(ctx context.Context, fn func(ctx context.Context) error) (bool, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		r.logger(ctx).Error("Error starting transaction:", err)
+		return false, repo_errors.OperationError{}
+	}
+	defer tx.Rollback(ctx)
+
+	work := &unitOfWork{tx: tx}
+	if err = fn(context.WithValue(ctx, txContextKey{}, work)); err != nil {
+		return work.retryable || isRetryableTxError(err), err
+	}
+	if err = tx.Commit(ctx); err != nil {
+		r.logger(ctx).Error("Error committing transaction:", err)
+		return isRetryableTxError(err), repo_errors.OperationError{}
+	}
+	return false, nil
+}
+
+// inTx runs fn in the transaction of an enclosing WithTx call or in a new
+// one, so audit events are stored together with the change they describe.
+func (r *PostgresRepository) inTx(ctx context.Context, fn func(tx querier) error) error {
+	return r.WithTx(ctx, func(ctx context.Context) error {
+		work, _ := unitOfWorkFromContext(ctx)
+		return fn(work)
+	})
+}
+
+// acquire returns the transaction of an enclosing WithTx call or a connection
+// from the pool. release must be called once the caller is done with it.
+func (r *PostgresRepository) acquire(ctx context.Context) (querier, func(), error) {
+	if work, ok := unitOfWorkFromContext(ctx); ok {
+		return work, func() {}, nil
+	}
+	conn, err := r.pool.Acquire(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	return conn, conn.Release, nil
+}
@@ -6,67 +6,87 @@ import (
 	"strings"
 	"task_tracker/src/entities"
 	"task_tracker/src/errors/repo_errors"
+	"time"
 
 	"github.com/jackc/pgconn"
-	"github.com/jackc/pgx"
+	"github.com/jackc/pgx/v4"
 
 	"errors"
-
-	"github.com/jackc/pgx/v4/pgxpool"
-	"github.com/sirupsen/logrus"
 )
 
-func CreateUser(
+func (r *PostgresRepository) CreateUser(
 	ctx context.Context,
-	pool *pgxpool.Pool,
-	log *logrus.Logger,
 	user entities.User,
 ) (int, error) {
-	conn, err := pool.Acquire(ctx)
-	if err != nil {
-		log.Error("Error with acquiring connection:", err)
-		return 0, repo_errors.OperationError{}
-	}
-	defer conn.Release()
-
-	var userID int
-	err_create := conn.QueryRow(
-		ctx,
-		`INSERT INTO users (passport_serie, passport_number, surname, name) 
-		VALUES ($1, $2, $3, $4) 
-		RETURNING user_id`,
-		user.PassportSerie, user.PassportNumber, user.Surname, user.Name,
-	).Scan(&userID)
-
-	if err_create != nil {
-		var pg_err *pgconn.PgError
-		if errors.As(err_create, &pg_err) {
-			if pg_err.Code == "23505" {
-				log.Errorf("error: %s. Detail: %s", pg_err.Error(), pg_err.Detail)
-				return 0, repo_errors.ObjectAlreadyExistsError{}
+	err := r.inTx(ctx, func(tx querier) error {
+		err := tx.QueryRow(
+			ctx,
+			`INSERT INTO users (passport_serie, passport_number, surname, name, patronymic, address) 
+			VALUES ($1, $2, $3, $4, $5, $6) 
+			RETURNING user_id, created_at, version`,
+			user.PassportSerie, user.PassportNumber, user.Surname, user.Name, user.Patronymic, user.Address,
+		).Scan(&user.Id, &user.CreatedAt, &user.Version)
+		if err != nil {
+			var pg_err *pgconn.PgError
+			if errors.As(err, &pg_err) && pg_err.Code == "23505" {
+				r.logger(ctx).Errorf("error: %s. Detail: %s", pg_err.Error(), pg_err.Detail)
+				return repo_errors.ObjectAlreadyExistsError{}
 			}
-		} else {
-			log.Error("Error creating user: ", err_create)
-			return 0, repo_errors.OperationError{}
+			r.logger(ctx).Error("Error creating user: ", err)
+			return repo_errors.OperationError{}
 		}
+
+		changes := auditChanges(nil, userAuditFields(user))
+		return r.insertAuditEvent(ctx, tx, newAuditEvent(ctx, entities.AuditActionCreate, entities.AuditEntityUser, user.Id, changes))
+	})
+	if err != nil {
+		return 0, err
 	}
-	return userID, err
+	return user.Id, nil
 }
 
-func UpdateUser(
+// lockUser reads an active user and locks the row until the end of tx.
+func (r *PostgresRepository) lockUser(
 	ctx context.Context,
-	pool *pgxpool.Pool,
-	log *logrus.Logger,
-	user entities.UserUpdateRepo,
+	tx querier,
 	user_id int,
 ) (entities.User, error) {
-	conn, err := pool.Acquire(ctx)
+	var user entities.User
+	err := tx.QueryRow(
+		ctx,
+		`SELECT user_id, passport_serie, passport_number, surname, name,
+			COALESCE(patronymic, ''), COALESCE(address, ''), created_at, version
+		FROM users
+		WHERE user_id=$1 AND deleted_at IS NULL
+		FOR UPDATE`,
+		user_id,
+	).Scan(
+		&user.Id,
+		&user.PassportSerie,
+		&user.PassportNumber,
+		&user.Surname,
+		&user.Name,
+		&user.Patronymic,
+		&user.Address,
+		&user.CreatedAt,
+		&user.Version,
+	)
 	if err != nil {
-		log.Error("Error with acquiring connection:", err)
+		if errors.Is(err, pgx.ErrNoRows) {
+			r.logger(ctx).Errorf("error: %s. Detail: %s=%d", err.Error(), "user_id", user_id)
+			return entities.User{}, repo_errors.ObjectNotFoundError{}
+		}
+		r.logger(ctx).Error("Error getting user: ", err)
 		return entities.User{}, repo_errors.OperationError{}
 	}
-	defer conn.Release()
+	return user, nil
+}
 
+func (r *PostgresRepository) UpdateUser(
+	ctx context.Context,
+	user entities.UserUpdateRepo,
+	user_id int,
+) (entities.User, error) {
 	set_clauses := []string{}
 	args := []interface{}{}
 	argID := 1
@@ -91,118 +111,445 @@ func UpdateUser(
 		args = append(args, *user.Name)
 		argID++
 	}
+	if user.Patronymic != nil {
+		set_clauses = append(set_clauses, fmt.Sprintf("patronymic=$%d", argID))
+		args = append(args, *user.Patronymic)
+		argID++
+	}
+	if user.Address != nil {
+		set_clauses = append(set_clauses, fmt.Sprintf("address=$%d", argID))
+		args = append(args, *user.Address)
+		argID++
+	}
 
 	if len(set_clauses) == 0 {
 		return entities.User{}, fmt.Errorf("No fields to update")
 	}
+	set_clauses = append(set_clauses, "version=version+1")
 
 	set_query := strings.Join(set_clauses, ", ")
 	query := fmt.Sprintf(
 		`UPDATE users 
 		SET %s 
-		WHERE user_id=$%d 
-		RETURNING user_id, passport_serie, passport_number, surname, name;`,
+		WHERE user_id=$%d AND deleted_at IS NULL
+		RETURNING user_id, passport_serie, passport_number, surname, name,
+			COALESCE(patronymic, ''), COALESCE(address, ''), created_at, version;`,
 		set_query,
 		argID,
 	)
 	args = append(args, user_id)
 
 	var updated_user entities.User
-	err = conn.QueryRow(ctx, query, args...).Scan(
-		&updated_user.Id,
-		&updated_user.PassportSerie,
-		&updated_user.PassportNumber,
-		&updated_user.Surname,
-		&updated_user.Name,
-	)
-
-	if err != nil {
-		var pg_err *pgconn.PgError
+	err := r.inTx(ctx, func(tx querier) error {
+		old_user, err := r.lockUser(ctx, tx, user_id)
+		if err != nil {
+			return err
+		}
 
-		if errors.As(err, &pg_err) {
-			if pg_err.Code == "23505" {
-				log.Errorf("error: %s. Detail: %s", pg_err.Error(), pg_err.Detail)
-				return entities.User{}, repo_errors.ObjectAlreadyExistsError{}
+		err = tx.QueryRow(ctx, query, args...).Scan(
+			&updated_user.Id,
+			&updated_user.PassportSerie,
+			&updated_user.PassportNumber,
+			&updated_user.Surname,
+			&updated_user.Name,
+			&updated_user.Patronymic,
+			&updated_user.Address,
+			&updated_user.CreatedAt,
+			&updated_user.Version,
+		)
+		if err != nil {
+			var pg_err *pgconn.PgError
+			if errors.As(err, &pg_err) && pg_err.Code == "23505" {
+				r.logger(ctx).Errorf("error: %s. Detail: %s", pg_err.Error(), pg_err.Detail)
+				return repo_errors.ObjectAlreadyExistsError{}
 			}
-		} else if err.Error() == pgx.ErrNoRows.Error() {
-			log.Errorf("error: %s. Detail: %s=%d", err.Error(), "user_id", user_id)
-			return entities.User{}, repo_errors.ObjectNotFoundError{}
-		} else {
-			log.Errorf("Error updating user: %s", err)
-			return entities.User{}, repo_errors.OperationError{}
+			r.logger(ctx).Errorf("Error updating user: %s", err)
+			return repo_errors.OperationError{}
 		}
-	}
 
-	if updated_user.Id == 0 {
-		return entities.User{}, repo_errors.ObjectNotFoundError{}
+		changes := auditChanges(userAuditFields(old_user), userAuditFields(updated_user))
+		return r.insertAuditEvent(ctx, tx, newAuditEvent(ctx, entities.AuditActionUpdate, entities.AuditEntityUser, user_id, changes))
+	})
+	if err != nil {
+		return entities.User{}, err
 	}
 	return updated_user, nil
 }
 
-func DeleteUser(
+func (r *PostgresRepository) DeleteUser(
+	ctx context.Context,
+	user_id int,
+) error {
+	return r.inTx(ctx, func(tx querier) error {
+		var deleted_at time.Time
+		err := tx.QueryRow(
+			ctx,
+			`UPDATE users 
+			SET deleted_at=current_timestamp, version=version+1
+			WHERE user_id=$1 AND deleted_at IS NULL
+			RETURNING deleted_at`,
+			user_id,
+		).Scan(&deleted_at)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				r.logger(ctx).Errorf("error: user not found. Detail: user_id=%d", user_id)
+				return repo_errors.ObjectNotFoundError{}
+			}
+			r.logger(ctx).Error("Error deleting user:", err)
+			return repo_errors.OperationError{}
+		}
+
+		// Tasks are kept for the time history but stop running.
+		rows, err := tx.Query(
+			ctx,
+			`WITH archived AS (
+				UPDATE tasks
+				SET end_time=current_timestamp, version=version+1
+				WHERE user_id=$1 AND end_time IS NULL
+				RETURNING task_id, user_id, project_id, task_name, start_time, end_time
+			), closed AS (
+				UPDATE task_intervals
+				SET end_time=current_timestamp
+				WHERE end_time IS NULL AND task_id IN (SELECT task_id FROM tasks WHERE user_id=$1)
+			)
+			SELECT task_id, user_id, project_id, task_name, start_time, end_time FROM archived ORDER BY task_id`,
+			user_id,
+		)
+		if err != nil {
+			r.logger(ctx).Error("Error archiving user tasks:", err)
+			return repo_errors.OperationError{}
+		}
+		archived_tasks := []entities.Task{}
+		for rows.Next() {
+			var task entities.Task
+			err = rows.Scan(&task.TaskId, &task.UserId, &task.ProjectId, &task.TaskName, &task.StartTime, &task.EndTime)
+			if err != nil {
+				break
+			}
+			archived_tasks = append(archived_tasks, task)
+		}
+		rows.Close()
+		if err == nil {
+			err = rows.Err()
+		}
+		if err != nil {
+			r.logger(ctx).Error("Error archiving user tasks:", err)
+			return repo_errors.OperationError{}
+		}
+		for _, task := range archived_tasks {
+			running_task := task
+			running_task.EndTime = nil
+			changes := auditChanges(taskAuditFields(running_task), taskAuditFields(task))
+			event := newAuditEvent(ctx, entities.AuditActionFinish, entities.AuditEntityTask, task.TaskId, changes)
+			if err = r.insertAuditEvent(ctx, tx, event); err != nil {
+				return err
+			}
+		}
+
+		_, err = tx.Exec(
+			ctx,
+			`UPDATE refresh_tokens
+			SET revoked_at=current_timestamp
+			WHERE user_id=$1 AND revoked_at IS NULL`,
+			user_id,
+		)
+		if err != nil {
+			r.logger(ctx).Error("Error revoking user refresh tokens:", err)
+			return repo_errors.OperationError{}
+		}
+
+		changes := auditChanges(nil, map[string]interface{}{"deletedAt": deleted_at})
+		return r.insertAuditEvent(ctx, tx, newAuditEvent(ctx, entities.AuditActionDelete, entities.AuditEntityUser, user_id, changes))
+	})
+}
+
+func (r *PostgresRepository) RestoreUser(
 	ctx context.Context,
-	pool *pgxpool.Pool,
-	log *logrus.Logger,
 	user_id int,
 ) error {
-	conn, err := pool.Acquire(ctx)
+	return r.inTx(ctx, func(tx querier) error {
+		var deleted_at *time.Time
+		err := tx.QueryRow(
+			ctx,
+			`SELECT deleted_at FROM users WHERE user_id=$1 FOR UPDATE`,
+			user_id,
+		).Scan(&deleted_at)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				r.logger(ctx).Errorf("error: user not found. Detail: user_id=%d", user_id)
+				return repo_errors.ObjectNotFoundError{}
+			}
+			r.logger(ctx).Error("Error restoring user:", err)
+			return repo_errors.OperationError{}
+		}
+		if deleted_at == nil {
+			return repo_errors.InvalidStateError{}
+		}
+
+		_, err = tx.Exec(
+			ctx,
+			`UPDATE users
+			SET deleted_at=NULL, version=version+1
+			WHERE user_id=$1`,
+			user_id,
+		)
+		if err != nil {
+			var pg_err *pgconn.PgError
+			if errors.As(err, &pg_err) && pg_err.Code == "23505" {
+				r.logger(ctx).Errorf("error: %s. Detail: %s", pg_err.Error(), pg_err.Detail)
+				return repo_errors.ObjectAlreadyExistsError{}
+			}
+			r.logger(ctx).Error("Error restoring user:", err)
+			return repo_errors.OperationError{}
+		}
+
+		changes := auditChanges(map[string]interface{}{"deletedAt": deleted_at}, nil)
+		return r.insertAuditEvent(ctx, tx, newAuditEvent(ctx, entities.AuditActionRestore, entities.AuditEntityUser, user_id, changes))
+	})
+}
+
+// PurgeDeletedUsers permanently removes users deleted before deleted_before
+// together with their tasks, and redacts their personal data from the audit
+// trail. Intervals and refresh tokens go with them via ON DELETE CASCADE.
+func (r *PostgresRepository) PurgeDeletedUsers(
+	ctx context.Context,
+	deleted_before time.Time,
+) (int, error) {
+	purged_ids := []int{}
+	err := r.inTx(ctx, func(tx querier) error {
+		_, err := tx.Exec(
+			ctx,
+			`DELETE FROM tasks
+			WHERE user_id IN (SELECT user_id FROM users WHERE deleted_at < $1)`,
+			deleted_before,
+		)
+		if err != nil {
+			r.logger(ctx).Error("Error purging tasks of deleted users:", err)
+			return repo_errors.OperationError{}
+		}
+
+		rows, err := tx.Query(
+			ctx,
+			`DELETE FROM users
+			WHERE deleted_at < $1
+			RETURNING user_id`,
+			deleted_before,
+		)
+		if err != nil {
+			r.logger(ctx).Error("Error purging deleted users:", err)
+			return repo_errors.OperationError{}
+		}
+		for rows.Next() {
+			var user_id int
+			if err = rows.Scan(&user_id); err != nil {
+				break
+			}
+			purged_ids = append(purged_ids, user_id)
+		}
+		rows.Close()
+		if err == nil {
+			err = rows.Err()
+		}
+		if err != nil {
+			r.logger(ctx).Error("Error purging deleted users:", err)
+			return repo_errors.OperationError{}
+		}
 
+		if err = r.redactUserAuditEvents(ctx, tx, purged_ids); err != nil {
+			return err
+		}
+		for _, user_id := range purged_ids {
+			event := newAuditEvent(ctx, entities.AuditActionPurge, entities.AuditEntityUser, user_id, map[string]entities.AuditChange{})
+			if err = r.insertAuditEvent(ctx, tx, event); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 	if err != nil {
-		log.Error("Error with acquiring connection:", err)
-		return repo_errors.OperationError{}
+		return 0, err
 	}
-	defer conn.Release()
+	return len(purged_ids), nil
+}
+
+func (r *PostgresRepository) GetUser(
+	ctx context.Context,
+	user_id int,
+) (entities.User, error) {
+	conn, release, err := r.acquire(ctx)
+	if err != nil {
+		r.logger(ctx).Error("Error with acquiring connection:", err)
+		return entities.User{}, repo_errors.OperationError{}
+	}
+	defer release()
 
-	_, err = conn.Exec(
+	var user entities.User
+	err = conn.QueryRow(
 		ctx,
-		`DELETE FROM users 
-		WHERE user_id=$1`,
+		`SELECT user_id, passport_serie, passport_number, surname, name,
+			COALESCE(patronymic, ''), COALESCE(address, ''), created_at, version
+		FROM users
+		WHERE user_id=$1 AND deleted_at IS NULL`,
 		user_id,
+	).Scan(
+		&user.Id,
+		&user.PassportSerie,
+		&user.PassportNumber,
+		&user.Surname,
+		&user.Name,
+		&user.Patronymic,
+		&user.Address,
+		&user.CreatedAt,
+		&user.Version,
 	)
 	if err != nil {
-		log.Error("Error deleting user:", err)
-		return repo_errors.OperationError{}
+		if errors.Is(err, pgx.ErrNoRows) {
+			r.logger(ctx).Errorf("error: %s. Detail: %s=%d", err.Error(), "user_id", user_id)
+			return entities.User{}, repo_errors.ObjectNotFoundError{}
+		}
+		r.logger(ctx).Error("Error getting user: ", err)
+		return entities.User{}, repo_errors.OperationError{}
 	}
+	return user, nil
+}
 
-	return nil
+// GetUserVersion returns the version of an active user. Inside a unit of work
+// the row stays locked until it ends, so the version can be checked before a
+// change without racing concurrent ones.
+func (r *PostgresRepository) GetUserVersion(
+	ctx context.Context,
+	user_id int,
+) (int, error) {
+	conn, release, err := r.acquire(ctx)
+	if err != nil {
+		r.logger(ctx).Error("Error with acquiring connection:", err)
+		return 0, repo_errors.OperationError{}
+	}
+	defer release()
+
+	var version int
+	err = conn.QueryRow(
+		ctx,
+		`SELECT version FROM users WHERE user_id=$1 AND deleted_at IS NULL FOR UPDATE`,
+		user_id,
+	).Scan(&version)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			r.logger(ctx).Errorf("error: %s. Detail: %s=%d", err.Error(), "user_id", user_id)
+			return 0, repo_errors.ObjectNotFoundError{}
+		}
+		r.logger(ctx).Error("Error getting user version: ", err)
+		return 0, repo_errors.OperationError{}
+	}
+	return version, nil
+}
+
+// Sort expressions of GET /users. Each one is indexed together with user_id,
+// see migration 0013. Names may be NULL, which keyset comparisons can not
+// handle, so they are sorted as empty strings.
+var user_sort_columns = map[string]string{
+	entities.UsersSortId:        "",
+	entities.UsersSortSurname:   `COALESCE(surname, '') COLLATE "C"`,
+	entities.UsersSortName:      `COALESCE(name, '') COLLATE "C"`,
+	entities.UsersSortCreatedAt: "created_at",
 }
 
-func GetUsers(
+var like_escaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+func (r *PostgresRepository) GetUsers(
 	ctx context.Context,
-	pool *pgxpool.Pool,
-	log *logrus.Logger,
-	offset int,
-	limit int,
-) ([]entities.User, int, error) {
-	conn, err := pool.Acquire(ctx)
-
-	var users []entities.User
+	query entities.UsersQuery,
+) ([]entities.User, error) {
+	sort_column, ok := user_sort_columns[query.Sort]
+	if !ok {
+		r.logger(ctx).Errorf("error: unknown users sort %q", query.Sort)
+		return nil, repo_errors.OperationError{}
+	}
+
+	where_clauses := []string{"deleted_at IS NULL"}
+	args := []interface{}{}
+	add_clause := func(clause string, values ...interface{}) {
+		placeholders := make([]interface{}, len(values))
+		for i, value := range values {
+			args = append(args, value)
+			placeholders[i] = len(args)
+		}
+		where_clauses = append(where_clauses, fmt.Sprintf(clause, placeholders...))
+	}
+	if query.Filter.SurnamePrefix != "" {
+		add_clause(user_sort_columns[entities.UsersSortSurname]+` LIKE $%d`, like_escaper.Replace(query.Filter.SurnamePrefix)+"%")
+	}
+	if query.Filter.NamePrefix != "" {
+		add_clause(user_sort_columns[entities.UsersSortName]+` LIKE $%d`, like_escaper.Replace(query.Filter.NamePrefix)+"%")
+	}
+	if query.Filter.PassportSerie != "" {
+		add_clause("passport_serie=$%d", query.Filter.PassportSerie)
+	}
+	if query.Filter.CreatedFrom != nil {
+		add_clause("created_at>=$%d::TIMESTAMPTZ", *query.Filter.CreatedFrom)
+	}
+	if query.Filter.CreatedTo != nil {
+		add_clause("created_at<=$%d::TIMESTAMPTZ", *query.Filter.CreatedTo)
+	}
+
+	direction, comparison := "ASC", ">"
+	if query.Descending {
+		direction, comparison = "DESC", "<"
+	}
+	order_by := "user_id " + direction
+	if sort_column != "" {
+		order_by = fmt.Sprintf("%s %s, %s", sort_column, direction, order_by)
+	}
+
+	if query.After != nil {
+		switch query.Sort {
+		case entities.UsersSortId:
+			add_clause("user_id"+comparison+"$%d", query.After.Id)
+		case entities.UsersSortCreatedAt:
+			created_at, err := time.Parse(time.RFC3339Nano, query.After.Key)
+			if err != nil {
+				r.logger(ctx).Errorf("error: invalid users position %q", query.After.Key)
+				return nil, repo_errors.OperationError{}
+			}
+			add_clause("(created_at, user_id)"+comparison+"($%d::TIMESTAMPTZ, $%d)", created_at, query.After.Id)
+		default:
+			add_clause("("+sort_column+", user_id)"+comparison+"($%d, $%d)", query.After.Key, query.After.Id)
+		}
+	}
+
+	limit_clause := ""
+	if query.Limit > 0 {
+		args = append(args, query.Limit)
+		limit_clause = fmt.Sprintf("LIMIT $%d", len(args))
+	}
+
+	conn, release, err := r.acquire(ctx)
 	if err != nil {
-		log.Error("Error with acquiring connection:", err)
-		return users, 0, repo_errors.OperationError{}
+		r.logger(ctx).Error("Error with acquiring connection:", err)
+		return nil, repo_errors.OperationError{}
 	}
-	defer conn.Release()
+	defer release()
 
 	rows, err := conn.Query(
 		ctx,
-		`SELECT user_id, passport_serie, passport_number, surname, name, total_count
-		FROM (
-    		SELECT user_id, passport_serie, passport_number, surname, name,
-           	COUNT(*) OVER () AS total_count
-    		FROM users
-    		ORDER BY user_id
-    		LIMIT $1
-    		OFFSET $2
-		) sub;`,
-		limit, offset,
+		fmt.Sprintf(
+			`SELECT user_id, passport_serie, passport_number, COALESCE(surname, ''), COALESCE(name, ''),
+				COALESCE(patronymic, ''), COALESCE(address, ''), created_at, version
+			FROM users
+			WHERE %s
+			ORDER BY %s
+			%s`,
+			strings.Join(where_clauses, " AND "), order_by, limit_clause,
+		),
+		args...,
 	)
 	if err != nil {
-		log.Error("Error getting users:", err)
-		return users, 0, repo_errors.OperationError{}
+		r.logger(ctx).Error("Error getting users:", err)
+		return nil, repo_errors.OperationError{}
 	}
 	defer rows.Close()
 
-	var users_count int
+	users := []entities.User{}
 	for rows.Next() {
 		var user entities.User
 		err = rows.Scan(
@@ -211,32 +558,49 @@ func GetUsers(
 			&user.PassportNumber,
 			&user.Surname,
 			&user.Name,
-			&users_count,
+			&user.Patronymic,
+			&user.Address,
+			&user.CreatedAt,
+			&user.Version,
 		)
 		if err != nil {
-			log.Error("Error scanning user:", err)
-			return users, 0, repo_errors.OperationError{}
+			r.logger(ctx).Error("Error scanning user:", err)
+			return nil, repo_errors.OperationError{}
 		}
 		users = append(users, user)
 	}
-
-	return users, users_count, err
+	if err := rows.Err(); err != nil {
+		r.logger(ctx).Error("Error getting users:", err)
+		return nil, repo_errors.OperationError{}
+	}
+	return users, nil
 }
 
-func GetUserActivity(
+func (r *PostgresRepository) GetUserActivity(
 	ctx context.Context,
-	pool *pgxpool.Pool,
-	log *logrus.Logger,
 	filters entities.UserActivityRequest,
 ) ([]entities.UserActivityTask, error) {
-	conn, err := pool.Acquire(ctx)
+	tasks := []entities.UserActivityTask{}
+	err := r.StreamUserActivity(ctx, filters, func(task entities.UserActivityTask) error {
+		tasks = append(tasks, task)
+		return nil
+	})
+	return tasks, err
+}
 
-	var tasks []entities.UserActivityTask
+// StreamUserActivity calls fn for every task of the report without loading
+// the whole report into memory. Errors returned by fn are passed through.
+func (r *PostgresRepository) StreamUserActivity(
+	ctx context.Context,
+	filters entities.UserActivityRequest,
+	fn func(task entities.UserActivityTask) error,
+) error {
+	conn, release, err := r.acquire(ctx)
 	if err != nil {
-		log.Error("Error with acquiring connection:", err)
-		return tasks, repo_errors.OperationError{}
+		r.logger(ctx).Error("Error with acquiring connection:", err)
+		return repo_errors.OperationError{}
 	}
-	defer conn.Release()
+	defer release()
 
 	where_clauses := []string{}
 	args := []interface{}{}
@@ -246,13 +610,13 @@ func GetUserActivity(
 
 	if filters.DateTo != nil && filters.DateFrom != nil {
 		where_clauses = append(where_clauses, "start_time BETWEEN $2::TIMESTAMPTZ AND $3::TIMESTAMPTZ")
-		args = append(args, *filters.DateFrom)
+		args = append(args, *filters.DateFrom, *filters.DateTo)
 	} else if filters.DateFrom != nil {
 		where_clauses = append(where_clauses, "start_time>=$2::TIMESTAMPTZ")
 		args = append(args, *filters.DateFrom)
 	} else if filters.DateTo != nil {
 		where_clauses = append(where_clauses, "start_time<=$2::TIMESTAMPTZ")
-		args = append(args, *filters.DateFrom)
+		args = append(args, *filters.DateTo)
 	}
 
 	where_query := strings.Join(where_clauses, " AND ")
@@ -260,15 +624,30 @@ func GetUserActivity(
 		`SELECT 
 			task_id,
 			task_name, 
-			CEIL(EXTRACT(EPOCH FROM age(COALESCE(end_time, current_timestamp), start_time)) / 3600) AS hours,
-    		CEIL((EXTRACT(EPOCH FROM age(COALESCE(end_time, current_timestamp), start_time)) / 60) %% 60) AS minutes,
+			project_id,
+			projects.project_name,
+			FLOOR(COALESCE(intervals.seconds, 0) / 3600)::INTEGER AS hours,
+			(FLOOR(COALESCE(intervals.seconds, 0) / 60)::INTEGER %% 60) AS minutes,
+			CASE 
+				WHEN end_time IS NOT NULL THEN true 
+				ELSE false 
+			END AS is_finished,
 			CASE 
-        		WHEN end_time IS NOT NULL THEN true 
-        		ELSE false 
-    		END AS is_finished
-        FROM tasks
+				WHEN end_time IS NULL AND NOT COALESCE(intervals.is_running, false) THEN true 
+				ELSE false 
+			END AS is_paused
+		FROM tasks
+		LEFT JOIN (
+			SELECT 
+				task_id,
+				SUM(EXTRACT(EPOCH FROM COALESCE(end_time, current_timestamp) - start_time)) AS seconds,
+				BOOL_OR(end_time IS NULL) AS is_running
+			FROM task_intervals
+			GROUP BY task_id
+		) intervals USING (task_id)
+		LEFT JOIN projects USING (project_id)
 		WHERE %s
-		ORDER BY 3 DESC;`,
+		ORDER BY COALESCE(intervals.seconds, 0) DESC;`,
 		where_query,
 	)
 
@@ -278,8 +657,8 @@ func GetUserActivity(
 		args...,
 	)
 	if err != nil {
-		log.Error("Error getting tasks:", err)
-		return tasks, repo_errors.OperationError{}
+		r.logger(ctx).Error("Error getting tasks:", err)
+		return repo_errors.OperationError{}
 	}
 	defer rows.Close()
 
@@ -288,15 +667,24 @@ func GetUserActivity(
 		err = rows.Scan(
 			&task.TaskID,
 			&task.TaskName,
+			&task.ProjectId,
+			&task.ProjectName,
 			&task.Hours,
 			&task.Minutes,
 			&task.IsFinished,
+			&task.IsPaused,
 		)
 		if err != nil {
-			log.Error("Error scanning task:", err)
-			return tasks, repo_errors.OperationError{}
+			r.logger(ctx).Error("Error scanning task:", err)
+			return repo_errors.OperationError{}
 		}
-		tasks = append(tasks, task)
+		if err := fn(task); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		r.logger(ctx).Error("Error getting tasks:", err)
+		return repo_errors.OperationError{}
 	}
-	return tasks, nil
+	return nil
 }
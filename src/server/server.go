@@ -0,0 +1,111 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"task_tracker/src/config"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+type shutdownHook struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+// Server is an http.Server that drains in-flight requests and then releases
+// registered resources when its context is cancelled.
+type Server struct {
+	http_server      *http.Server
+	shutdown_timeout time.Duration
+	hooks            []shutdownHook
+	log              *logrus.Logger
+}
+
+func New(server_config config.ServerConfig, handler http.Handler, log *logrus.Logger) *Server {
+	return &Server{
+		http_server: &http.Server{
+			Handler:      handler,
+			Addr:         server_config.Address(),
+			ReadTimeout:  server_config.ReadTimeout,
+			WriteTimeout: server_config.WriteTimeout,
+		},
+		shutdown_timeout: server_config.ShutdownTimeout,
+		log:              log,
+	}
+}
+
+// OnShutdown registers fn to run after the HTTP server has drained. Hooks run
+// in registration order, so background workers should be registered before
+// the resources they use, e.g. the database pool.
+func (s *Server) OnShutdown(name string, fn func(ctx context.Context) error) {
+	s.hooks = append(s.hooks, shutdownHook{name: name, fn: fn})
+}
+
+// Run listens on the configured address and serves until ctx is cancelled.
+func (s *Server) Run(ctx context.Context) error {
+	listener, err := net.Listen("tcp", s.http_server.Addr)
+	if err != nil {
+		s.runHooks()
+		return err
+	}
+	return s.Serve(ctx, listener)
+}
+
+// Serve serves on listener until ctx is cancelled, then stops accepting
+// connections, waits up to the shutdown timeout for in-flight requests and
+// runs the shutdown hooks. Hooks also run when serving fails, and share a
+// fresh shutdown timeout so a slow drain does not starve them.
+func (s *Server) Serve(ctx context.Context, listener net.Listener) error {
+	serve_errors := make(chan error, 1)
+	go func() {
+		serve_errors <- s.http_server.Serve(listener)
+	}()
+
+	s.log.Info("Server is listening on ", listener.Addr().String())
+
+	var err error
+	select {
+	case err = <-serve_errors:
+		s.log.Error("Server stopped unexpectedly: ", err)
+	case <-ctx.Done():
+		s.log.Info("Shutting down, draining in-flight requests")
+		err = s.shutdown()
+	}
+
+	if hooks_err := s.runHooks(); err == nil {
+		err = hooks_err
+	}
+	return err
+}
+
+func (s *Server) shutdown() error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.shutdown_timeout)
+	defer cancel()
+
+	if err := s.http_server.Shutdown(ctx); err != nil {
+		s.log.Error("Drain timed out, closing remaining connections: ", err)
+		s.http_server.Close()
+		return err
+	}
+	return nil
+}
+
+func (s *Server) runHooks() error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.shutdown_timeout)
+	defer cancel()
+
+	var errs []error
+	for _, hook := range s.hooks {
+		if err := hook.fn(ctx); err != nil {
+			s.log.Errorf("Error stopping %s: %s", hook.name, err)
+			errs = append(errs, err)
+			continue
+		}
+		s.log.Info("Stopped ", hook.name)
+	}
+	return errors.Join(errs...)
+}
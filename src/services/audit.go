@@ -0,0 +1,28 @@
+package services
+
+import (
+	"context"
+	"task_tracker/src/entities"
+	"task_tracker/src/errors/api_errors"
+	"task_tracker/src/repository"
+	"task_tracker/src/tracing"
+)
+
+func GetAuditEvents(
+	ctx context.Context,
+	repo repository.AuditRepository,
+	filter entities.AuditEventsFilter,
+) ([]entities.AuditEvent, int, error) {
+	ctx, span := tracing.Start(ctx, "services.GetAuditEvents")
+	defer span.End()
+
+	if _, err := requireRole(ctx, repo, entities.RoleAdmin); err != nil {
+		return []entities.AuditEvent{}, 0, err
+	}
+
+	events, events_count, err := repo.GetAuditEvents(ctx, filter)
+	if err != nil {
+		return []entities.AuditEvent{}, 0, &api_errors.InternalServerError{}
+	}
+	return events, events_count, nil
+}
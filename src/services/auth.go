@@ -0,0 +1,156 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"task_tracker/src/auth"
+	"task_tracker/src/entities"
+	"task_tracker/src/errors/api_errors"
+	"task_tracker/src/errors/repo_errors"
+	"task_tracker/src/repository"
+	"task_tracker/src/tracing"
+	"time"
+)
+
+func Login(
+	ctx context.Context,
+	repo repository.AuthRepository,
+	token_manager *auth.TokenManager,
+	request entities.LoginRequest,
+) (entities.TokenResponse, error) {
+	ctx, span := tracing.Start(ctx, "services.Login")
+	defer span.End()
+
+	passport_serie, passport_number, err := parsePassport(request.PassportNumber)
+	if err != nil {
+		return entities.TokenResponse{}, err
+	}
+
+	credentials, err := repo.GetUserCredentials(ctx, passport_serie, passport_number)
+	if err != nil {
+		if errors.Is(err, repo_errors.ObjectNotFoundError{}) {
+			return entities.TokenResponse{}, &api_errors.UnauthorizedError{Detail: "Invalid passport number or password"}
+		}
+		return entities.TokenResponse{}, &api_errors.InternalServerError{}
+	}
+	if credentials.PasswordHash == "" || !auth.CheckPassword(credentials.PasswordHash, request.Password) {
+		return entities.TokenResponse{}, &api_errors.UnauthorizedError{Detail: "Invalid passport number or password"}
+	}
+
+	return issueTokens(ctx, repo, token_manager, credentials.UserId)
+}
+
+func RefreshTokens(
+	ctx context.Context,
+	repo repository.AuthRepository,
+	token_manager *auth.TokenManager,
+	refresh_token string,
+) (entities.TokenResponse, error) {
+	ctx, span := tracing.Start(ctx, "services.RefreshTokens")
+	defer span.End()
+
+	// Refresh tokens are single use: the presented one is revoked and a new
+	// pair is issued. Only the request that revokes it gets the new pair.
+	var tokens entities.TokenResponse
+	err := repo.WithTx(ctx, func(ctx context.Context) error {
+		token, err := getActiveRefreshToken(ctx, repo, token_manager, refresh_token)
+		if err != nil {
+			return err
+		}
+		if err := revokeRefreshToken(ctx, repo, token.TokenId); err != nil {
+			return err
+		}
+		issued_tokens, err := issueTokens(ctx, repo, token_manager, token.UserId)
+		tokens = issued_tokens
+		return err
+	})
+	if errors.Is(err, repo_errors.OperationError{}) {
+		return entities.TokenResponse{}, &api_errors.InternalServerError{}
+	} else if err != nil {
+		return entities.TokenResponse{}, err
+	}
+	return tokens, nil
+}
+
+func Logout(
+	ctx context.Context,
+	repo repository.AuthRepository,
+	token_manager *auth.TokenManager,
+	refresh_token string,
+) error {
+	ctx, span := tracing.Start(ctx, "services.Logout")
+	defer span.End()
+
+	token, err := getActiveRefreshToken(ctx, repo, token_manager, refresh_token)
+	if err != nil {
+		return err
+	}
+	return revokeRefreshToken(ctx, repo, token.TokenId)
+}
+
+func revokeRefreshToken(ctx context.Context, repo repository.AuthRepository, token_id string) error {
+	revoked, err := repo.RevokeRefreshToken(ctx, token_id)
+	if err != nil {
+		return &api_errors.InternalServerError{}
+	}
+	if !revoked {
+		return &api_errors.UnauthorizedError{Detail: "Refresh token is revoked or expired"}
+	}
+	return nil
+}
+
+func getActiveRefreshToken(
+	ctx context.Context,
+	repo repository.AuthRepository,
+	token_manager *auth.TokenManager,
+	refresh_token string,
+) (entities.RefreshToken, error) {
+	parsed_token, err := token_manager.ParseRefreshToken(refresh_token)
+	if err != nil {
+		return entities.RefreshToken{}, &api_errors.UnauthorizedError{Detail: "Invalid refresh token"}
+	}
+
+	token, err := repo.GetRefreshToken(ctx, parsed_token.TokenId)
+	if err != nil {
+		if errors.Is(err, repo_errors.ObjectNotFoundError{}) {
+			return entities.RefreshToken{}, &api_errors.UnauthorizedError{Detail: "Invalid refresh token"}
+		}
+		return entities.RefreshToken{}, &api_errors.InternalServerError{}
+	}
+	if token.RevokedAt != nil || token.ExpiresAt.Before(time.Now()) {
+		return entities.RefreshToken{}, &api_errors.UnauthorizedError{Detail: "Refresh token is revoked or expired"}
+	}
+	return token, nil
+}
+
+func issueTokens(
+	ctx context.Context,
+	repo repository.AuthRepository,
+	token_manager *auth.TokenManager,
+	user_id int,
+) (entities.TokenResponse, error) {
+	access_token, err := token_manager.NewAccessToken(user_id)
+	if err != nil {
+		return entities.TokenResponse{}, &api_errors.InternalServerError{}
+	}
+	refresh_token, err := token_manager.NewRefreshToken(user_id)
+	if err != nil {
+		return entities.TokenResponse{}, &api_errors.InternalServerError{}
+	}
+
+	err = repo.SaveRefreshToken(ctx, entities.RefreshToken{
+		TokenId:   refresh_token.TokenId,
+		UserId:    user_id,
+		ExpiresAt: refresh_token.ExpiresAt,
+	})
+	if err != nil {
+		return entities.TokenResponse{}, &api_errors.InternalServerError{}
+	}
+
+	return entities.TokenResponse{
+		AccessToken:  access_token,
+		RefreshToken: refresh_token.Token,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(token_manager.AccessTokenTTL().Seconds()),
+	}, nil
+}
@@ -0,0 +1,107 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"task_tracker/src/auth"
+	"task_tracker/src/entities"
+	"task_tracker/src/errors/api_errors"
+	"task_tracker/src/errors/repo_errors"
+	"task_tracker/src/repository"
+)
+
+type access_level int
+
+const (
+	read_access access_level = iota
+	write_access
+)
+
+func getCaller(ctx context.Context, repo repository.AccessRepository) (entities.UserAccess, error) {
+	user_id, ok := auth.UserIdFromContext(ctx)
+	if !ok {
+		return entities.UserAccess{}, &api_errors.UnauthorizedError{Detail: "Authentication required"}
+	}
+
+	caller, err := repo.GetUserAccess(ctx, user_id)
+	if err != nil {
+		if errors.Is(err, repo_errors.ObjectNotFoundError{}) {
+			return entities.UserAccess{}, &api_errors.UnauthorizedError{Detail: "User no longer exists"}
+		}
+		return entities.UserAccess{}, &api_errors.InternalServerError{}
+	}
+	return caller, nil
+}
+
+func requireRole(ctx context.Context, repo repository.AccessRepository, roles ...string) (entities.UserAccess, error) {
+	caller, err := getCaller(ctx, repo)
+	if err != nil {
+		return entities.UserAccess{}, err
+	}
+	for _, role := range roles {
+		if caller.Role == role {
+			return caller, nil
+		}
+	}
+	return entities.UserAccess{}, &api_errors.ForbiddenError{Detail: "Not enough permissions"}
+}
+
+// authorizeUserAccess checks that the caller may access data owned by
+// owner_id. Admins may access anything, every user may access their own data
+// and managers may read the data of their reports.
+func authorizeUserAccess(
+	ctx context.Context,
+	repo repository.AccessRepository,
+	owner_id int,
+	level access_level,
+) (entities.UserAccess, error) {
+	caller, err := getCaller(ctx, repo)
+	if err != nil {
+		return entities.UserAccess{}, err
+	}
+	if caller.Role == entities.RoleAdmin || caller.UserId == owner_id {
+		return caller, nil
+	}
+
+	if level == read_access && caller.Role == entities.RoleManager {
+		owner, err := repo.GetUserAccess(ctx, owner_id)
+		if err != nil && !errors.Is(err, repo_errors.ObjectNotFoundError{}) {
+			return entities.UserAccess{}, &api_errors.InternalServerError{}
+		}
+		if err == nil && owner.ManagerId != nil && *owner.ManagerId == caller.UserId {
+			return caller, nil
+		}
+	}
+	return entities.UserAccess{}, &api_errors.ForbiddenError{Detail: "Not enough permissions"}
+}
+
+// authorizeTaskAccess answers tasks the caller may not read like missing
+// ones, so task ids of other teams can not be discovered. Tasks the caller
+// may read but not change are forbidden.
+func authorizeTaskAccess(
+	ctx context.Context,
+	repo repository.TaskRepository,
+	task_id int,
+	level access_level,
+) (entities.Task, error) {
+	task, err := getTask(ctx, repo, task_id)
+	if err != nil {
+		return entities.Task{}, err
+	}
+	if _, err := authorizeUserAccess(ctx, repo, task.UserId, read_access); err != nil {
+		var forbidden *api_errors.ForbiddenError
+		if errors.As(err, &forbidden) {
+			return entities.Task{}, &api_errors.NotFoundError{
+				Detail: fmt.Sprintf("Task with id=%d does not exist", task_id),
+			}
+		}
+		return entities.Task{}, err
+	}
+	if level != read_access {
+		if _, err := authorizeUserAccess(ctx, repo, task.UserId, level); err != nil {
+			return entities.Task{}, err
+		}
+	}
+	return task, nil
+}
@@ -0,0 +1,84 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"task_tracker/src/entities"
+	"task_tracker/src/repository"
+	"time"
+)
+
+const health_check_timeout = 2 * time.Second
+
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+type healthCheck struct {
+	name     string
+	critical bool
+	check    func(ctx context.Context) error
+}
+
+// CheckReadiness runs the dependency checks concurrently, each bounded by its
+// own timeout. The people-info service is checked only when configured and is
+// not critical since users are created without enrichment when it is down.
+func CheckReadiness(
+	ctx context.Context,
+	repo repository.HealthRepository,
+	people_info_pinger Pinger,
+) entities.HealthReport {
+	checks := []healthCheck{
+		{name: "postgres", critical: true, check: repo.Ping},
+		{name: "migrations", critical: true, check: repo.CheckSchemaVersion},
+	}
+	if people_info_pinger != nil {
+		checks = append(checks, healthCheck{name: "people_info", check: people_info_pinger.Ping})
+	}
+
+	report := entities.HealthReport{
+		Status: entities.HealthStatusOk,
+		Checks: make([]entities.HealthCheckResult, len(checks)),
+	}
+
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func(i int, check healthCheck) {
+			defer wg.Done()
+			report.Checks[i] = runHealthCheck(ctx, check)
+		}(i, check)
+	}
+	wg.Wait()
+
+	for _, result := range report.Checks {
+		if result.Status == entities.HealthStatusOk {
+			continue
+		}
+		if result.Critical {
+			report.Status = entities.HealthStatusFailing
+		} else if report.Status == entities.HealthStatusOk {
+			report.Status = entities.HealthStatusDegraded
+		}
+	}
+	return report
+}
+
+func runHealthCheck(ctx context.Context, check healthCheck) entities.HealthCheckResult {
+	ctx, cancel := context.WithTimeout(ctx, health_check_timeout)
+	defer cancel()
+
+	start_time := time.Now()
+	err := check.check(ctx)
+	result := entities.HealthCheckResult{
+		Name:      check.name,
+		Status:    entities.HealthStatusOk,
+		Critical:  check.critical,
+		LatencyMs: float64(time.Since(start_time).Microseconds()) / 1000,
+	}
+	if err != nil {
+		result.Status = entities.HealthStatusFailing
+		result.Error = err.Error()
+	}
+	return result
+}
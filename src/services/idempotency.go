@@ -0,0 +1,64 @@
+package services
+
+import (
+	"context"
+	"task_tracker/src/entities"
+	"task_tracker/src/errors/api_errors"
+	"task_tracker/src/repository"
+	"task_tracker/src/tracing"
+	"time"
+)
+
+// BeginIdempotentRequest reserves key for the user. It returns the stored
+// response when a request with the key already completed and nil when the
+// caller reserved the key and has to run the request.
+func BeginIdempotentRequest(
+	ctx context.Context,
+	repo repository.IdempotencyRepository,
+	user_id int,
+	key string,
+	fingerprint string,
+	ttl time.Duration,
+) (*entities.IdempotencyRecord, error) {
+	ctx, span := tracing.Start(ctx, "services.BeginIdempotentRequest")
+	defer span.End()
+
+	stored, reserved, err := repo.ReserveIdempotencyKey(ctx, entities.IdempotencyRecord{
+		UserId:      user_id,
+		Key:         key,
+		Fingerprint: fingerprint,
+		ExpiresAt:   time.Now().Add(ttl),
+	})
+	if err != nil {
+		return nil, api_errors.FromRepoError(err, "Request with this Idempotency-Key is being processed")
+	}
+	if reserved {
+		return nil, nil
+	}
+	if stored.Fingerprint != fingerprint {
+		return nil, &api_errors.UnprocessableEntityError{
+			Detail: "Idempotency-Key was already used with a different request",
+		}
+	}
+	if stored.StatusCode == 0 {
+		return nil, &api_errors.ConflictError{Detail: "Request with this Idempotency-Key is being processed"}
+	}
+	return &stored, nil
+}
+
+// FinishIdempotentRequest stores the response of a reserved key. Server
+// errors and requests that did not complete are not stored, the key is
+// released so the client can retry them.
+func FinishIdempotentRequest(
+	ctx context.Context,
+	repo repository.IdempotencyRepository,
+	record entities.IdempotencyRecord,
+) error {
+	ctx, span := tracing.Start(ctx, "services.FinishIdempotentRequest")
+	defer span.End()
+
+	if record.StatusCode == 0 || record.StatusCode >= 500 {
+		return repo.ReleaseIdempotencyKey(ctx, record.UserId, record.Key)
+	}
+	return repo.SaveIdempotentResponse(ctx, record)
+}
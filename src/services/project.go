@@ -0,0 +1,121 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"task_tracker/src/entities"
+	"task_tracker/src/errors/api_errors"
+	"task_tracker/src/errors/repo_errors"
+	"task_tracker/src/repository"
+	"task_tracker/src/tracing"
+)
+
+func CreateProject(
+	ctx context.Context,
+	repo repository.ProjectRepository,
+	project entities.CreateProjectRequest,
+) (entities.Project, error) {
+	ctx, span := tracing.Start(ctx, "services.CreateProject")
+	defer span.End()
+
+	if _, err := requireRole(ctx, repo, entities.RoleAdmin, entities.RoleManager); err != nil {
+		return entities.Project{}, err
+	}
+
+	created_project, err := repo.CreateProject(ctx, project)
+	if err != nil {
+		if errors.Is(err, repo_errors.ObjectAlreadyExistsError{}) {
+			return entities.Project{}, &api_errors.ConflictError{
+				Detail: fmt.Sprintf("Project with name %q already exists", project.ProjectName),
+			}
+		}
+		return entities.Project{}, &api_errors.InternalServerError{}
+	}
+	return created_project, nil
+}
+
+func GetProject(
+	ctx context.Context,
+	repo repository.ProjectRepository,
+	project_id int,
+) (entities.Project, error) {
+	ctx, span := tracing.Start(ctx, "services.GetProject")
+	defer span.End()
+
+	if _, err := getCaller(ctx, repo); err != nil {
+		return entities.Project{}, err
+	}
+
+	project, err := repo.GetProject(ctx, project_id)
+	if err != nil {
+		return entities.Project{}, api_errors.FromRepoError(err, fmt.Sprintf("Project with id=%d does not exist", project_id))
+	}
+	return project, nil
+}
+
+func GetProjects(
+	ctx context.Context,
+	repo repository.ProjectRepository,
+) ([]entities.Project, error) {
+	ctx, span := tracing.Start(ctx, "services.GetProjects")
+	defer span.End()
+
+	if _, err := getCaller(ctx, repo); err != nil {
+		return []entities.Project{}, err
+	}
+
+	projects, err := repo.GetProjects(ctx)
+	if err != nil {
+		return []entities.Project{}, &api_errors.InternalServerError{}
+	}
+	return projects, nil
+}
+
+func UpdateProject(
+	ctx context.Context,
+	repo repository.ProjectRepository,
+	project entities.UpdateProjectRequest,
+	project_id int,
+) (entities.Project, error) {
+	ctx, span := tracing.Start(ctx, "services.UpdateProject")
+	defer span.End()
+
+	if _, err := requireRole(ctx, repo, entities.RoleAdmin, entities.RoleManager); err != nil {
+		return entities.Project{}, err
+	}
+
+	updated_project, err := repo.UpdateProject(ctx, project, project_id)
+	if err != nil {
+		if errors.Is(err, repo_errors.ObjectNotFoundError{}) {
+			return entities.Project{}, &api_errors.NotFoundError{
+				Detail: fmt.Sprintf("Project with id=%d does not exist", project_id),
+			}
+		} else if errors.Is(err, repo_errors.ObjectAlreadyExistsError{}) {
+			return entities.Project{}, &api_errors.ConflictError{
+				Detail: fmt.Sprintf("Project with name %q already exists", *project.ProjectName),
+			}
+		}
+		return entities.Project{}, &api_errors.InternalServerError{}
+	}
+	return updated_project, nil
+}
+
+func DeleteProject(
+	ctx context.Context,
+	repo repository.ProjectRepository,
+	project_id int,
+) error {
+	ctx, span := tracing.Start(ctx, "services.DeleteProject")
+	defer span.End()
+
+	if _, err := requireRole(ctx, repo, entities.RoleAdmin, entities.RoleManager); err != nil {
+		return err
+	}
+
+	err := repo.DeleteProject(ctx, project_id)
+	if err != nil {
+		return api_errors.FromRepoError(err, fmt.Sprintf("Project with id=%d does not exist", project_id))
+	}
+	return nil
+}
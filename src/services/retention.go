@@ -0,0 +1,35 @@
+package services
+
+import (
+	"context"
+	"task_tracker/src/repository"
+	"task_tracker/src/tracing"
+	"time"
+)
+
+// PurgeDeletedUsers permanently removes users that were soft deleted more
+// than retention_days before now, together with their time history.
+func PurgeDeletedUsers(
+	ctx context.Context,
+	repo repository.UserRepository,
+	retention_days int,
+	now time.Time,
+) (int, error) {
+	ctx, span := tracing.Start(ctx, "services.PurgeDeletedUsers")
+	defer span.End()
+
+	return repo.PurgeDeletedUsers(ctx, now.AddDate(0, 0, -retention_days))
+}
+
+// PurgeExpiredIdempotencyKeys removes responses stored for Idempotency-Key
+// headers that expired before now.
+func PurgeExpiredIdempotencyKeys(
+	ctx context.Context,
+	repo repository.IdempotencyRepository,
+	now time.Time,
+) (int, error) {
+	ctx, span := tracing.Start(ctx, "services.PurgeExpiredIdempotencyKeys")
+	defer span.End()
+
+	return repo.PurgeExpiredIdempotencyKeys(ctx, now)
+}
@@ -5,25 +5,46 @@ import (
 	"errors"
 	"fmt"
 
+	"task_tracker/src/auth"
 	"task_tracker/src/entities"
 	"task_tracker/src/errors/api_errors"
 	"task_tracker/src/errors/repo_errors"
+	"task_tracker/src/metrics"
 	"task_tracker/src/repository"
-
-	"github.com/jackc/pgx/v4/pgxpool"
-
-	"github.com/sirupsen/logrus"
+	"task_tracker/src/tracing"
 )
 
 func CreateTask(
 	ctx context.Context,
-	pool *pgxpool.Pool,
-	log *logrus.Logger,
+	repo repository.TaskRepository,
 	task entities.CreateTaskRequest,
 ) (*entities.CreateTaskResponse, error) {
+	ctx, span := tracing.Start(ctx, "services.CreateTask")
+	defer span.End()
 
-	created_task, err := repository.CreateTask(
-		ctx, pool, log, task,
+	if task.UserId == 0 {
+		user_id, ok := auth.UserIdFromContext(ctx)
+		if !ok {
+			return nil, &api_errors.BadRequestError{Detail: "Field userId is required"}
+		}
+		task.UserId = user_id
+	}
+	if _, err := authorizeUserAccess(ctx, repo, task.UserId, write_access); err != nil {
+		return nil, err
+	}
+	if task.ProjectId != nil {
+		_, err := repo.GetProject(ctx, *task.ProjectId)
+		if errors.Is(err, repo_errors.ObjectNotFoundError{}) {
+			return nil, &api_errors.BadRequestError{
+				Detail: fmt.Sprintf("Project with id=%d does not exist", *task.ProjectId),
+			}
+		} else if err != nil {
+			return nil, &api_errors.InternalServerError{}
+		}
+	}
+
+	created_task, err := repo.CreateTask(
+		ctx, task,
 	)
 	if err != nil {
 		if errors.Is(err, repo_errors.ObjectNotFoundError{}) {
@@ -33,20 +54,240 @@ func CreateTask(
 		}
 		return nil, &api_errors.InternalServerError{}
 	}
+	metrics.TasksCreated.Inc()
 	return created_task, nil
 }
 
 func FinishTask(
 	ctx context.Context,
-	pool *pgxpool.Pool,
-	log *logrus.Logger,
+	repo repository.TaskRepository,
 	task_id int,
-) error {
-	err := repository.FinishTask(
-		ctx, pool, log, task_id,
+	if_match entities.VersionPrecondition,
+) (entities.Task, error) {
+	ctx, span := tracing.Start(ctx, "services.FinishTask")
+	defer span.End()
+
+	if _, err := authorizeTaskAccess(ctx, repo, task_id, write_access); err != nil {
+		return entities.Task{}, err
+	}
+
+	err := repo.WithTx(ctx, func(ctx context.Context) error {
+		if err := checkTaskVersion(ctx, repo, task_id, if_match); err != nil {
+			return err
+		}
+		return repo.FinishTask(ctx, task_id)
+	})
+	if err != nil {
+		if problem, ok := asProblem(err); ok {
+			return entities.Task{}, problem
+		} else if errors.Is(err, repo_errors.ObjectNotFoundError{}) {
+			return entities.Task{}, &api_errors.NotFoundError{
+				Detail: fmt.Sprintf("Task with id=%d does not exist", task_id),
+			}
+		} else if errors.Is(err, repo_errors.InvalidStateError{}) {
+			return entities.Task{}, &api_errors.ConflictError{
+				Detail: fmt.Sprintf("Task with id=%d is already finished", task_id),
+			}
+		}
+		return entities.Task{}, &api_errors.InternalServerError{}
+	}
+	metrics.TasksFinished.Inc()
+	return getTask(ctx, repo, task_id)
+}
+
+func PauseTask(
+	ctx context.Context,
+	repo repository.TaskRepository,
+	task_id int,
+) (entities.Task, error) {
+	ctx, span := tracing.Start(ctx, "services.PauseTask")
+	defer span.End()
+
+	if _, err := authorizeTaskAccess(ctx, repo, task_id, write_access); err != nil {
+		return entities.Task{}, err
+	}
+
+	err := repo.PauseTask(
+		ctx, task_id,
 	)
 	if err != nil {
-		return &api_errors.InternalServerError{}
+		if errors.Is(err, repo_errors.ObjectNotFoundError{}) {
+			return entities.Task{}, &api_errors.NotFoundError{
+				Detail: fmt.Sprintf("Task with id=%d does not exist", task_id),
+			}
+		} else if errors.Is(err, repo_errors.InvalidStateError{}) {
+			return entities.Task{}, &api_errors.ConflictError{
+				Detail: fmt.Sprintf("Task with id=%d is not running", task_id),
+			}
+		}
+		return entities.Task{}, &api_errors.InternalServerError{}
+	}
+	return getTask(ctx, repo, task_id)
+}
+
+func ResumeTask(
+	ctx context.Context,
+	repo repository.TaskRepository,
+	task_id int,
+) (entities.Task, error) {
+	ctx, span := tracing.Start(ctx, "services.ResumeTask")
+	defer span.End()
+
+	if _, err := authorizeTaskAccess(ctx, repo, task_id, write_access); err != nil {
+		return entities.Task{}, err
+	}
+
+	err := repo.ResumeTask(
+		ctx, task_id,
+	)
+	if err != nil {
+		if errors.Is(err, repo_errors.ObjectNotFoundError{}) {
+			return entities.Task{}, &api_errors.NotFoundError{
+				Detail: fmt.Sprintf("Task with id=%d does not exist", task_id),
+			}
+		} else if errors.Is(err, repo_errors.InvalidStateError{}) {
+			return entities.Task{}, &api_errors.ConflictError{
+				Detail: fmt.Sprintf("Task with id=%d is already running or finished", task_id),
+			}
+		}
+		return entities.Task{}, &api_errors.InternalServerError{}
+	}
+	return getTask(ctx, repo, task_id)
+}
+
+func GetTask(
+	ctx context.Context,
+	repo repository.TaskRepository,
+	task_id int,
+) (entities.Task, error) {
+	ctx, span := tracing.Start(ctx, "services.GetTask")
+	defer span.End()
+
+	return authorizeTaskAccess(ctx, repo, task_id, read_access)
+}
+
+func getTask(
+	ctx context.Context,
+	repo repository.TaskRepository,
+	task_id int,
+) (entities.Task, error) {
+	task, err := repo.GetTask(ctx, task_id)
+	if err != nil {
+		return entities.Task{}, api_errors.FromRepoError(err, fmt.Sprintf("Task with id=%d does not exist", task_id))
+	}
+	return task, nil
+}
+
+func GetTasks(
+	ctx context.Context,
+	repo repository.TaskRepository,
+	filters entities.TasksFilter,
+) ([]entities.Task, error) {
+	ctx, span := tracing.Start(ctx, "services.GetTasks")
+	defer span.End()
+
+	caller, err := getCaller(ctx, repo)
+	if err != nil {
+		return []entities.Task{}, err
+	}
+	if filters.UserId == nil && caller.Role != entities.RoleAdmin {
+		filters.UserId = &caller.UserId
+	}
+	if filters.UserId != nil {
+		if _, err := authorizeUserAccess(ctx, repo, *filters.UserId, read_access); err != nil {
+			return []entities.Task{}, err
+		}
+	}
+
+	tasks, err := repo.GetTasks(ctx, filters)
+	if err != nil {
+		return []entities.Task{}, &api_errors.InternalServerError{}
+	}
+	return tasks, nil
+}
+
+func UpdateTask(
+	ctx context.Context,
+	repo repository.TaskRepository,
+	task entities.UpdateTaskRequest,
+	task_id int,
+	if_match entities.VersionPrecondition,
+) (entities.Task, error) {
+	ctx, span := tracing.Start(ctx, "services.UpdateTask")
+	defer span.End()
+
+	if _, err := authorizeTaskAccess(ctx, repo, task_id, write_access); err != nil {
+		return entities.Task{}, err
+	}
+
+	var updated_task entities.Task
+	err := repo.WithTx(ctx, func(ctx context.Context) error {
+		if err := checkTaskVersion(ctx, repo, task_id, if_match); err != nil {
+			return err
+		}
+		var err error
+		updated_task, err = repo.UpdateTask(ctx, task, task_id)
+		return err
+	})
+	if problem, ok := asProblem(err); ok {
+		return entities.Task{}, problem
+	} else if err != nil {
+		return entities.Task{}, api_errors.FromRepoError(err, fmt.Sprintf("Task with id=%d does not exist", task_id))
+	}
+	return updated_task, nil
+}
+
+func DeleteTask(
+	ctx context.Context,
+	repo repository.TaskRepository,
+	task_id int,
+	if_match entities.VersionPrecondition,
+) error {
+	ctx, span := tracing.Start(ctx, "services.DeleteTask")
+	defer span.End()
+
+	if _, err := authorizeTaskAccess(ctx, repo, task_id, write_access); err != nil {
+		return err
+	}
+
+	err := repo.WithTx(ctx, func(ctx context.Context) error {
+		if err := checkTaskVersion(ctx, repo, task_id, if_match); err != nil {
+			return err
+		}
+		return repo.DeleteTask(ctx, task_id)
+	})
+	if problem, ok := asProblem(err); ok {
+		return problem
+	} else if err != nil {
+		return api_errors.FromRepoError(err, fmt.Sprintf("Task with id=%d does not exist", task_id))
 	}
 	return nil
 }
+
+func ReopenTask(
+	ctx context.Context,
+	repo repository.TaskRepository,
+	task_id int,
+) (entities.Task, error) {
+	ctx, span := tracing.Start(ctx, "services.ReopenTask")
+	defer span.End()
+
+	if _, err := authorizeTaskAccess(ctx, repo, task_id, write_access); err != nil {
+		return entities.Task{}, err
+	}
+
+	err := repo.ReopenTask(ctx, task_id)
+	if err != nil {
+		if errors.Is(err, repo_errors.ObjectNotFoundError{}) {
+			return entities.Task{}, &api_errors.NotFoundError{
+				Detail: fmt.Sprintf("Task with id=%d does not exist", task_id),
+			}
+		} else if errors.Is(err, repo_errors.InvalidStateError{}) {
+			return entities.Task{}, &api_errors.ConflictError{
+				Detail: fmt.Sprintf("Task with id=%d is not finished", task_id),
+			}
+		}
+		return entities.Task{}, &api_errors.InternalServerError{}
+	}
+	return getTask(ctx, repo, task_id)
+}
@@ -0,0 +1,379 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"task_tracker/src/entities"
+	"task_tracker/src/errors/api_errors"
+	"task_tracker/src/errors/repo_errors"
+	"task_tracker/src/repository"
+	"task_tracker/src/tracing"
+	"time"
+)
+
+const timesheet_day_layout = "2006-01-02"
+
+// ParseTimesheetPeriod builds the period for an ISO week ("2024-W28") or a
+// month ("2024-07"). Days are counted in the given IANA timezone, UTC by
+// default.
+func ParseTimesheetPeriod(week string, month string, timezone string) (entities.TimesheetPeriod, error) {
+	if timezone == "" {
+		timezone = "UTC"
+	}
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return entities.TimesheetPeriod{}, &api_errors.BadRequestError{
+			Detail: fmt.Sprintf("Unknown timezone %q", timezone),
+		}
+	}
+
+	if (week == "") == (month == "") {
+		return entities.TimesheetPeriod{}, &api_errors.BadRequestError{
+			Detail: "Exactly one of parametrs week and month is required",
+		}
+	}
+
+	if month != "" {
+		from, err := time.ParseInLocation("2006-01", month, location)
+		if err != nil {
+			return entities.TimesheetPeriod{}, &api_errors.BadRequestError{
+				Detail: "Parametr month must be a month. Format YYYY-MM",
+			}
+		}
+		return entities.TimesheetPeriod{From: from, To: from.AddDate(0, 1, 0), Location: location}, nil
+	}
+
+	var year, week_number int
+	_, err = fmt.Sscanf(week, "%4d-W%2d", &year, &week_number)
+	if err != nil || len(week) != len("2006-W01") {
+		return entities.TimesheetPeriod{}, &api_errors.BadRequestError{
+			Detail: "Parametr week must be an ISO week. Format YYYY-Www",
+		}
+	}
+
+	// The first ISO week is the one containing January 4th.
+	january_4 := time.Date(year, time.January, 4, 0, 0, 0, 0, location)
+	days_since_monday := (int(january_4.Weekday()) + 6) % 7
+	from := january_4.AddDate(0, 0, -days_since_monday+(week_number-1)*7)
+
+	if iso_year, iso_week := from.ISOWeek(); week_number < 1 || iso_year != year || iso_week != week_number {
+		return entities.TimesheetPeriod{}, &api_errors.BadRequestError{
+			Detail: fmt.Sprintf("Week %s does not exist", week),
+		}
+	}
+	return entities.TimesheetPeriod{From: from, To: from.AddDate(0, 0, 7), Location: location}, nil
+}
+
+func GetTimesheet(
+	ctx context.Context,
+	repo repository.TimesheetRepository,
+	user_id int,
+	period entities.TimesheetPeriod,
+) (entities.Timesheet, error) {
+	ctx, span := tracing.Start(ctx, "services.GetTimesheet")
+	defer span.End()
+
+	if _, err := authorizeUserAccess(ctx, repo, user_id, read_access); err != nil {
+		return entities.Timesheet{}, err
+	}
+
+	intervals, err := repo.GetTaskIntervals(ctx, entities.TaskIntervalsFilter{
+		UserIds: []int{user_id},
+		From:    period.From,
+		To:      period.To,
+	})
+	if err != nil {
+		return entities.Timesheet{}, &api_errors.InternalServerError{}
+	}
+	return buildTimesheet(user_id, period, intervals), nil
+}
+
+func GetTeamTimesheet(
+	ctx context.Context,
+	repo repository.TimesheetRepository,
+	period entities.TimesheetPeriod,
+) (entities.TeamTimesheet, error) {
+	ctx, span := tracing.Start(ctx, "services.GetTeamTimesheet")
+	defer span.End()
+
+	if _, err := requireRole(ctx, repo, entities.RoleAdmin); err != nil {
+		return entities.TeamTimesheet{}, err
+	}
+
+	users, err := repo.GetUsers(ctx, entities.UsersQuery{Sort: entities.UsersSortId})
+	if err != nil {
+		return entities.TeamTimesheet{}, &api_errors.InternalServerError{}
+	}
+	user_ids := []int{}
+	for _, user := range users {
+		user_ids = append(user_ids, user.Id)
+	}
+	intervals, err := repo.GetTaskIntervals(ctx, entities.TaskIntervalsFilter{
+		UserIds: user_ids,
+		From:    period.From,
+		To:      period.To,
+	})
+	if err != nil {
+		return entities.TeamTimesheet{}, &api_errors.InternalServerError{}
+	}
+
+	intervals_by_user := map[int][]entities.TaskInterval{}
+	for _, interval := range intervals {
+		intervals_by_user[interval.UserId] = append(intervals_by_user[interval.UserId], interval)
+	}
+
+	team_timesheet := entities.TeamTimesheet{
+		Timezone:   period.Location.String(),
+		Days:       TimesheetDays(period),
+		Timesheets: []entities.Timesheet{},
+	}
+	for _, user := range users {
+		team_timesheet.Timesheets = append(
+			team_timesheet.Timesheets,
+			buildTimesheet(user.Id, period, intervals_by_user[user.Id]),
+		)
+	}
+	return team_timesheet, nil
+}
+
+// TimesheetDays lists the days of the period as YYYY-MM-DD.
+func TimesheetDays(period entities.TimesheetPeriod) []string {
+	days := []string{}
+	for day := period.From; day.Before(period.To); day = day.AddDate(0, 0, 1) {
+		days = append(days, day.Format(timesheet_day_layout))
+	}
+	return days
+}
+
+// buildTimesheet spreads intervals over the days of the period. Intervals
+// crossing midnight are split between days, and days are computed in the
+// period location so DST changes are respected. Cells are rounded down to
+// whole minutes and all totals are sums of the rounded cells.
+func buildTimesheet(
+	user_id int,
+	period entities.TimesheetPeriod,
+	intervals []entities.TaskInterval,
+) entities.Timesheet {
+	days := TimesheetDays(period)
+
+	task_seconds := map[int][]float64{}
+	task_names := map[int]string{}
+	for _, interval := range intervals {
+		if _, ok := task_seconds[interval.TaskId]; !ok {
+			task_seconds[interval.TaskId] = make([]float64, len(days))
+			task_names[interval.TaskId] = interval.TaskName
+		}
+
+		addIntervalSeconds(period, interval, task_seconds[interval.TaskId])
+	}
+
+	task_ids := []int{}
+	for task_id := range task_seconds {
+		task_ids = append(task_ids, task_id)
+	}
+	sort.Ints(task_ids)
+
+	timesheet := entities.Timesheet{
+		UserId:    user_id,
+		Timezone:  period.Location.String(),
+		Days:      days,
+		Tasks:     []entities.TimesheetTask{},
+		DayTotals: make([]int, len(days)),
+	}
+	for _, task_id := range task_ids {
+		task := entities.TimesheetTask{
+			TaskId:   task_id,
+			TaskName: task_names[task_id],
+			Minutes:  make([]int, len(days)),
+		}
+		task.TotalMinutes = secondsToMinutes(task_seconds[task_id], task.Minutes, timesheet.DayTotals)
+		timesheet.TotalMinutes += task.TotalMinutes
+		timesheet.Tasks = append(timesheet.Tasks, task)
+	}
+	return timesheet
+}
+
+// addIntervalSeconds adds the interval to the per-day seconds of its task,
+// splitting it at midnight in the period location.
+func addIntervalSeconds(period entities.TimesheetPeriod, interval entities.TaskInterval, seconds []float64) {
+	day_start := period.From
+	for i := range seconds {
+		day_end := day_start.AddDate(0, 0, 1)
+		start_time := maxTime(interval.StartTime, day_start)
+		end_time := minTime(interval.EndTime, day_end)
+		if end_time.After(start_time) {
+			seconds[i] += end_time.Sub(start_time).Seconds()
+		}
+		day_start = day_end
+	}
+}
+
+// secondsToMinutes rounds every day down to whole minutes, stores them in
+// minutes, adds them to day_totals and returns their sum.
+func secondsToMinutes(seconds []float64, minutes []int, day_totals []int) int {
+	total_minutes := 0
+	for i := range seconds {
+		minutes[i] = int(seconds[i] / 60)
+		day_totals[i] += minutes[i]
+		total_minutes += minutes[i]
+	}
+	return total_minutes
+}
+
+// StreamTimesheet calls fn for every task row of the user followed by the
+// row with the user totals.
+func StreamTimesheet(
+	ctx context.Context,
+	repo repository.TimesheetRepository,
+	user_id int,
+	period entities.TimesheetPeriod,
+	fn func(row entities.TimesheetRow) error,
+) error {
+	ctx, span := tracing.Start(ctx, "services.StreamTimesheet")
+	defer span.End()
+
+	if _, err := authorizeUserAccess(ctx, repo, user_id, read_access); err != nil {
+		return err
+	}
+	filters := entities.TaskIntervalsFilter{UserIds: []int{user_id}, From: period.From, To: period.To}
+	return streamTimesheetRows(ctx, repo, []int{user_id}, filters, period, fn)
+}
+
+// StreamTeamTimesheet is StreamTimesheet for all users ordered by id. Users
+// without tracked time get a totals row only.
+func StreamTeamTimesheet(
+	ctx context.Context,
+	repo repository.TimesheetRepository,
+	period entities.TimesheetPeriod,
+	fn func(row entities.TimesheetRow) error,
+) error {
+	ctx, span := tracing.Start(ctx, "services.StreamTeamTimesheet")
+	defer span.End()
+
+	if _, err := requireRole(ctx, repo, entities.RoleAdmin); err != nil {
+		return err
+	}
+
+	users, err := repo.GetUsers(ctx, entities.UsersQuery{Sort: entities.UsersSortId})
+	if err != nil {
+		return &api_errors.InternalServerError{}
+	}
+	user_ids := []int{}
+	for _, user := range users {
+		user_ids = append(user_ids, user.Id)
+	}
+	// Intervals of deleted users are left out, as in GetTeamTimesheet.
+	filters := entities.TaskIntervalsFilter{UserIds: user_ids, From: period.From, To: period.To}
+	return streamTimesheetRows(ctx, repo, user_ids, filters, period, fn)
+}
+
+// streamTimesheetRows relies on intervals being ordered by user and task, so
+// only the task being accumulated is kept in memory. user_ids must be sorted.
+func streamTimesheetRows(
+	ctx context.Context,
+	repo repository.TimesheetRepository,
+	user_ids []int,
+	filters entities.TaskIntervalsFilter,
+	period entities.TimesheetPeriod,
+	fn func(row entities.TimesheetRow) error,
+) error {
+	days_count := len(TimesheetDays(period))
+	day_totals := make([]int, days_count)
+	var task *entities.TimesheetRow
+	var task_seconds []float64
+	current_user_id := 0
+	next_user := 0
+
+	emitTask := func() error {
+		if task == nil {
+			return nil
+		}
+		task.TotalMinutes = secondsToMinutes(task_seconds, task.Minutes, day_totals)
+		row := *task
+		task = nil
+		return fn(row)
+	}
+	emitTotals := func(user_id int) error {
+		row := entities.TimesheetRow{UserId: user_id, TaskName: "Total", Minutes: day_totals}
+		for _, minutes := range day_totals {
+			row.TotalMinutes += minutes
+		}
+		day_totals = make([]int, days_count)
+		if next_user < len(user_ids) && user_ids[next_user] == user_id {
+			next_user++
+		}
+		return fn(row)
+	}
+	// emitUsersBefore emits empty totals for listed users without intervals.
+	emitUsersBefore := func(user_id int) error {
+		for next_user < len(user_ids) && user_ids[next_user] < user_id {
+			if err := emitTotals(user_ids[next_user]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	err := repo.StreamTaskIntervals(ctx, filters, func(interval entities.TaskInterval) error {
+		if task != nil && (task.UserId != interval.UserId || *task.TaskId != interval.TaskId) {
+			if err := emitTask(); err != nil {
+				return err
+			}
+		}
+		if interval.UserId != current_user_id {
+			if current_user_id != 0 {
+				if err := emitTotals(current_user_id); err != nil {
+					return err
+				}
+			}
+			if err := emitUsersBefore(interval.UserId); err != nil {
+				return err
+			}
+			current_user_id = interval.UserId
+		}
+		if task == nil {
+			task_id := interval.TaskId
+			task = &entities.TimesheetRow{
+				UserId:   interval.UserId,
+				TaskId:   &task_id,
+				TaskName: interval.TaskName,
+				Minutes:  make([]int, days_count),
+			}
+			task_seconds = make([]float64, days_count)
+		}
+		addIntervalSeconds(period, interval, task_seconds)
+		return nil
+	})
+	if errors.Is(err, repo_errors.OperationError{}) {
+		return &api_errors.InternalServerError{}
+	} else if err != nil {
+		return err
+	}
+
+	if err := emitTask(); err != nil {
+		return err
+	}
+	if current_user_id != 0 {
+		if err := emitTotals(current_user_id); err != nil {
+			return err
+		}
+	}
+	return emitUsersBefore(math.MaxInt)
+}
+
+func maxTime(a time.Time, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+func minTime(a time.Time, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
@@ -4,148 +4,434 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"strconv"
-	"strings"
+	"slices"
+	"sort"
+	"task_tracker/src/auth"
+	"task_tracker/src/clients/people_info"
 	"task_tracker/src/entities"
 	"task_tracker/src/errors/api_errors"
 	"task_tracker/src/errors/repo_errors"
+	"task_tracker/src/metrics"
+	"task_tracker/src/passport"
 	"task_tracker/src/repository"
-
-	"github.com/jackc/pgx/v4/pgxpool"
-
-	"github.com/sirupsen/logrus"
+	"task_tracker/src/tracing"
 )
 
+type PeopleInfoProvider interface {
+	GetPeopleInfo(ctx context.Context, passport_serie string, passport_number string) (*people_info.People, error)
+}
+
 func CreateUser(
 	ctx context.Context,
-	pool *pgxpool.Pool,
-	log *logrus.Logger,
+	repo repository.UserRepository,
+	people_info_provider PeopleInfoProvider,
 	user entities.UserCreateRequest,
 ) (entities.User, error) {
-	passport_data, err := getPassportDataFromString(user.PassportNumber)
+	ctx, span := tracing.Start(ctx, "services.CreateUser")
+	defer span.End()
+
+	// Only admins create users, so people info is never returned to anyone
+	// else.
+	if _, err := requireRole(ctx, repo, entities.RoleAdmin); err != nil {
+		return entities.User{}, err
+	}
+
+	passport_serie, passport_number, err := parsePassport(user.PassportNumber)
 	if err != nil {
-		return entities.User{}, &api_errors.BadRequestError{
-			Detail: err.Error(),
-		}
+		return entities.User{}, err
 	}
-	passport_serie := passport_data[0]
-	passport_number := passport_data[1]
 
 	user_to_create := entities.User{
-		PassportSerie:  *passport_serie,
-		PassportNumber: *passport_number,
+		PassportSerie:  passport_serie,
+		PassportNumber: passport_number,
 		Surname:        user.Surname,
 		Name:           user.Name,
+		Patronymic:     user.Patronymic,
+		Address:        user.Address,
+	}
+	if people_info_provider != nil {
+		enrichUser(ctx, people_info_provider, &user_to_create)
+	}
+
+	var password_hash string
+	if user.Password != "" {
+		password_hash, err = auth.HashPassword(user.Password)
+		if err != nil {
+			return entities.User{}, &api_errors.InternalServerError{}
+		}
 	}
-	user_id, err := repository.CreateUser(
-		ctx, pool, log, user_to_create,
-	)
+
+	err = repo.WithTx(ctx, func(ctx context.Context) error {
+		user_id, err := repo.CreateUser(ctx, user_to_create)
+		if err != nil {
+			return err
+		}
+		user_to_create.Id = user_id
+		if password_hash == "" {
+			return nil
+		}
+		return repo.SetUserPassword(ctx, user_id, password_hash)
+	})
 	if err != nil {
 		if errors.Is(err, repo_errors.ObjectAlreadyExistsError{}) {
-			return entities.User{}, &api_errors.BadRequestError{
-				Detail: fmt.Sprintf("User with passport number %s already exists", user.PassportNumber),
+			return entities.User{}, &api_errors.ConflictError{
+				Detail: fmt.Sprintf("User with passport number %s %s already exists", passport_serie, passport_number),
 			}
 		}
 		return entities.User{}, &api_errors.InternalServerError{}
 	}
-	user_to_create.Id = user_id
+	metrics.UsersCreated.Inc()
 	return user_to_create, nil
 }
 
 func UpdateUser(
 	ctx context.Context,
-	pool *pgxpool.Pool,
-	log *logrus.Logger,
+	repo repository.UserRepository,
 	user entities.UserUpdateRequest,
 	user_id int,
+	if_match entities.VersionPrecondition,
 ) (entities.User, error) {
-	passport_data := []*int{nil, nil}
-	var err error
+	ctx, span := tracing.Start(ctx, "services.UpdateUser")
+	defer span.End()
+
+	if _, err := authorizeUserAccess(ctx, repo, user_id, write_access); err != nil {
+		return entities.User{}, err
+	}
+	if user == (entities.UserUpdateRequest{}) {
+		return entities.User{}, &api_errors.BadRequestError{Detail: "Request body must contain at least one field to update"}
+	}
 
+	var passport_serie, passport_number *string
 	if user.PassportNumber != nil {
-		passport_data, err = getPassportDataFromString(*user.PassportNumber)
+		serie, number, err := parsePassport(*user.PassportNumber)
 		if err != nil {
-			return entities.User{}, &api_errors.BadRequestError{
-				Detail: err.Error(),
-			}
+			return entities.User{}, err
 		}
+		passport_serie, passport_number = &serie, &number
 	}
-	passport_serie := passport_data[0]
-	passport_number := passport_data[1]
 
 	user_to_update := entities.UserUpdateRepo{
 		PassportSerie:  passport_serie,
 		PassportNumber: passport_number,
 		Surname:        user.Surname,
 		Name:           user.Name,
+		Patronymic:     user.Patronymic,
+		Address:        user.Address,
 	}
-	updated_user, err := repository.UpdateUser(
-		ctx, pool, log, user_to_update, user_id,
-	)
+	var updated_user entities.User
+	err := repo.WithTx(ctx, func(ctx context.Context) error {
+		if err := checkUserVersion(ctx, repo, user_id, if_match); err != nil {
+			return err
+		}
+		var err error
+		updated_user, err = repo.UpdateUser(ctx, user_to_update, user_id)
+		return err
+	})
 	if err != nil {
-		if errors.Is(err, repo_errors.ObjectAlreadyExistsError{}) {
-			return entities.User{}, &api_errors.BadRequestError{
+		if problem, ok := asProblem(err); ok {
+			return entities.User{}, problem
+		} else if errors.Is(err, repo_errors.ObjectAlreadyExistsError{}) {
+			return entities.User{}, &api_errors.ConflictError{
 				Detail: fmt.Sprintf("%s. userId=%d", err, user_id),
 			}
 		} else if errors.Is(err, repo_errors.ObjectNotFoundError{}) {
-			return entities.User{}, &api_errors.BadRequestError{
-				Detail: fmt.Sprintf("%s. userId=%d", err, user_id),
+			return entities.User{}, &api_errors.NotFoundError{
+				Detail: fmt.Sprintf("User with id=%d does not exist", user_id),
 			}
 		}
-		return entities.User{}, &api_errors.BadRequestError{Detail: err.Error()}
+		return entities.User{}, &api_errors.InternalServerError{}
 	}
 	return updated_user, nil
 }
 
+// enrichUser fills personal data from the people-info service. When the
+// service is unavailable the values from the request are kept.
+func enrichUser(ctx context.Context, people_info_provider PeopleInfoProvider, user *entities.User) {
+	people, err := people_info_provider.GetPeopleInfo(ctx, user.PassportSerie, user.PassportNumber)
+	if err != nil {
+		return
+	}
+	if people.Surname != "" {
+		user.Surname = people.Surname
+	}
+	if people.Name != "" {
+		user.Name = people.Name
+	}
+	if people.Patronymic != "" {
+		user.Patronymic = people.Patronymic
+	}
+	if people.Address != "" {
+		user.Address = people.Address
+	}
+}
+
 func DeleteUser(
 	ctx context.Context,
-	pool *pgxpool.Pool,
-	log *logrus.Logger,
+	repo repository.UserRepository,
+	user_id int,
+	if_match entities.VersionPrecondition,
+) error {
+	ctx, span := tracing.Start(ctx, "services.DeleteUser")
+	defer span.End()
+
+	if _, err := requireRole(ctx, repo, entities.RoleAdmin); err != nil {
+		return err
+	}
+
+	err := repo.WithTx(ctx, func(ctx context.Context) error {
+		if err := checkUserVersion(ctx, repo, user_id, if_match); err != nil {
+			return err
+		}
+		return repo.DeleteUser(ctx, user_id)
+	})
+	if problem, ok := asProblem(err); ok {
+		return problem
+	} else if err != nil {
+		return api_errors.FromRepoError(err, fmt.Sprintf("User with id=%d does not exist", user_id))
+	}
+	return nil
+}
+
+func RestoreUser(
+	ctx context.Context,
+	repo repository.UserRepository,
 	user_id int,
 ) error {
-	err := repository.DeleteUser(ctx, pool, log, user_id)
+	ctx, span := tracing.Start(ctx, "services.RestoreUser")
+	defer span.End()
+
+	if _, err := requireRole(ctx, repo, entities.RoleAdmin); err != nil {
+		return err
+	}
+
+	err := repo.RestoreUser(ctx, user_id)
 	if err != nil {
-		return api_errors.InternalServerError{}
+		if errors.Is(err, repo_errors.ObjectNotFoundError{}) {
+			return &api_errors.NotFoundError{
+				Detail: fmt.Sprintf("User with id=%d does not exist", user_id),
+			}
+		} else if errors.Is(err, repo_errors.InvalidStateError{}) {
+			return &api_errors.ConflictError{
+				Detail: fmt.Sprintf("User with id=%d is not deleted", user_id),
+			}
+		} else if errors.Is(err, repo_errors.ObjectAlreadyExistsError{}) {
+			return &api_errors.ConflictError{
+				Detail: fmt.Sprintf("Passport of user with id=%d is used by another user", user_id),
+			}
+		}
+		return &api_errors.InternalServerError{}
 	}
 	return nil
 }
 
-func getPassportDataFromString(passport_number_string string) ([]*int, error) {
-	passport_data := strings.Split(passport_number_string, " ")
-	if len(passport_data) < 2 {
-		return []*int{}, fmt.Errorf("Incorrect passportNumber format. Passport serie and passport number should be devided with space")
+func parsePassport(value string) (string, string, error) {
+	passport_serie, passport_number, err := passport.Parse(value)
+	if err != nil {
+		return "", "", &api_errors.BadRequestError{
+			Detail:        fmt.Sprintf("Incorrect passportNumber: %s", err),
+			InvalidParams: []entities.InvalidParam{{Name: "passportNumber", Reason: err.Error()}},
+		}
 	}
-	passport_serie, err_serie := strconv.Atoi(passport_data[0])
-	passport_number, err_num := strconv.Atoi(passport_data[1])
-	if err_serie != nil || err_num != nil {
-		return []*int{}, fmt.Errorf("Incorrect passportNumber format. Passport serie and passport number must be numbers, not strings")
+	return passport_serie, passport_number, nil
+}
+
+func GetUser(
+	ctx context.Context,
+	repo repository.UserRepository,
+	user_id int,
+) (entities.User, error) {
+	ctx, span := tracing.Start(ctx, "services.GetUser")
+	defer span.End()
+
+	if _, err := authorizeUserAccess(ctx, repo, user_id, read_access); err != nil {
+		return entities.User{}, err
+	}
+
+	user, err := repo.GetUser(ctx, user_id)
+	if err != nil {
+		return entities.User{}, api_errors.FromRepoError(err, fmt.Sprintf("User with id=%d does not exist", user_id))
 	}
-	return []*int{&passport_serie, &passport_number}, nil
+	return user, nil
 }
 
+// GetUsers returns the page of users next to the position of the request.
+// One user more than the limit is read to tell whether another page follows.
 func GetUsers(
 	ctx context.Context,
-	pool *pgxpool.Pool,
-	log *logrus.Logger,
-	offset int,
-	limit int,
-) ([]entities.User, int, error) {
-	users, users_count, err := repository.GetUsers(ctx, pool, log, offset, limit)
+	repo repository.UserRepository,
+	request entities.UsersPageRequest,
+) (entities.UsersPage, error) {
+	ctx, span := tracing.Start(ctx, "services.GetUsers")
+	defer span.End()
+
+	if _, err := requireRole(ctx, repo, entities.RoleAdmin); err != nil {
+		return entities.UsersPage{Users: []entities.User{}}, err
+	}
+
+	// Pages before the position are read in reverse order.
+	users, err := repo.GetUsers(ctx, entities.UsersQuery{
+		Filter:     request.Filter,
+		Sort:       request.Sort,
+		Descending: request.Descending != request.Backward,
+		After:      request.Position,
+		Limit:      request.Limit + 1,
+	})
 	if err != nil {
-		return []entities.User{}, 0, api_errors.InternalServerError{}
+		return entities.UsersPage{Users: []entities.User{}}, &api_errors.InternalServerError{}
+	}
+	has_more := len(users) > request.Limit
+	if has_more {
+		users = users[:request.Limit]
+	}
+	if request.Backward {
+		slices.Reverse(users)
+	}
+
+	page := entities.UsersPage{Users: users}
+	if len(users) == 0 {
+		// Users past the position were deleted, let the client turn back.
+		if request.Position != nil {
+			reverse := request
+			reverse.Backward = !request.Backward
+			if request.Backward {
+				page.Next = &reverse
+			} else {
+				page.Prev = &reverse
+			}
+		}
+		return page, nil
+	}
+
+	page_request := func(user entities.User, backward bool) *entities.UsersPageRequest {
+		position := entities.UserPositionOf(user, request.Sort)
+		return &entities.UsersPageRequest{
+			Filter:     request.Filter,
+			Sort:       request.Sort,
+			Descending: request.Descending,
+			Position:   &position,
+			Backward:   backward,
+		}
+	}
+	if has_more || request.Backward {
+		page.Next = page_request(users[len(users)-1], false)
 	}
-	return users, users_count, nil
+	if request.Backward && has_more || !request.Backward && request.Position != nil {
+		page.Prev = page_request(users[0], true)
+	}
+	return page, nil
 }
 
 func GetUserActivities(
 	ctx context.Context,
-	pool *pgxpool.Pool,
-	log *logrus.Logger,
+	repo repository.UserRepository,
 	filters entities.UserActivityRequest,
 ) ([]entities.UserActivityTask, error) {
-	tasks, err := repository.GetUserActivity(ctx, pool, log, filters)
+	ctx, span := tracing.Start(ctx, "services.GetUserActivities")
+	defer span.End()
+
+	if _, err := authorizeUserAccess(ctx, repo, filters.UserId, read_access); err != nil {
+		return []entities.UserActivityTask{}, err
+	}
+
+	tasks, err := repo.GetUserActivity(ctx, filters)
 	if err != nil {
 		return []entities.UserActivityTask{}, &api_errors.InternalServerError{}
 	}
 	return tasks, nil
 }
+
+// StreamUserActivities is GetUserActivities for exports, fn is called for
+// every task as it is read.
+func StreamUserActivities(
+	ctx context.Context,
+	repo repository.UserRepository,
+	filters entities.UserActivityRequest,
+	fn func(task entities.UserActivityTask) error,
+) error {
+	ctx, span := tracing.Start(ctx, "services.StreamUserActivities")
+	defer span.End()
+
+	if _, err := authorizeUserAccess(ctx, repo, filters.UserId, read_access); err != nil {
+		return err
+	}
+
+	err := repo.StreamUserActivity(ctx, filters, fn)
+	if errors.Is(err, repo_errors.OperationError{}) {
+		return &api_errors.InternalServerError{}
+	}
+	return err
+}
+
+// GroupUserActivitiesByProject totals the activity report per project. Tasks
+// without a project are collected in a group with a nil ProjectId, which is
+// always listed last.
+func GroupUserActivitiesByProject(tasks []entities.UserActivityTask) []entities.UserActivityProject {
+	projects := []entities.UserActivityProject{}
+	project_index := map[int]int{}
+	no_project_index := -1
+
+	for _, task := range tasks {
+		var index int
+		var ok bool
+		if task.ProjectId == nil {
+			index, ok = no_project_index, no_project_index >= 0
+		} else {
+			index, ok = project_index[*task.ProjectId]
+		}
+		if !ok {
+			projects = append(projects, entities.UserActivityProject{
+				ProjectId:   task.ProjectId,
+				ProjectName: task.ProjectName,
+				Tasks:       []entities.UserActivityTask{},
+			})
+			index = len(projects) - 1
+			if task.ProjectId == nil {
+				no_project_index = index
+			} else {
+				project_index[*task.ProjectId] = index
+			}
+		}
+
+		project := &projects[index]
+		total_minutes := project.Hours*60 + project.Minutes + task.Hours*60 + task.Minutes
+		project.Hours = total_minutes / 60
+		project.Minutes = total_minutes % 60
+		project.Tasks = append(project.Tasks, task)
+	}
+
+	sort.SliceStable(projects, func(i, j int) bool {
+		if (projects[i].ProjectId == nil) != (projects[j].ProjectId == nil) {
+			return projects[j].ProjectId == nil
+		}
+		return projects[i].Hours*60+projects[i].Minutes > projects[j].Hours*60+projects[j].Minutes
+	})
+	return projects
+}
+
+func SetUserRole(
+	ctx context.Context,
+	repo repository.UserRepository,
+	request entities.SetUserRoleRequest,
+	user_id int,
+) (entities.UserAccess, error) {
+	ctx, span := tracing.Start(ctx, "services.SetUserRole")
+	defer span.End()
+
+	if _, err := requireRole(ctx, repo, entities.RoleAdmin); err != nil {
+		return entities.UserAccess{}, err
+	}
+	if request.ManagerId != nil && *request.ManagerId == user_id {
+		return entities.UserAccess{}, &api_errors.BadRequestError{Detail: "User can not be their own manager"}
+	}
+
+	access := entities.UserAccess{
+		UserId:    user_id,
+		Role:      request.Role,
+		ManagerId: request.ManagerId,
+	}
+	err := repo.SetUserRole(ctx, access)
+	if err != nil {
+		return entities.UserAccess{}, api_errors.FromRepoError(err, fmt.Sprintf("User with id=%d or its manager does not exist", user_id))
+	}
+	return access, nil
+}
@@ -0,0 +1,61 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"task_tracker/src/entities"
+	"task_tracker/src/errors/api_errors"
+	"task_tracker/src/repository"
+)
+
+// checkUserVersion is called in the unit of work of a change. The version
+// read keeps the row locked, so no concurrent change slips in between.
+func checkUserVersion(
+	ctx context.Context,
+	repo repository.UserRepository,
+	user_id int,
+	if_match entities.VersionPrecondition,
+) error {
+	if if_match == nil {
+		return nil
+	}
+	version, err := repo.GetUserVersion(ctx, user_id)
+	if err != nil {
+		return err
+	}
+	if !if_match.Allows(version) {
+		return &api_errors.PreconditionFailedError{
+			Detail: fmt.Sprintf("User with id=%d was modified, its current version is %d", user_id, version),
+		}
+	}
+	return nil
+}
+
+func checkTaskVersion(
+	ctx context.Context,
+	repo repository.TaskRepository,
+	task_id int,
+	if_match entities.VersionPrecondition,
+) error {
+	if if_match == nil {
+		return nil
+	}
+	version, err := repo.GetTaskVersion(ctx, task_id)
+	if err != nil {
+		return err
+	}
+	if !if_match.Allows(version) {
+		return &api_errors.PreconditionFailedError{
+			Detail: fmt.Sprintf("Task with id=%d was modified, its current version is %d", task_id, version),
+		}
+	}
+	return nil
+}
+
+// asProblem returns err when it already is an API error, like a failed
+// precondition returned from a unit of work.
+func asProblem(err error) (api_errors.Problem, bool) {
+	var problem api_errors.Problem
+	return problem, errors.As(err, &problem)
+}
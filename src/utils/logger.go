@@ -1,17 +1,74 @@
 package utils
 
 import (
+	"context"
 	"os"
+	"sync"
+	"task_tracker/src/config"
 
 	"github.com/sirupsen/logrus"
 )
 
-func GetLogger() *logrus.Logger {
+func GetLogger(log_config config.LogConfig) *logrus.Logger {
 	log := logrus.New()
-	log.SetFormatter(&logrus.JSONFormatter{})
+	if log_config.Format == config.LogFormatText {
+		log.SetFormatter(&logrus.TextFormatter{FullTimestamp: true})
+	} else {
+		log.SetFormatter(&logrus.JSONFormatter{})
+	}
 
 	log.SetOutput(os.Stdout)
 
-	log.SetLevel(logrus.InfoLevel)
+	level, err := logrus.ParseLevel(log_config.Level)
+	if err != nil {
+		level = logrus.InfoLevel
+	}
+	log.SetLevel(level)
 	return log
 }
+
+type request_logger_key struct{}
+
+// requestLogger is shared by everything handling one request, so fields added
+// deep in the chain, like the user id, also end up in the access log.
+type requestLogger struct {
+	mu    sync.Mutex
+	entry *logrus.Entry
+}
+
+func ContextWithLogger(ctx context.Context, entry *logrus.Entry) context.Context {
+	return context.WithValue(ctx, request_logger_key{}, &requestLogger{entry: entry})
+}
+
+// LoggerFromContext returns the request logger, or an entry of fallback
+// outside of a request.
+func LoggerFromContext(ctx context.Context, fallback *logrus.Logger) *logrus.Entry {
+	if request_logger, ok := ctx.Value(request_logger_key{}).(*requestLogger); ok {
+		request_logger.mu.Lock()
+		defer request_logger.mu.Unlock()
+		return request_logger.entry
+	}
+	return logrus.NewEntry(fallback)
+}
+
+// AddLogFields adds fields to the request logger in ctx, if any.
+func AddLogFields(ctx context.Context, fields logrus.Fields) {
+	if request_logger, ok := ctx.Value(request_logger_key{}).(*requestLogger); ok {
+		request_logger.mu.Lock()
+		defer request_logger.mu.Unlock()
+		request_logger.entry = request_logger.entry.WithFields(fields)
+	}
+}
+
+type request_id_key struct{}
+
+func ContextWithRequestId(ctx context.Context, request_id string) context.Context {
+	return context.WithValue(ctx, request_id_key{}, request_id)
+}
+
+// RequestIdFromContext returns the id of the request being handled, or an
+// empty string outside of a request.
+func RequestIdFromContext(ctx context.Context) string {
+	request_id, _ := ctx.Value(request_id_key{}).(string)
+	return request_id
+}
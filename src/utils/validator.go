@@ -5,35 +5,79 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"task_tracker/src/entities"
 	"task_tracker/src/errors/api_errors"
+	"task_tracker/src/passport"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/go-playground/validator/v10"
 )
 
+const max_task_name_length = 255
+
 var validate *validator.Validate
 
+var strict_decoding atomic.Bool
+
+var validation_reasons = map[string]string{
+	"required":  "is required",
+	"task_name": fmt.Sprintf("must not be blank, longer than %d characters or contain control characters", max_task_name_length),
+}
+
 func init() {
 	validate = validator.New()
+	validate.RegisterTagNameFunc(jsonFieldName)
+	validate.RegisterValidation("passport", validatePassport)
+	validate.RegisterValidation("task_name", validateTaskName)
+}
+
+// SetStrictDecoding makes ValidateRequestData reject bodies with fields the
+// request schema does not have.
+func SetStrictDecoding(enabled bool) {
+	strict_decoding.Store(enabled)
 }
 
 func ValidateRequestData[T any](request_schema T, body io.Reader) (*T, error) {
 	decoder := json.NewDecoder(body)
+	if strict_decoding.Load() {
+		decoder.DisallowUnknownFields()
+	}
 	if err := decoder.Decode(&request_schema); err != nil {
-		var unmarshal_err *json.UnmarshalTypeError
-		if errors.As(err, &unmarshal_err) {
-			return nil, api_errors.BadRequestError{Detail: getJsonUnmarshalError(unmarshal_err)}
+		return nil, getDecodeError(err)
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		var max_bytes_err *http.MaxBytesError
+		if errors.As(err, &max_bytes_err) {
+			return nil, getDecodeError(err)
 		}
-		return nil, api_errors.BadRequestError{Detail: err.Error()}
+		return nil, api_errors.BadRequestError{Detail: "Request body must contain a single JSON object"}
 	}
 
 	err := validateData(&request_schema)
 	if err != nil {
-		for _, err := range err.(validator.ValidationErrors) {
-			fieldError := fmt.Sprintf("Validation failed on field '%s', condition: '%s'", err.Field(), err.Tag())
-			return nil, api_errors.BadRequestError{Detail: fieldError}
+		var validation_errors validator.ValidationErrors
+		if !errors.As(err, &validation_errors) {
+			return nil, api_errors.BadRequestError{Detail: "Validation failed"}
+		}
+		details := make([]string, 0, len(validation_errors))
+		invalid_params := make([]entities.InvalidParam, 0, len(validation_errors))
+		for _, field_error := range validation_errors {
+			field_path := getFieldPath(field_error)
+			details = append(details, fmt.Sprintf("Validation failed on field '%s', condition: '%s'", field_path, field_error.Tag()))
+			invalid_params = append(invalid_params, entities.InvalidParam{
+				Name:   field_path,
+				Reason: getValidationReason(field_error),
+			})
+		}
+		return nil, api_errors.BadRequestError{
+			Detail:        strings.Join(details, "; "),
+			InvalidParams: invalid_params,
 		}
-		// TODO: add logging
-		return nil, api_errors.BadRequestError{Detail: "Validation failed"}
 	}
 	return &request_schema, nil
 }
@@ -42,6 +86,84 @@ func validateData(data interface{}) error {
 	return validate.Struct(data)
 }
 
+func getDecodeError(err error) error {
+	var unmarshal_err *json.UnmarshalTypeError
+	if errors.As(err, &unmarshal_err) {
+		detail := getJsonUnmarshalError(unmarshal_err)
+		return api_errors.BadRequestError{
+			Detail:        detail,
+			InvalidParams: []entities.InvalidParam{{Name: unmarshal_err.Field, Reason: detail}},
+		}
+	}
+	var max_bytes_err *http.MaxBytesError
+	if errors.As(err, &max_bytes_err) {
+		return api_errors.PayloadTooLargeError{
+			Detail: fmt.Sprintf("Request body must not exceed %d bytes", max_bytes_err.Limit),
+		}
+	}
+	if errors.Is(err, io.EOF) {
+		return api_errors.BadRequestError{Detail: "Request body must not be empty"}
+	}
+	// encoding/json has no typed error for unknown fields.
+	if field, found := strings.CutPrefix(err.Error(), "json: unknown field "); found {
+		field = strings.Trim(field, `"`)
+		return api_errors.BadRequestError{
+			Detail:        fmt.Sprintf("Unknown field '%s'", field),
+			InvalidParams: []entities.InvalidParam{{Name: field, Reason: "is not allowed"}},
+		}
+	}
+	return api_errors.BadRequestError{Detail: err.Error()}
+}
+
 func getJsonUnmarshalError(err *json.UnmarshalTypeError) string {
 	return "Field '" + err.Field + "' must be of type " + err.Type.String()
 }
+
+// getFieldPath drops the struct name from the namespace, leaving the JSON path
+// of the field, e.g. "tasks[0].taskName".
+func getFieldPath(field_error validator.FieldError) string {
+	namespace := field_error.Namespace()
+	if _, path, found := strings.Cut(namespace, "."); found {
+		return path
+	}
+	return namespace
+}
+
+func getValidationReason(field_error validator.FieldError) string {
+	if field_error.Tag() == "passport" {
+		if _, _, err := passport.Parse(fmt.Sprint(field_error.Value())); err != nil {
+			return err.Error()
+		}
+	}
+	if reason, ok := validation_reasons[field_error.Tag()]; ok {
+		return reason
+	}
+	if field_error.Param() != "" {
+		return fmt.Sprintf("condition '%s=%s'", field_error.Tag(), field_error.Param())
+	}
+	return fmt.Sprintf("condition '%s'", field_error.Tag())
+}
+
+func jsonFieldName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "-" {
+		return ""
+	}
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
+
+func validatePassport(field validator.FieldLevel) bool {
+	_, _, err := passport.Parse(field.Field().String())
+	return err == nil
+}
+
+func validateTaskName(field validator.FieldLevel) bool {
+	task_name := field.Field().String()
+	if strings.TrimSpace(task_name) == "" || utf8.RuneCountInString(task_name) > max_task_name_length {
+		return false
+	}
+	return strings.IndexFunc(task_name, unicode.IsControl) == -1
+}
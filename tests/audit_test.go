@@ -0,0 +1,191 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"task_tracker/src/api"
+	"task_tracker/src/entities"
+	"task_tracker/src/services"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newAuditTestRouter(backend *TestBackend, user_id int) *mux.Router {
+	log := logrus.New()
+	log.SetOutput(io.Discard)
+
+	router := NewTestRouter(user_id)
+	router.Use(api.RequestLoggingMiddleware(log))
+	api.InitUserRoutes(router, backend.Repo, nil, NewTestConfig().Pagination)
+	api.InitTaskRoutes(router, backend.Repo)
+	api.InitAuditRoutes(router, backend.Repo, NewTestConfig().Pagination)
+	return router
+}
+
+func getAuditEvents(t *testing.T, router *mux.Router, query string) entities.GetAuditEventsResponse {
+	rr := doJSONRequest(router, "GET", "/audit?"+query, nil, "")
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var response entities.GetAuditEventsResponse
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&response))
+	return response
+}
+
+func TestAudit__UserUpdate(t *testing.T) {
+	backend, cleanup, err := SetupTestBackend()
+	require.NoError(t, err)
+	defer cleanup()
+
+	err = backend.CreateUsers(
+		entities.User{PassportSerie: "1111", PassportNumber: "111111", Surname: "Ivanov", Name: "Ivan"},
+		entities.User{PassportSerie: "1212", PassportNumber: "232323", Surname: "Petrov", Name: "Petr"},
+	)
+	require.NoError(t, err)
+	require.NoError(t, backend.SetRole(1, entities.RoleAdmin, nil))
+	router := newAuditTestRouter(backend, 1)
+
+	passport := "3344 232323"
+	body, _ := json.Marshal(entities.UserUpdateRequest{PassportNumber: &passport})
+	req := httptest.NewRequest("PATCH", "/users/2", bytes.NewBuffer(body))
+	req.Header.Set("X-Request-ID", "req-audit")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	response := getAuditEvents(t, router, "entity=user&entityId=2")
+	require.Equal(t, 2, len(response.Events))
+	assert.Equal(t, 1, response.LastPage)
+
+	update_event := response.Events[0]
+	assert.Equal(t, entities.AuditActionUpdate, update_event.Action)
+	require.NotNil(t, update_event.ActorId)
+	assert.Equal(t, 1, *update_event.ActorId)
+	assert.Equal(t, "req-audit", update_event.RequestId)
+	require.Equal(t, 1, len(update_event.Changes))
+	assert.JSONEq(t, `"1212"`, string(update_event.Changes["passportSerie"].Before))
+	assert.JSONEq(t, `"3344"`, string(update_event.Changes["passportSerie"].After))
+
+	create_event := response.Events[1]
+	assert.Equal(t, entities.AuditActionCreate, create_event.Action)
+	assert.Nil(t, create_event.ActorId)
+	assert.JSONEq(t, `null`, string(create_event.Changes["surname"].Before))
+	assert.JSONEq(t, `"Petrov"`, string(create_event.Changes["surname"].After))
+
+	response = getAuditEvents(t, router, "action=set_role")
+	require.Equal(t, 1, len(response.Events))
+	assert.Equal(t, 1, response.Events[0].EntityId)
+	assert.JSONEq(t, `"admin"`, string(response.Events[0].Changes["role"].After))
+}
+
+func TestAudit__TaskFinish(t *testing.T) {
+	backend, cleanup, err := SetupTestBackend()
+	require.NoError(t, err)
+	defer cleanup()
+
+	createUserWithRunningTask(backend)
+	require.NoError(t, backend.SetRole(1, entities.RoleAdmin, nil))
+	router := newAuditTestRouter(backend, 1)
+
+	rr := doJSONRequest(router, "POST", "/tasks/finish", entities.FinishTaskRequest{TaskId: 1}, "")
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	response := getAuditEvents(t, router, "entity=task&action=finish&actorId=1")
+	require.Equal(t, 1, len(response.Events))
+	changes := response.Events[0].Changes
+	require.Equal(t, 1, len(changes))
+	assert.JSONEq(t, `null`, string(changes["endTime"].Before))
+	assert.NotEqual(t, "null", string(changes["endTime"].After))
+}
+
+func TestAudit__TaskPauseAndResume(t *testing.T) {
+	backend, cleanup, err := SetupTestBackend()
+	require.NoError(t, err)
+	defer cleanup()
+
+	createUserWithRunningTask(backend)
+	require.NoError(t, backend.SetRole(1, entities.RoleAdmin, nil))
+	router := newAuditTestRouter(backend, 1)
+
+	rr := doJSONRequest(router, "POST", "/tasks/1/pause", nil, "")
+	require.Equal(t, http.StatusOK, rr.Code)
+	rr = doJSONRequest(router, "POST", "/tasks/1/resume", nil, "")
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	response := getAuditEvents(t, router, "entity=task&entityId=1&actorId=1")
+	require.Equal(t, 2, len(response.Events))
+	assert.Equal(t, entities.AuditActionResume, response.Events[0].Action)
+	assert.JSONEq(t, `true`, string(response.Events[0].Changes["paused"].Before))
+	assert.JSONEq(t, `false`, string(response.Events[0].Changes["paused"].After))
+	assert.Equal(t, entities.AuditActionPause, response.Events[1].Action)
+	assert.JSONEq(t, `false`, string(response.Events[1].Changes["paused"].Before))
+	assert.JSONEq(t, `true`, string(response.Events[1].Changes["paused"].After))
+}
+
+func TestAudit__UserDeleteAndPurge(t *testing.T) {
+	backend, cleanup, err := SetupTestBackend()
+	require.NoError(t, err)
+	defer cleanup()
+
+	err = backend.CreateUsers(
+		entities.User{PassportSerie: "1111", PassportNumber: "111111", Surname: "Ivanov", Name: "Ivan"},
+		entities.User{PassportSerie: "1212", PassportNumber: "232323", Surname: "Petrov", Name: "Petr"},
+	)
+	require.NoError(t, err)
+	require.NoError(t, backend.SetRole(1, entities.RoleAdmin, nil))
+	require.NoError(t, backend.CreateTask(entities.Task{UserId: 2, TaskName: "task1"}))
+	router := newAuditTestRouter(backend, 1)
+
+	rr := doJSONRequest(router, "DELETE", "/users/2", nil, "")
+	require.Equal(t, http.StatusNoContent, rr.Code)
+
+	// Archiving the tasks of a deleted user finishes them.
+	response := getAuditEvents(t, router, "entity=task&action=finish&actorId=1")
+	require.Equal(t, 1, len(response.Events))
+	assert.Equal(t, 1, response.Events[0].EntityId)
+	assert.JSONEq(t, `null`, string(response.Events[0].Changes["endTime"].Before))
+	assert.NotEqual(t, "null", string(response.Events[0].Changes["endTime"].After))
+
+	purged, err := services.PurgeDeletedUsers(context.Background(), backend.Repo, 30, time.Now().AddDate(0, 0, 31))
+	require.NoError(t, err)
+	require.Equal(t, 1, purged)
+
+	response = getAuditEvents(t, router, "entity=user&entityId=2")
+	require.Equal(t, 3, len(response.Events))
+	assert.Equal(t, entities.AuditActionPurge, response.Events[0].Action)
+	assert.NotEqual(t, "null", string(response.Events[1].Changes["deletedAt"].After))
+	create_changes := response.Events[2].Changes
+	for _, name := range []string{"passportSerie", "passportNumber", "surname", "name"} {
+		assert.JSONEq(t, `null`, string(create_changes[name].Before))
+		assert.JSONEq(t, `"[redacted]"`, string(create_changes[name].After))
+	}
+	assert.JSONEq(t, `""`, string(create_changes["patronymic"].After))
+
+	// Users that are not purged keep their data.
+	response = getAuditEvents(t, router, "entity=user&entityId=1&action=create")
+	require.Equal(t, 1, len(response.Events))
+	assert.JSONEq(t, `"Ivanov"`, string(response.Events[0].Changes["surname"].After))
+}
+
+func TestAudit__Forbidden(t *testing.T) {
+	backend, cleanup, err := SetupTestBackend()
+	require.NoError(t, err)
+	defer cleanup()
+
+	createUserWithRunningTask(backend)
+	router := newAuditTestRouter(backend, 1)
+
+	rr := doJSONRequest(router, "GET", "/audit", nil, "")
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+
+	rr = doJSONRequest(router, "GET", "/audit?entityId=abc", nil, "")
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
@@ -0,0 +1,202 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"task_tracker/src/api"
+	"task_tracker/src/auth"
+	"task_tracker/src/entities"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+)
+
+func setupAuthRouter(backend *TestBackend) *mux.Router {
+	token_manager := auth.NewTokenManager(auth.Config{
+		Secret:          []byte("test-secret"),
+		AccessTokenTTL:  time.Minute,
+		RefreshTokenTTL: time.Hour,
+	})
+
+	router := mux.NewRouter()
+	router.Use(api.AuthMiddleware(token_manager))
+	api.InitAuthRoutes(router, backend.Repo, token_manager)
+	api.InitUserRoutes(router, backend.Repo, nil, NewTestConfig().Pagination)
+	api.InitTaskRoutes(router, backend.Repo)
+	return router
+}
+
+func doJSONRequest(router *mux.Router, method string, url string, body interface{}, access_token string) *httptest.ResponseRecorder {
+	var request_body bytes.Buffer
+	if body != nil {
+		json.NewEncoder(&request_body).Encode(body)
+	}
+	req := httptest.NewRequest(method, url, &request_body)
+	if access_token != "" {
+		req.Header.Set("Authorization", "Bearer "+access_token)
+	}
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	return rr
+}
+
+// registerAndLogin creates user 1 with a password and logs them in.
+func registerAndLogin(t *testing.T, backend *TestBackend, router *mux.Router) entities.TokenResponse {
+	err := backend.CreateUsers(
+		entities.User{PassportSerie: "1234", PassportNumber: "567890", Surname: "Ivanov", Name: "Ivan"},
+	)
+	require.NoError(t, err)
+	require.NoError(t, backend.SetPassword(1, "secret"))
+
+	rr := doJSONRequest(router, "POST", "/auth/login", entities.LoginRequest{
+		PassportNumber: "1234 567890",
+		Password:       "secret",
+	}, "")
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var tokens entities.TokenResponse
+	err = json.NewDecoder(rr.Body).Decode(&tokens)
+	require.NoError(t, err)
+	return tokens
+}
+
+func TestAuth__ProtectedRouteWithoutToken__Unauthorized(t *testing.T) {
+	backend, cleanup, err := SetupTestBackend()
+	require.NoError(t, err)
+	defer cleanup()
+
+	router := setupAuthRouter(backend)
+
+	rr := doJSONRequest(router, "GET", "/users", nil, "")
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+
+	rr = doJSONRequest(router, "GET", "/users", nil, "not-a-token")
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+}
+
+func TestAuth__Login__WrongPassword(t *testing.T) {
+	backend, cleanup, err := SetupTestBackend()
+	require.NoError(t, err)
+	defer cleanup()
+
+	router := setupAuthRouter(backend)
+	registerAndLogin(t, backend, router)
+
+	rr := doJSONRequest(router, "POST", "/auth/login", entities.LoginRequest{
+		PassportNumber: "1234 567890",
+		Password:       "wrong",
+	}, "")
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+
+	var response entities.ProblemResponse
+	err = json.NewDecoder(rr.Body).Decode(&response)
+	require.NoError(t, err)
+	assert.Equal(t, "unauthorized", response.Code)
+	assert.Equal(t, "Invalid passport number or password", response.Detail)
+}
+
+func TestAuth__CreateTask__DefaultsToCaller(t *testing.T) {
+	backend, cleanup, err := SetupTestBackend()
+	require.NoError(t, err)
+	defer cleanup()
+
+	router := setupAuthRouter(backend)
+	tokens := registerAndLogin(t, backend, router)
+
+	rr := doJSONRequest(router, "POST", "/tasks", map[string]string{"taskName": "task1"}, tokens.AccessToken)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var response entities.CreateTaskResponse
+	err = json.NewDecoder(rr.Body).Decode(&response)
+	require.NoError(t, err)
+	assert.Equal(t, 1, response.UserId)
+}
+
+func TestAuth__RefreshAndLogout(t *testing.T) {
+	backend, cleanup, err := SetupTestBackend()
+	require.NoError(t, err)
+	defer cleanup()
+
+	router := setupAuthRouter(backend)
+	tokens := registerAndLogin(t, backend, router)
+
+	rr := doJSONRequest(router, "POST", "/auth/refresh", entities.RefreshTokenRequest{RefreshToken: tokens.RefreshToken}, "")
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var refreshed_tokens entities.TokenResponse
+	err = json.NewDecoder(rr.Body).Decode(&refreshed_tokens)
+	require.NoError(t, err)
+	assert.NotEqual(t, tokens.RefreshToken, refreshed_tokens.RefreshToken)
+
+	rr = doJSONRequest(router, "GET", "/tasks", nil, refreshed_tokens.AccessToken)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	// The original refresh token was rotated and cannot be reused.
+	rr = doJSONRequest(router, "POST", "/auth/refresh", entities.RefreshTokenRequest{RefreshToken: tokens.RefreshToken}, "")
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+
+	rr = doJSONRequest(router, "POST", "/auth/logout", entities.RefreshTokenRequest{RefreshToken: refreshed_tokens.RefreshToken}, "")
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+
+	rr = doJSONRequest(router, "POST", "/auth/refresh", entities.RefreshTokenRequest{RefreshToken: refreshed_tokens.RefreshToken}, "")
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+}
+
+func TestAuth__ConcurrentRefresh(t *testing.T) {
+	backend, cleanup, err := SetupTestBackend()
+	require.NoError(t, err)
+	defer cleanup()
+
+	router := setupAuthRouter(backend)
+	tokens := registerAndLogin(t, backend, router)
+
+	codes := make(chan int, 5)
+	var wg sync.WaitGroup
+	for i := 0; i < cap(codes); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rr := doJSONRequest(router, "POST", "/auth/refresh", entities.RefreshTokenRequest{RefreshToken: tokens.RefreshToken}, "")
+			codes <- rr.Code
+		}()
+	}
+	wg.Wait()
+	close(codes)
+
+	refreshed := 0
+	for code := range codes {
+		if code == http.StatusOK {
+			refreshed++
+		} else {
+			assert.Equal(t, http.StatusUnauthorized, code)
+		}
+	}
+	assert.Equal(t, 1, refreshed)
+}
+
+func TestAuth__CreateUser__RequiresAdmin(t *testing.T) {
+	backend, cleanup, err := SetupTestBackend()
+	require.NoError(t, err)
+	defer cleanup()
+
+	router := setupAuthRouter(backend)
+	tokens := registerAndLogin(t, backend, router)
+	request := entities.UserCreateRequest{PassportNumber: "4321 098765", Name: "Petr", Surname: "Petrov"}
+
+	rr := doJSONRequest(router, "POST", "/users", request, "")
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	rr = doJSONRequest(router, "POST", "/users", request, tokens.AccessToken)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+
+	users, err := backend.GetAllUsers()
+	require.NoError(t, err)
+	assert.Equal(t, 1, len(users))
+}
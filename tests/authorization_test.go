@@ -0,0 +1,141 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"task_tracker/src/api"
+	"task_tracker/src/entities"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// createTeam creates an admin (1), a manager (2), a member reporting to the
+// manager (3) and a member without a manager (4). Every member has one task.
+func createTeam(t *testing.T, backend *TestBackend) {
+	err := backend.CreateUsers(
+		entities.User{PassportSerie: "1212", PassportNumber: "232323", Surname: "Ivanov", Name: "Ivan"},
+		entities.User{PassportSerie: "3434", PassportNumber: "454545", Surname: "Petrov", Name: "Petr"},
+		entities.User{PassportSerie: "5656", PassportNumber: "676767", Surname: "Sidorov", Name: "Sidr"},
+		entities.User{PassportSerie: "7878", PassportNumber: "898989", Surname: "Smirnov", Name: "Sergey"},
+	)
+	require.NoError(t, err)
+
+	manager_id := 2
+	require.NoError(t, backend.SetRole(1, entities.RoleAdmin, nil))
+	require.NoError(t, backend.SetRole(2, entities.RoleManager, nil))
+	require.NoError(t, backend.SetRole(3, entities.RoleMember, &manager_id))
+
+	require.NoError(t, backend.CreateTask(entities.Task{UserId: 3, TaskName: "task1"}))
+	require.NoError(t, backend.CreateTask(entities.Task{UserId: 4, TaskName: "task2"}))
+}
+
+func setupTeamRouter(backend *TestBackend, user_id int) *mux.Router {
+	router := NewTestRouter(user_id)
+	api.InitUserRoutes(router, backend.Repo, nil, NewTestConfig().Pagination)
+	api.InitTaskRoutes(router, backend.Repo)
+	return router
+}
+
+func TestAuthorization__MemberAccessesOnlyOwnTasks(t *testing.T) {
+	backend, cleanup, err := SetupTestBackend()
+	require.NoError(t, err)
+	defer cleanup()
+
+	createTeam(t, backend)
+	router := setupTeamRouter(backend, 3)
+
+	rr := doJSONRequest(router, "GET", "/tasks/1", nil, "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	// Tasks of other teams look like missing ones, so their ids can not be
+	// discovered.
+	rr = doJSONRequest(router, "GET", "/tasks/2", nil, "")
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assert.Equal(t, "Task with id=2 does not exist", decodeProblem(t, rr).Detail)
+	rr = doJSONRequest(router, "GET", "/tasks/99", nil, "")
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	rr = doJSONRequest(router, "POST", "/tasks/finish", entities.FinishTaskRequest{TaskId: 2}, "")
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	rr = doJSONRequest(router, "POST", "/tasks", entities.CreateTaskRequest{TaskName: "task3", UserId: 4}, "")
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+
+	rr = doJSONRequest(router, "GET", "/user-activities/4", nil, "")
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+
+	rr = doJSONRequest(router, "GET", "/tasks", nil, "")
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var response entities.GetTasksResponse
+	err = json.NewDecoder(rr.Body).Decode(&response)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(response.Tasks))
+	assert.Equal(t, 3, response.Tasks[0].UserId)
+}
+
+func TestAuthorization__ManagerReadsReportActivities(t *testing.T) {
+	backend, cleanup, err := SetupTestBackend()
+	require.NoError(t, err)
+	defer cleanup()
+
+	createTeam(t, backend)
+	router := setupTeamRouter(backend, 2)
+
+	rr := doJSONRequest(router, "GET", "/user-activities/3", nil, "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	rr = doJSONRequest(router, "GET", "/user-activities/4", nil, "")
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+
+	// Managers may only read the data of their reports.
+	rr = doJSONRequest(router, "POST", "/tasks/finish", entities.FinishTaskRequest{TaskId: 1}, "")
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	rr = doJSONRequest(router, "POST", "/tasks/finish", entities.FinishTaskRequest{TaskId: 2}, "")
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestAuthorization__AdminOnlyEndpoints(t *testing.T) {
+	backend, cleanup, err := SetupTestBackend()
+	require.NoError(t, err)
+	defer cleanup()
+
+	createTeam(t, backend)
+
+	member_router := setupTeamRouter(backend, 3)
+	rr := doJSONRequest(member_router, "GET", "/users", nil, "")
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+
+	rr = doJSONRequest(member_router, "DELETE", "/users/4", nil, "")
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+
+	rr = doJSONRequest(member_router, "PUT", "/users/3/role", entities.SetUserRoleRequest{Role: entities.RoleAdmin}, "")
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+
+	admin_router := setupTeamRouter(backend, 1)
+	rr = doJSONRequest(admin_router, "PUT", "/users/4/role", entities.SetUserRoleRequest{Role: entities.RoleManager}, "")
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var response entities.SetUserRoleResponse
+	err = json.NewDecoder(rr.Body).Decode(&response)
+	require.NoError(t, err)
+	assert.Equal(t, 4, response.UserId)
+	assert.Equal(t, entities.RoleManager, response.Role)
+
+	rr = doJSONRequest(admin_router, "PUT", "/users/4/role", entities.SetUserRoleRequest{Role: "owner"}, "")
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestAuthorization__UnknownCaller(t *testing.T) {
+	backend, cleanup, err := SetupTestBackend()
+	require.NoError(t, err)
+	defer cleanup()
+
+	router := setupTeamRouter(backend, 12)
+
+	rr := doJSONRequest(router, "GET", "/tasks", nil, "")
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+}
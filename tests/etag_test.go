@@ -0,0 +1,146 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"task_tracker/src/api"
+	"task_tracker/src/entities"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func doConditionalRequest(router *mux.Router, method string, url string, body string, header string, etag string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, url, strings.NewReader(body))
+	if header != "" {
+		req.Header.Set(header, etag)
+	}
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestETag__Task(t *testing.T) {
+	backend, cleanup, err := SetupTestBackend()
+	require.NoError(t, err)
+	defer cleanup()
+
+	createUserWithRunningTask(backend)
+	router := NewTestRouter(1)
+	api.InitTaskRoutes(router, backend.Repo)
+
+	rr := doConditionalRequest(router, "GET", "/tasks/1", "", "", "")
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, `"1"`, rr.Header().Get("ETag"))
+
+	rr = doConditionalRequest(router, "GET", "/tasks/1", "", "If-None-Match", `W/"1"`)
+	assert.Equal(t, http.StatusNotModified, rr.Code)
+	assert.Equal(t, `"1"`, rr.Header().Get("ETag"))
+	assert.Empty(t, rr.Body.String())
+
+	rr = doConditionalRequest(router, "PATCH", "/tasks/1", `{"taskName": "renamed"}`, "If-Match", `"1"`)
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, `"2"`, rr.Header().Get("ETag"))
+
+	rr = doConditionalRequest(router, "PATCH", "/tasks/1", `{"taskName": "lost update"}`, "If-Match", `"1"`)
+	require.Equal(t, http.StatusPreconditionFailed, rr.Code)
+	problem := decodeProblem(t, rr)
+	assert.Equal(t, "precondition_failed", problem.Code)
+
+	task, err := backend.Repo.GetTask(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, "renamed", task.TaskName)
+
+	rr = doConditionalRequest(router, "GET", "/tasks/1", "", "If-None-Match", `"1"`)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	rr = doConditionalRequest(router, "POST", "/tasks/finish", `{"taskId": 1}`, "If-Match", `W/"2"`)
+	assert.Equal(t, http.StatusPreconditionFailed, rr.Code)
+	rr = doConditionalRequest(router, "POST", "/tasks/finish", `{"taskId": 1}`, "If-Match", `"5", "2"`)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	rr = doConditionalRequest(router, "DELETE", "/tasks/1", "", "If-Match", `"2"`)
+	assert.Equal(t, http.StatusPreconditionFailed, rr.Code)
+	rr = doConditionalRequest(router, "DELETE", "/tasks/1", "", "If-Match", "*")
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+}
+
+func TestETag__User(t *testing.T) {
+	backend, cleanup, err := SetupTestBackend()
+	require.NoError(t, err)
+	defer cleanup()
+
+	err = backend.CreateUsers(
+		entities.User{PassportSerie: "1111", PassportNumber: "111111", Surname: "Ivanov", Name: "Ivan"},
+		entities.User{PassportSerie: "1212", PassportNumber: "232323", Surname: "Petrov", Name: "Petr"},
+	)
+	require.NoError(t, err)
+	require.NoError(t, backend.SetRole(1, entities.RoleAdmin, nil))
+	router := NewTestRouter(1)
+	api.InitUserRoutes(router, backend.Repo, nil, NewTestConfig().Pagination)
+
+	rr := doConditionalRequest(router, "GET", "/users/2", "", "", "")
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, `"1"`, rr.Header().Get("ETag"))
+	assert.Contains(t, rr.Body.String(), `"surname":"Petrov"`)
+
+	rr = doConditionalRequest(router, "PATCH", "/users/2", `{"surname": "Sidorov"}`, "If-Match", `"1"`)
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, `"2"`, rr.Header().Get("ETag"))
+
+	// A second admin still holding the first version.
+	rr = doConditionalRequest(router, "PATCH", "/users/2", `{"name": "Pavel"}`, "If-Match", `"1"`)
+	require.Equal(t, http.StatusPreconditionFailed, rr.Code)
+
+	user, err := backend.GetUser(2)
+	require.NoError(t, err)
+	assert.Equal(t, "Sidorov", user.Surname)
+	assert.Equal(t, "Petr", user.Name)
+
+	rr = doConditionalRequest(router, "DELETE", "/users/2", "", "If-Match", `"1"`)
+	assert.Equal(t, http.StatusPreconditionFailed, rr.Code)
+	rr = doConditionalRequest(router, "DELETE", "/users/2", "", "If-Match", `"2"`)
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+
+	rr = doConditionalRequest(router, "GET", "/users/2", "", "", "")
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	rr = doConditionalRequest(router, "POST", "/users/2/restore", "", "", "")
+	require.Equal(t, http.StatusNoContent, rr.Code)
+	rr = doConditionalRequest(router, "GET", "/users/2", "", "If-None-Match", `"2"`)
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, `"4"`, rr.Header().Get("ETag"))
+}
+
+func TestETag__TaskStateChanges(t *testing.T) {
+	backend, cleanup, err := SetupTestBackend()
+	require.NoError(t, err)
+	defer cleanup()
+
+	createUserWithRunningTask(backend)
+	router := NewTestRouter(1)
+	api.InitTaskRoutes(router, backend.Repo)
+
+	rr := doConditionalRequest(router, "POST", "/tasks/1/pause", "", "", "")
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, `"2"`, rr.Header().Get("ETag"))
+
+	rr = doConditionalRequest(router, "POST", "/tasks/1/resume", "", "", "")
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, `"3"`, rr.Header().Get("ETag"))
+
+	// A client that saw the task before it was paused is stale.
+	rr = doConditionalRequest(router, "POST", "/tasks/finish", `{"taskId": 1}`, "If-Match", `"1"`)
+	require.Equal(t, http.StatusPreconditionFailed, rr.Code)
+	rr = doConditionalRequest(router, "POST", "/tasks/finish", `{"taskId": 1}`, "If-Match", `"3"`)
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, `"4"`, rr.Header().Get("ETag"))
+
+	rr = doConditionalRequest(router, "POST", "/tasks/1/reopen", "", "", "")
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, `"5"`, rr.Header().Get("ETag"))
+}
@@ -0,0 +1,214 @@
+package tests
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"task_tracker/src/api"
+	"task_tracker/src/entities"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupExportRouter(backend *TestBackend, user_id int) *mux.Router {
+	router := NewTestRouter(user_id)
+	api.InitUserRoutes(router, backend.Repo, nil, NewTestConfig().Pagination)
+	api.InitTimesheetRoutes(router, backend.Repo)
+	return router
+}
+
+func doExportRequest(router *mux.Router, url string, accept string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("GET", url, nil)
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	return rr
+}
+
+func readCSV(t *testing.T, rr *httptest.ResponseRecorder) [][]string {
+	body, found := strings.CutPrefix(rr.Body.String(), "\ufeff")
+	require.True(t, found, "CSV must start with a UTF-8 BOM")
+	records, err := csv.NewReader(strings.NewReader(body)).ReadAll()
+	require.NoError(t, err)
+	return records
+}
+
+func TestExportUserActivities__CSV(t *testing.T) {
+	backend, cleanup, err := SetupTestBackend()
+	require.NoError(t, err)
+	defer cleanup()
+
+	createUsersAndTasks(backend)
+	require.NoError(t, backend.CreateTask(entities.Task{UserId: 1, TaskName: "=SUM(A1:A2)"}))
+	router := setupExportRouter(backend, 1)
+
+	rr := doExportRequest(router, "/user-activities/1?format=csv", "")
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", rr.Header().Get("Content-Type"))
+	assert.Equal(t, `attachment; filename="user-1-activities.csv"`, rr.Header().Get("Content-Disposition"))
+
+	records := readCSV(t, rr)
+	require.Equal(t, 5, len(records))
+	assert.Equal(t, []string{
+		"task_id", "task_name", "project_id", "project_name", "hours", "minutes", "is_finished", "is_paused",
+	}, records[0])
+	assert.Equal(t, []string{"2", "task2", "", "", "51", "29", "true", "false"}, records[1])
+	assert.Equal(t, "'=SUM(A1:A2)", records[4][1])
+}
+
+func TestExportUserActivities__JSONLines(t *testing.T) {
+	backend, cleanup, err := SetupTestBackend()
+	require.NoError(t, err)
+	defer cleanup()
+
+	createUsersAndTasks(backend)
+	router := setupExportRouter(backend, 1)
+
+	rr := doExportRequest(router, "/user-activities/1", "text/html;q=0.9, application/x-ndjson")
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "application/x-ndjson", rr.Header().Get("Content-Type"))
+
+	tasks := []entities.UserActivityTask{}
+	scanner := bufio.NewScanner(rr.Body)
+	for scanner.Scan() {
+		var task entities.UserActivityTask
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &task))
+		tasks = append(tasks, task)
+	}
+	require.Equal(t, 3, len(tasks))
+	assert.Equal(t, "task2", tasks[0].TaskName)
+}
+
+func TestExportUserActivities__Negotiation(t *testing.T) {
+	backend, cleanup, err := SetupTestBackend()
+	require.NoError(t, err)
+	defer cleanup()
+
+	createUsersAndTasks(backend)
+	require.NoError(t, backend.CreateUsers(
+		entities.User{PassportSerie: "3434", PassportNumber: "454545", Surname: "Petrov", Name: "Petr"},
+	))
+	router := setupExportRouter(backend, 1)
+
+	rr := doExportRequest(router, "/user-activities/1", "*/*")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+
+	rr = doExportRequest(router, "/user-activities/1", "application/xml")
+	assert.Equal(t, http.StatusNotAcceptable, rr.Code)
+
+	rr = doExportRequest(router, "/user-activities/1?format=xml", "")
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	rr = doExportRequest(router, "/user-activities/1?format=csv&groupBy=project", "")
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	// Errors before the first row are still reported as problem documents.
+	rr = doExportRequest(setupExportRouter(backend, 2), "/user-activities/1?format=csv", "")
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	assert.Equal(t, "application/problem+json", rr.Header().Get("Content-Type"))
+}
+
+func TestExportTimesheet__CSV(t *testing.T) {
+	backend, cleanup, err := SetupTestBackend()
+	require.NoError(t, err)
+	defer cleanup()
+
+	createUsersWithTimesheetTasks(t, backend)
+	router := setupExportRouter(backend, 1)
+
+	rr := doExportRequest(router, "/timesheets/1?week=2024-W28&timezone=Europe/Moscow", "text/csv")
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	records := readCSV(t, rr)
+	assert.Equal(t, [][]string{
+		{"user_id", "task_id", "task_name",
+			"2024-07-08", "2024-07-09", "2024-07-10", "2024-07-11", "2024-07-12", "2024-07-13", "2024-07-14",
+			"total_minutes"},
+		{"1", "1", "task1", "0", "90", "90", "0", "0", "0", "0", "180"},
+		{"1", "2", "task2", "0", "0", "0", "0", "135", "0", "0", "135"},
+		{"1", "", "Total", "0", "90", "90", "0", "135", "0", "0", "315"},
+	}, records)
+}
+
+func TestExportTeamTimesheet__JSONLines(t *testing.T) {
+	backend, cleanup, err := SetupTestBackend()
+	require.NoError(t, err)
+	defer cleanup()
+
+	createUsersWithTimesheetTasks(t, backend)
+	require.NoError(t, backend.SetRole(2, entities.RoleAdmin, nil))
+	router := setupExportRouter(backend, 2)
+
+	rr := doExportRequest(router, "/timesheets?week=2024-W28&format=jsonl", "")
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	type line struct {
+		UserId       int            `json:"userId"`
+		TaskId       *int           `json:"taskId"`
+		TaskName     string         `json:"taskName"`
+		Minutes      map[string]int `json:"minutes"`
+		TotalMinutes int            `json:"totalMinutes"`
+	}
+	lines := []line{}
+	scanner := bufio.NewScanner(rr.Body)
+	for scanner.Scan() {
+		var l line
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &l))
+		lines = append(lines, l)
+	}
+
+	require.Equal(t, 4, len(lines))
+	assert.Equal(t, "task1", lines[0].TaskName)
+	assert.Equal(t, 180, lines[0].Minutes["2024-07-09"])
+	assert.Nil(t, lines[2].TaskId)
+	assert.Equal(t, 315, lines[2].TotalMinutes)
+	assert.Equal(t, 2, lines[3].UserId)
+	assert.Nil(t, lines[3].TaskId)
+	assert.Equal(t, 0, lines[3].TotalMinutes)
+}
+
+func TestExportTeamTimesheet__DeletedUser(t *testing.T) {
+	backend, cleanup, err := SetupTestBackend()
+	require.NoError(t, err)
+	defer cleanup()
+
+	createUsersWithTimesheetTasks(t, backend)
+	require.NoError(t, backend.SetRole(2, entities.RoleAdmin, nil))
+	require.NoError(t, backend.CreateUsers(
+		entities.User{PassportSerie: "5656", PassportNumber: "676767", Surname: "Sidorov", Name: "Sidr"},
+	))
+	start_time, _ := time.Parse(time.DateTime, "2024-07-10 08:00:00")
+	end_time := start_time.Add(time.Hour)
+	require.NoError(t, backend.CreateTask(entities.Task{UserId: 3, TaskName: "task4", StartTime: start_time, EndTime: &end_time}))
+	require.NoError(t, backend.Repo.DeleteUser(context.Background(), 3))
+	router := setupExportRouter(backend, 2)
+
+	rr := doExportRequest(router, "/timesheets?week=2024-W28&format=csv", "")
+	require.Equal(t, http.StatusOK, rr.Code)
+	csv_user_ids := []string{}
+	for _, record := range readCSV(t, rr)[1:] {
+		csv_user_ids = append(csv_user_ids, record[0])
+	}
+	assert.Equal(t, []string{"1", "1", "1", "2"}, csv_user_ids)
+
+	rr = doExportRequest(router, "/timesheets?week=2024-W28", "application/json")
+	require.Equal(t, http.StatusOK, rr.Code)
+	var team_timesheet entities.TeamTimesheet
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&team_timesheet))
+	json_user_ids := []int{}
+	for _, timesheet := range team_timesheet.Timesheets {
+		json_user_ids = append(json_user_ids, timesheet.UserId)
+	}
+	assert.Equal(t, []int{1, 2}, json_user_ids)
+}
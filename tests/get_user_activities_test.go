@@ -0,0 +1,80 @@
+package tests
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"task_tracker/src/api"
+	"task_tracker/src/entities"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stretchr/testify/require"
+)
+
+func createUsersAndTasks(backend *TestBackend) {
+	err := backend.CreateUsers(
+		entities.User{PassportSerie: "1212", PassportNumber: "232323", Surname: "Ivanov", Name: "Ivan"},
+	)
+	if err != nil {
+		log.Fatalf("Failed to create user: %v\n", err)
+	}
+
+	tasks := []struct {
+		name       string
+		start_time string
+		end_time   string
+	}{
+		{"task1", "2024-07-09 11:50:07", "2024-07-09 19:24:07"},
+		{"task2", "2024-07-06 10:55:07", "2024-07-08 14:24:07"},
+		{"task3", "2024-07-10 11:50:07", "2024-07-11 15:24:07"},
+	}
+	for _, task := range tasks {
+		start_time, _ := time.Parse(time.DateTime, task.start_time)
+		end_time, _ := time.Parse(time.DateTime, task.end_time)
+		err = backend.CreateTask(entities.Task{
+			UserId:    1,
+			TaskName:  task.name,
+			StartTime: start_time,
+			EndTime:   &end_time,
+		})
+		if err != nil {
+			log.Fatalf("Failed to create task: %v\n", err)
+		}
+	}
+}
+
+func TestGetUserActivitiesHandler__OK(t *testing.T) {
+	backend, cleanup, err := SetupTestBackend()
+	require.NoError(t, err)
+	defer cleanup()
+
+	router := NewTestRouter(1)
+	api.InitUserRoutes(router, backend.Repo, nil, NewTestConfig().Pagination)
+
+	createUsersAndTasks(backend)
+
+	req, err := http.NewRequest("GET", "/user-activities/1", nil)
+	require.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var response entities.UserActivityResponse
+	err = json.NewDecoder(rr.Body).Decode(&response)
+	require.NoError(t, err)
+
+	require.Equal(t, 3, len(response.Tasks))
+	assert.Equal(t, "task2", response.Tasks[0].TaskName)
+	assert.Equal(t, "task3", response.Tasks[1].TaskName)
+	assert.Equal(t, "task1", response.Tasks[2].TaskName)
+	assert.Equal(t, 7, response.Tasks[2].Hours)
+	assert.Equal(t, 34, response.Tasks[2].Minutes)
+	assert.True(t, response.Tasks[2].IsFinished)
+}
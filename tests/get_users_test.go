@@ -1,63 +1,51 @@
 package tests
 
 import (
-	"context"
 	"encoding/json"
 	"log"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"task_tracker/src/api"
 	"task_tracker/src/entities"
 	"testing"
+	"time"
 
+	"github.com/gorilla/mux"
 	"github.com/stretchr/testify/assert"
 
-	"github.com/gorilla/mux"
-	"github.com/jackc/pgx/v4/pgxpool"
 	"github.com/stretchr/testify/require"
 )
 
-func createUsers(pool *pgxpool.Pool) {
-	queries := []string{
-		`INSERT INTO users (user_id, passport_serie, passport_number, surname, name) 
-			VALUES (1, 1212, 232323, 'Ivanov', 'Ivan');`,
-		`INSERT INTO users (user_id, passport_serie, passport_number, surname, name) 
-			VALUES (2, 3434, 454545, 'Petrov', 'Petr');`,
-		`INSERT INTO users (user_id, passport_serie, passport_number, surname, name) 
-			VALUES (3, 5656, 676767, 'Sidorov', 'Sidr');`,
-		`INSERT INTO users (user_id, passport_serie, passport_number, surname, name) 
-			VALUES (4, 7878, 898989, 'Smirnov', 'Sergey');`,
-		`INSERT INTO users (user_id, passport_serie, passport_number, surname, name) 
-			VALUES (5, 9090, 111111, 'Kuznetsov', 'Alexey');`,
-		`INSERT INTO users (user_id, passport_serie, passport_number, surname, name) 
-			VALUES (6, 1213, 121212, 'Popov', 'Dmitry');`,
-		`INSERT INTO users (user_id, passport_serie, passport_number, surname, name) 
-			VALUES (7, 1414, 232424, 'Vasilev', 'Vladimir');`,
-		`INSERT INTO users (user_id, passport_serie, passport_number, surname, name) 
-			VALUES (8, 1616, 343535, 'Mikhailov', 'Mikhail');`,
-		`INSERT INTO users (user_id, passport_serie, passport_number, surname, name) 
-			VALUES (9, 1818, 454646, 'Fedorov', 'Fedor');`,
+func createUsers(backend *TestBackend) {
+	users := []entities.User{
+		{PassportSerie: "1212", PassportNumber: "232323", Surname: "Ivanov", Name: "Ivan"},
+		{PassportSerie: "3434", PassportNumber: "454545", Surname: "Petrov", Name: "Petr"},
+		{PassportSerie: "5656", PassportNumber: "676767", Surname: "Sidorov", Name: "Sidr"},
+		{PassportSerie: "7878", PassportNumber: "898989", Surname: "Smirnov", Name: "Sergey"},
+		{PassportSerie: "9090", PassportNumber: "111111", Surname: "Kuznetsov", Name: "Alexey"},
+		{PassportSerie: "1213", PassportNumber: "121212", Surname: "Popov", Name: "Dmitry"},
+		{PassportSerie: "1414", PassportNumber: "232424", Surname: "Vasilev", Name: "Vladimir"},
+		{PassportSerie: "1616", PassportNumber: "343535", Surname: "Mikhailov", Name: "Mikhail"},
+		{PassportSerie: "1818", PassportNumber: "454646", Surname: "Fedorov", Name: "Fedor"},
 	}
 
-	for _, query := range queries {
-		_, err := pool.Exec(context.Background(), query)
-		if err != nil {
-			log.Fatalf("Failed to execute query: %v\n", err)
-		}
+	err := backend.CreateUsers(users...)
+	if err != nil {
+		log.Fatalf("Failed to create users: %v\n", err)
 	}
 }
 
 func TestGetUsersHandler__OK(t *testing.T) {
-	pool, cleanup, err := SetupTestDB()
+	backend, cleanup, err := SetupTestBackend()
 	require.NoError(t, err)
 	defer cleanup()
 
-	log := SetupLogger()
+	router := NewTestRouter(1)
+	api.InitUserRoutes(router, backend.Repo, nil, NewTestConfig().Pagination)
 
-	router := mux.NewRouter()
-	api.InitUserRoutes(router, pool, log)
-
-	createUsers(pool)
+	createUsers(backend)
+	require.NoError(t, backend.SetRole(1, entities.RoleAdmin, nil))
 
 	req, err := http.NewRequest("GET", "/users", nil)
 	require.NoError(t, err)
@@ -67,12 +55,108 @@ func TestGetUsersHandler__OK(t *testing.T) {
 	router.ServeHTTP(rr, req)
 
 	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"passportSerie":"1212","passportNumber":"232323","surname":"Ivanov"`)
 
 	var response entities.GetUsersResponse
 	err = json.NewDecoder(rr.Body).Decode(&response)
 	require.NoError(t, err)
 
 	assert.Equal(t, 5, len(response.Users))
-	assert.Equal(t, 1, response.Page)
-	assert.Equal(t, 2, response.LastPage)
+	assert.False(t, response.Users[0].CreatedAt.IsZero())
+	assert.NotEmpty(t, response.Next)
+	assert.Empty(t, response.Prev)
+}
+
+func getUsersPage(t *testing.T, router *mux.Router, query string) entities.GetUsersResponse {
+	rr := doJSONRequest(router, "GET", "/users?"+query, nil, "")
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	var response entities.GetUsersResponse
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&response))
+	return response
+}
+
+func userSurnames(users []entities.UserListItem) []string {
+	surnames := []string{}
+	for _, user := range users {
+		surnames = append(surnames, user.Surname)
+	}
+	return surnames
+}
+
+func TestGetUsersHandler__Cursor(t *testing.T) {
+	backend, cleanup, err := SetupTestBackend()
+	require.NoError(t, err)
+	defer cleanup()
+
+	router := NewTestRouter(1)
+	api.InitUserRoutes(router, backend.Repo, nil, NewTestConfig().Pagination)
+
+	createUsers(backend)
+	require.NoError(t, backend.SetRole(1, entities.RoleAdmin, nil))
+
+	first := getUsersPage(t, router, "sort=-surname&limit=4")
+	assert.Equal(t, []string{"Vasilev", "Smirnov", "Sidorov", "Popov"}, userSurnames(first.Users))
+	assert.Empty(t, first.Prev)
+
+	// Deleting a user seen on an earlier page does not shift the next one.
+	require.Equal(t, http.StatusNoContent, doJSONRequest(router, "DELETE", "/users/3", nil, "").Code)
+
+	second := getUsersPage(t, router, "limit=4&cursor="+url.QueryEscape(first.Next))
+	assert.Equal(t, []string{"Petrov", "Mikhailov", "Kuznetsov", "Ivanov"}, userSurnames(second.Users))
+	assert.NotEmpty(t, second.Prev)
+
+	last := getUsersPage(t, router, "limit=4&cursor="+url.QueryEscape(second.Next))
+	assert.Equal(t, []string{"Fedorov"}, userSurnames(last.Users))
+	assert.Empty(t, last.Next)
+
+	back := getUsersPage(t, router, "limit=4&cursor="+url.QueryEscape(last.Prev))
+	assert.Equal(t, userSurnames(second.Users), userSurnames(back.Users))
+	back = getUsersPage(t, router, "limit=4&cursor="+url.QueryEscape(back.Prev))
+	assert.Equal(t, []string{"Vasilev", "Smirnov", "Popov"}, userSurnames(back.Users))
+	assert.Empty(t, back.Prev)
+	assert.NotEmpty(t, back.Next)
+}
+
+func TestGetUsersHandler__Filters(t *testing.T) {
+	backend, cleanup, err := SetupTestBackend()
+	require.NoError(t, err)
+	defer cleanup()
+
+	router := NewTestRouter(1)
+	api.InitUserRoutes(router, backend.Repo, nil, NewTestConfig().Pagination)
+
+	createUsers(backend)
+	require.NoError(t, backend.SetRole(1, entities.RoleAdmin, nil))
+
+	response := getUsersPage(t, router, "surname=S&sort=name")
+	assert.Equal(t, []string{"Smirnov", "Sidorov"}, userSurnames(response.Users))
+	assert.Empty(t, response.Next)
+
+	response = getUsersPage(t, router, "name=Mi&passportSerie=1616")
+	assert.Equal(t, []string{"Mikhailov"}, userSurnames(response.Users))
+	// Series are normalized like stored passports.
+	response = getUsersPage(t, router, "passportSerie="+url.QueryEscape(" 16 16 "))
+	assert.Equal(t, []string{"Mikhailov"}, userSurnames(response.Users))
+	response = getUsersPage(t, router, "surname=%25")
+	assert.Empty(t, response.Users)
+
+	tomorrow := url.QueryEscape(time.Now().Add(24 * time.Hour).Format("2006-01-02 15:04"))
+	response = getUsersPage(t, router, "limit=20&sort=createdAt&createdTo="+tomorrow)
+	assert.Equal(t, 9, len(response.Users))
+	response = getUsersPage(t, router, "createdFrom="+tomorrow)
+	assert.Empty(t, response.Users)
+
+	for _, query := range []string{
+		"limit=0",
+		"limit=101",
+		"sort=patronymic",
+		"passportSerie=16a6",
+		"passportSerie=161",
+		"cursor=abc",
+		"surname=S&cursor=" + url.QueryEscape(getUsersPage(t, router, "limit=1").Next),
+	} {
+		rr := doJSONRequest(router, "GET", "/users?"+query, nil, "")
+		assert.Equal(t, http.StatusBadRequest, rr.Code, query)
+	}
 }
@@ -0,0 +1,99 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"task_tracker/src/api"
+	"task_tracker/src/auth"
+	"task_tracker/src/entities"
+	"task_tracker/src/errors/repo_errors"
+	"task_tracker/src/repository"
+	"task_tracker/src/services"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type outdatedSchemaRepository struct{}
+
+func (r outdatedSchemaRepository) Ping(ctx context.Context) error {
+	return nil
+}
+
+func (r outdatedSchemaRepository) CheckSchemaVersion(ctx context.Context) error {
+	return repo_errors.SchemaVersionError{Current: 5, Expected: 7}
+}
+
+// setupHealthRouter uses the real auth middleware, the probes must work
+// without a token.
+func setupHealthRouter(repo repository.HealthRepository, people_info_pinger services.Pinger) *mux.Router {
+	token_manager := auth.NewTokenManager(auth.Config{
+		Secret:          []byte("test-secret"),
+		AccessTokenTTL:  time.Minute,
+		RefreshTokenTTL: time.Hour,
+	})
+	router := mux.NewRouter()
+	router.Use(api.AuthMiddleware(token_manager))
+	api.InitHealthRoutes(router, repo, people_info_pinger)
+	return router
+}
+
+func getHealthReport(t *testing.T, router *mux.Router) (int, entities.HealthReport) {
+	rr := doJSONRequest(router, "GET", "/readyz", nil, "")
+	var report entities.HealthReport
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&report))
+	return rr.Code, report
+}
+
+func TestHealthHandlers__Ready(t *testing.T) {
+	backend, cleanup, err := SetupTestBackend()
+	require.NoError(t, err)
+	defer cleanup()
+
+	router := setupHealthRouter(backend.Repo, nil)
+
+	rr := doJSONRequest(router, "GET", "/healthz", nil, "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"status":"ok"}`, rr.Body.String())
+
+	code, report := getHealthReport(t, router)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, entities.HealthStatusOk, report.Status)
+	require.Equal(t, 2, len(report.Checks))
+	assert.Equal(t, "postgres", report.Checks[0].Name)
+	assert.Equal(t, "migrations", report.Checks[1].Name)
+	assert.True(t, report.Checks[1].Critical)
+}
+
+func TestHealthHandlers__PeopleInfoDownIsDegraded(t *testing.T) {
+	backend, cleanup, err := SetupTestBackend()
+	require.NoError(t, err)
+	defer cleanup()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	code, report := getHealthReport(t, setupHealthRouter(backend.Repo, setupPeopleInfoClient(server)))
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, entities.HealthStatusDegraded, report.Status)
+	require.Equal(t, 3, len(report.Checks))
+	assert.Equal(t, "people_info", report.Checks[2].Name)
+	assert.Equal(t, entities.HealthStatusFailing, report.Checks[2].Status)
+	assert.False(t, report.Checks[2].Critical)
+	assert.Contains(t, report.Checks[2].Error, "503")
+}
+
+func TestHealthHandlers__OutdatedSchemaIsNotReady(t *testing.T) {
+	code, report := getHealthReport(t, setupHealthRouter(outdatedSchemaRepository{}, nil))
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, entities.HealthStatusFailing, report.Status)
+	assert.Equal(t, entities.HealthStatusOk, report.Checks[0].Status)
+	assert.Equal(t, "Schema version is 5, expected 7", report.Checks[1].Error)
+}
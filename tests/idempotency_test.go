@@ -0,0 +1,142 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"task_tracker/src/api"
+	"task_tracker/src/entities"
+	"task_tracker/src/services"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newIdempotencyTestRouter(backend *TestBackend) *mux.Router {
+	router := NewTestRouter(1)
+	router.Use(api.IdempotencyMiddleware(backend.Repo, time.Hour))
+	api.InitTaskRoutes(router, backend.Repo)
+	return router
+}
+
+func TestIdempotency__CreateTask(t *testing.T) {
+	backend, cleanup, err := SetupTestBackend()
+	require.NoError(t, err)
+	defer cleanup()
+
+	err = backend.CreateUsers(
+		entities.User{PassportSerie: "1212", PassportNumber: "232323", Surname: "Ivanov", Name: "Ivan"},
+	)
+	require.NoError(t, err)
+	router := newIdempotencyTestRouter(backend)
+
+	body := `{"taskName": "task1", "userId": 1}`
+	first := doConditionalRequest(router, "POST", "/tasks", body, "Idempotency-Key", "key-1")
+	require.Equal(t, http.StatusOK, first.Code)
+	assert.Empty(t, first.Header().Get("Idempotent-Replayed"))
+
+	retry := doConditionalRequest(router, "POST", "/tasks", body, "Idempotency-Key", "key-1")
+	require.Equal(t, http.StatusOK, retry.Code)
+	assert.Equal(t, "true", retry.Header().Get("Idempotent-Replayed"))
+	assert.Equal(t, first.Header().Get("ETag"), retry.Header().Get("ETag"))
+	assert.Equal(t, first.Header().Get("Content-Type"), retry.Header().Get("Content-Type"))
+	assert.Equal(t, first.Body.String(), retry.Body.String())
+
+	rr := doConditionalRequest(router, "POST", "/tasks", `{"taskName": "task2", "userId": 1}`, "Idempotency-Key", "key-1")
+	require.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+	problem := decodeProblem(t, rr)
+	assert.Equal(t, "unprocessable_entity", problem.Code)
+
+	rr = doConditionalRequest(router, "POST", "/tasks", body, "Idempotency-Key", "key-2")
+	require.Equal(t, http.StatusOK, rr.Code)
+	rr = doConditionalRequest(router, "POST", "/tasks", body, "", "")
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	user_id := 1
+	tasks, err := backend.Repo.GetTasks(context.Background(), entities.TasksFilter{UserId: &user_id})
+	require.NoError(t, err)
+	assert.Equal(t, 3, len(tasks))
+
+	rr = doConditionalRequest(router, "POST", "/tasks", body, "Idempotency-Key", "bad\tkey")
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestIdempotency__ServerErrorNotStored(t *testing.T) {
+	backend, cleanup, err := SetupTestBackend()
+	require.NoError(t, err)
+	defer cleanup()
+
+	calls := 0
+	router := NewTestRouter(1)
+	router.Use(api.IdempotencyMiddleware(backend.Repo, time.Hour))
+	router.HandleFunc("/tasks", func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}).Methods("POST")
+
+	rr := doConditionalRequest(router, "POST", "/tasks", `{}`, "Idempotency-Key", "key-1")
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+	rr = doConditionalRequest(router, "POST", "/tasks", `{}`, "Idempotency-Key", "key-1")
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	rr = doConditionalRequest(router, "POST", "/tasks", `{}`, "Idempotency-Key", "key-1")
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	assert.Equal(t, 2, calls)
+}
+
+func TestIdempotency__Expired(t *testing.T) {
+	backend, cleanup, err := SetupTestBackend()
+	require.NoError(t, err)
+	defer cleanup()
+
+	router := NewTestRouter(1)
+	router.Use(api.IdempotencyMiddleware(backend.Repo, time.Hour))
+	router.HandleFunc("/tasks", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	}).Methods("POST")
+
+	rr := doConditionalRequest(router, "POST", "/tasks", `{"taskName": "a"}`, "Idempotency-Key", "key-1")
+	require.Equal(t, http.StatusCreated, rr.Code)
+
+	ctx := context.Background()
+	purged, err := services.PurgeExpiredIdempotencyKeys(ctx, backend.Repo, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 0, purged)
+	purged, err = services.PurgeExpiredIdempotencyKeys(ctx, backend.Repo, time.Now().Add(2*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 1, purged)
+
+	rr = doConditionalRequest(router, "POST", "/tasks", `{"taskName": "b"}`, "Idempotency-Key", "key-1")
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	assert.Empty(t, rr.Header().Get("Idempotent-Replayed"))
+}
+
+func TestIdempotency__Anonymous(t *testing.T) {
+	backend, cleanup, err := SetupTestBackend()
+	require.NoError(t, err)
+	defer cleanup()
+
+	calls := 0
+	router := mux.NewRouter()
+	router.Use(api.IdempotencyMiddleware(backend.Repo, time.Hour))
+	router.HandleFunc("/users", func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusCreated)
+	}).Methods("POST")
+
+	// Another anonymous client must not get this response replayed.
+	for i := 0; i < 2; i++ {
+		rr := doConditionalRequest(router, "POST", "/users", `{"passportNumber": "1234 567890"}`, "Idempotency-Key", "key-1")
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Equal(t, "validation_failed", decodeProblem(t, rr).Code)
+	}
+	assert.Equal(t, 0, calls)
+
+	rr := doConditionalRequest(router, "POST", "/users", `{"passportNumber": "1234 567890"}`, "", "")
+	assert.Equal(t, http.StatusCreated, rr.Code)
+}
@@ -0,0 +1,106 @@
+package tests
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"task_tracker/src/api"
+	"task_tracker/src/auth"
+	"task_tracker/src/utils"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupLoggingRouter(t *testing.T) (*mux.Router, *bytes.Buffer, string) {
+	var output bytes.Buffer
+	log := logrus.New()
+	log.SetFormatter(&logrus.JSONFormatter{})
+	log.SetOutput(&output)
+
+	token_manager := auth.NewTokenManager(auth.Config{
+		Secret:          []byte("test-secret"),
+		AccessTokenTTL:  time.Minute,
+		RefreshTokenTTL: time.Hour,
+	})
+	access_token, err := token_manager.NewAccessToken(7)
+	require.NoError(t, err)
+
+	router := mux.NewRouter()
+	router.Use(api.RequestLoggingMiddleware(log))
+	router.Use(api.AuthMiddleware(token_manager))
+	router.HandleFunc("/items/{itemId}", func(w http.ResponseWriter, r *http.Request) {
+		// Stands in for a repository logging an error with the request logger.
+		utils.LoggerFromContext(r.Context(), log).Error("Error loading item")
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte("failed"))
+	}).Methods("GET")
+	return router, &output, access_token
+}
+
+func readLogLines(t *testing.T, output *bytes.Buffer) []map[string]interface{} {
+	lines := []map[string]interface{}{}
+	scanner := bufio.NewScanner(output)
+	for scanner.Scan() {
+		var line map[string]interface{}
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &line))
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func TestRequestLogging__ContextFieldsAndAccessLog(t *testing.T) {
+	router, output, access_token := setupLoggingRouter(t)
+
+	req := httptest.NewRequest("GET", "/items/3", nil)
+	req.Header.Set("Authorization", "Bearer "+access_token)
+	req.Header.Set("X-Request-ID", "req-42")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, "req-42", rr.Header().Get("X-Request-ID"))
+
+	lines := readLogLines(t, output)
+	require.Equal(t, 2, len(lines))
+
+	error_line := lines[0]
+	assert.Equal(t, "Error loading item", error_line["msg"])
+	assert.Equal(t, "req-42", error_line["request_id"])
+	assert.Equal(t, "/items/{itemId}", error_line["route"])
+	assert.Equal(t, 7.0, error_line["user_id"])
+
+	access_line := lines[1]
+	assert.Equal(t, "Request completed", access_line["msg"])
+	assert.Equal(t, "req-42", access_line["request_id"])
+	assert.Equal(t, 7.0, access_line["user_id"])
+	assert.Equal(t, "GET", access_line["method"])
+	assert.Equal(t, "/items/3", access_line["path"])
+	assert.Equal(t, 500.0, access_line["status"])
+	assert.Equal(t, 6.0, access_line["bytes"])
+	assert.Contains(t, access_line, "duration_ms")
+}
+
+func TestRequestLogging__GeneratesRequestId(t *testing.T) {
+	router, output, _ := setupLoggingRouter(t)
+
+	req := httptest.NewRequest("GET", "/items/3", nil)
+	req.Header.Set("X-Request-ID", "bad id\nwith newline")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	request_id := rr.Header().Get("X-Request-ID")
+	assert.Regexp(t, "^[0-9a-f]{32}$", request_id)
+
+	lines := readLogLines(t, output)
+	require.Equal(t, 1, len(lines))
+	assert.Equal(t, request_id, lines[0]["request_id"])
+	assert.NotContains(t, lines[0], "user_id")
+	assert.Equal(t, 401.0, lines[0]["status"])
+}
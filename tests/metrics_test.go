@@ -0,0 +1,51 @@
+package tests
+
+import (
+	"net/http"
+	"task_tracker/src/api"
+	"task_tracker/src/entities"
+	"task_tracker/src/metrics"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetrics__RequestsAndDomainCounters(t *testing.T) {
+	backend, cleanup, err := SetupTestBackend()
+	require.NoError(t, err)
+	defer cleanup()
+
+	createUserWithRunningTask(backend)
+	router := NewTestRouter(1)
+	router.Use(api.MetricsMiddleware)
+	api.InitMetricsRoutes(router)
+	api.InitTaskRoutes(router, backend.Repo)
+
+	// Counters are global, so only deltas are checked.
+	get_task_ok := metrics.HTTPRequests.WithLabelValues("GET", "/tasks/{taskId}", "200")
+	get_task_not_found := metrics.HTTPRequests.WithLabelValues("GET", "/tasks/{taskId}", "404")
+	get_task_ok_before := testutil.ToFloat64(get_task_ok)
+	get_task_not_found_before := testutil.ToFloat64(get_task_not_found)
+	tasks_created_before := testutil.ToFloat64(metrics.TasksCreated)
+
+	rr := doJSONRequest(router, "GET", "/tasks/1", nil, "")
+	require.Equal(t, http.StatusOK, rr.Code)
+	rr = doJSONRequest(router, "GET", "/tasks/42", nil, "")
+	require.Equal(t, http.StatusNotFound, rr.Code)
+	rr = doJSONRequest(router, "POST", "/tasks", entities.CreateTaskRequest{TaskName: "task2"}, "")
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(get_task_ok)-get_task_ok_before)
+	assert.Equal(t, 1.0, testutil.ToFloat64(get_task_not_found)-get_task_not_found_before)
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.TasksCreated)-tasks_created_before)
+
+	rr = doJSONRequest(router, "GET", "/metrics", nil, "")
+	require.Equal(t, http.StatusOK, rr.Code)
+	body := rr.Body.String()
+	assert.Contains(t, body, `task_tracker_http_requests_total{code="404",method="GET",route="/tasks/{taskId}"}`)
+	assert.Contains(t, body, `task_tracker_http_request_duration_seconds_bucket{method="POST",route="/tasks"`)
+	assert.Contains(t, body, "task_tracker_tasks_created_total")
+	assert.NotContains(t, body, `route="/tasks/42"`)
+}
@@ -0,0 +1,49 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"task_tracker/src/errors/repo_errors"
+	"task_tracker/src/repository"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrations__UpAndDown(t *testing.T) {
+	if GetTestBackendName() != PostgresBackend {
+		t.Skip("migrations require TEST_BACKEND=postgres")
+	}
+
+	pool, cleanup, err := SetupTestDB()
+	require.NoError(t, err)
+	defer cleanup()
+
+	log := SetupLogger()
+	ctx := context.Background()
+
+	err = repository.CheckSchemaVersion(ctx, pool, log)
+	require.NoError(t, err)
+
+	err = repository.MigrateDown(ctx, pool, log, 1)
+	require.NoError(t, err)
+
+	version, err := repository.GetSchemaVersion(ctx, pool, log)
+	require.NoError(t, err)
+	assert.Equal(t, repository.ExpectedSchemaVersion()-1, version)
+
+	err = repository.CheckSchemaVersion(ctx, pool, log)
+	var version_err repo_errors.SchemaVersionError
+	assert.True(t, errors.As(err, &version_err))
+
+	err = repository.MigrateUp(ctx, pool, log)
+	require.NoError(t, err)
+
+	migrations, err := repository.GetMigrations(ctx, pool, log)
+	require.NoError(t, err)
+	assert.Equal(t, repository.ExpectedSchemaVersion(), len(migrations))
+	for _, migration := range migrations {
+		assert.NotNil(t, migration.AppliedAt)
+	}
+}
@@ -0,0 +1,67 @@
+package tests
+
+import (
+	"task_tracker/src/config"
+	"task_tracker/src/passport"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPassport__Parse(t *testing.T) {
+	test_cases := []struct {
+		value  string
+		serie  string
+		number string
+		err    string
+	}{
+		{value: "1234 567890", serie: "1234", number: "567890"},
+		{value: "0012 000345", serie: "0012", number: "000345"},
+		{value: "12 34 567890", serie: "1234", number: "567890"},
+		{value: " 1234-567890 ", serie: "1234", number: "567890"},
+		{value: "1234567890", serie: "1234", number: "567890"},
+		{value: "", err: "must not be empty"},
+		{value: "-1234 567890", err: "must start and end with a digit"},
+		{value: "1234 56789O", err: `must contain only digits, spaces and dashes, got 'O'`},
+		{value: "12 567890", err: "serie must have 4 digits, got 2"},
+		{value: "1234 5678901", err: "number must have 6 digits, got 7"},
+		{value: "12345678", err: "must have 10 digits when written without separators, got 8"},
+	}
+	for _, test_case := range test_cases {
+		serie, number, err := passport.Parse(test_case.value)
+		if test_case.err != "" {
+			assert.EqualError(t, err, test_case.err, test_case.value)
+			continue
+		}
+		assert.NoError(t, err, test_case.value)
+		assert.Equal(t, test_case.serie, serie, test_case.value)
+		assert.Equal(t, test_case.number, number, test_case.value)
+	}
+}
+
+func TestPassport__ConfiguredFormat(t *testing.T) {
+	passport.SetFormat(config.PassportConfig{SerieDigits: 2, NumberDigits: 7})
+	defer passport.SetFormat(config.Default().Passport)
+
+	_, _, err := passport.Parse("AB")
+	assert.Error(t, err)
+
+	serie, number, err := passport.Parse("071234567")
+	assert.NoError(t, err)
+	assert.Equal(t, "07", serie)
+	assert.Equal(t, "1234567", number)
+
+	_, _, err = passport.Parse("1234 567890")
+	assert.EqualError(t, err, "serie must have 2 digits, got 4")
+}
+
+func TestPassport__ParseSerie(t *testing.T) {
+	serie, err := passport.ParseSerie(" 00-12 ")
+	assert.NoError(t, err)
+	assert.Equal(t, "0012", serie)
+
+	_, err = passport.ParseSerie("123")
+	assert.EqualError(t, err, "serie must have 4 digits, got 3")
+	_, err = passport.ParseSerie("12a4")
+	assert.EqualError(t, err, `must contain only digits, spaces and dashes, got 'a'`)
+}
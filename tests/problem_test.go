@@ -0,0 +1,68 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"task_tracker/src/api"
+	"task_tracker/src/entities"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func decodeProblem(t *testing.T, rr *httptest.ResponseRecorder) entities.ProblemResponse {
+	assert.Equal(t, "application/problem+json", rr.Header().Get("Content-Type"))
+
+	var problem entities.ProblemResponse
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&problem))
+	assert.Equal(t, rr.Code, problem.Status)
+	return problem
+}
+
+func TestProblems__FinishTask(t *testing.T) {
+	backend, cleanup, err := SetupTestBackend()
+	require.NoError(t, err)
+	defer cleanup()
+
+	createUserWithRunningTask(backend)
+	router := NewTestRouter(1)
+	api.InitTaskRoutes(router, backend.Repo)
+
+	rr := doJSONRequest(router, "POST", "/tasks/finish", entities.FinishTaskRequest{TaskId: 1}, "")
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	rr = doJSONRequest(router, "POST", "/tasks/finish", entities.FinishTaskRequest{TaskId: 1}, "")
+	require.Equal(t, http.StatusConflict, rr.Code)
+	problem := decodeProblem(t, rr)
+	assert.Equal(t, "conflict", problem.Code)
+	assert.Equal(t, "Conflict", problem.Title)
+	assert.Equal(t, "Task with id=1 is already finished", problem.Detail)
+
+	rr = doJSONRequest(router, "POST", "/tasks/finish", entities.FinishTaskRequest{TaskId: 12}, "")
+	require.Equal(t, http.StatusNotFound, rr.Code)
+	problem = decodeProblem(t, rr)
+	assert.Equal(t, "not_found", problem.Code)
+}
+
+func TestProblems__InvalidParams(t *testing.T) {
+	backend, cleanup, err := SetupTestBackend()
+	require.NoError(t, err)
+	defer cleanup()
+
+	router := NewTestRouter(1)
+	api.InitTaskRoutes(router, backend.Repo)
+
+	req := httptest.NewRequest("POST", "/tasks", strings.NewReader(`{"taskName": 5}`))
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusBadRequest, rr.Code)
+	problem := decodeProblem(t, rr)
+	assert.Equal(t, "validation_failed", problem.Code)
+	assert.Equal(t, "about:blank", problem.Type)
+	require.Equal(t, 1, len(problem.InvalidParams))
+	assert.Equal(t, "taskName", problem.InvalidParams[0].Name)
+}
@@ -0,0 +1,180 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"task_tracker/src/api"
+	"task_tracker/src/entities"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupProjectRouter(backend *TestBackend, user_id int) *mux.Router {
+	router := NewTestRouter(user_id)
+	api.InitProjectRoutes(router, backend.Repo)
+	api.InitTaskRoutes(router, backend.Repo)
+	api.InitUserRoutes(router, backend.Repo, nil, NewTestConfig().Pagination)
+	return router
+}
+
+func TestProjectHandlers__CRUD(t *testing.T) {
+	backend, cleanup, err := SetupTestBackend()
+	require.NoError(t, err)
+	defer cleanup()
+
+	createUserWithRunningTask(backend)
+	require.NoError(t, backend.SetRole(1, entities.RoleManager, nil))
+	router := setupProjectRouter(backend, 1)
+
+	rr := doJSONRequest(router, "POST", "/projects", entities.CreateProjectRequest{ProjectName: "Acme"}, "")
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var project entities.Project
+	err = json.NewDecoder(rr.Body).Decode(&project)
+	require.NoError(t, err)
+	assert.Equal(t, 1, project.ProjectId)
+	assert.Equal(t, "Acme", project.ProjectName)
+
+	rr = doJSONRequest(router, "POST", "/projects", entities.CreateProjectRequest{ProjectName: "Acme"}, "")
+	assert.Equal(t, http.StatusConflict, rr.Code)
+
+	project_name := "Acme Corp"
+	rr = doJSONRequest(router, "PATCH", "/projects/1", entities.UpdateProjectRequest{ProjectName: &project_name}, "")
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	rr = doJSONRequest(router, "GET", "/projects", nil, "")
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var response entities.GetProjectsResponse
+	err = json.NewDecoder(rr.Body).Decode(&response)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(response.Projects))
+	assert.Equal(t, "Acme Corp", response.Projects[0].ProjectName)
+
+	rr = doJSONRequest(router, "DELETE", "/projects/1", nil, "")
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+
+	rr = doJSONRequest(router, "GET", "/projects/1", nil, "")
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestProjectHandlers__MemberCannotManageProjects(t *testing.T) {
+	backend, cleanup, err := SetupTestBackend()
+	require.NoError(t, err)
+	defer cleanup()
+
+	createUserWithRunningTask(backend)
+	_, err = backend.Repo.CreateProject(context.Background(), entities.CreateProjectRequest{ProjectName: "Acme"})
+	require.NoError(t, err)
+	router := setupProjectRouter(backend, 1)
+
+	rr := doJSONRequest(router, "POST", "/projects", entities.CreateProjectRequest{ProjectName: "Globex"}, "")
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+
+	rr = doJSONRequest(router, "DELETE", "/projects/1", nil, "")
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+
+	rr = doJSONRequest(router, "GET", "/projects/1", nil, "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestCreateTaskHandler__WithProject(t *testing.T) {
+	backend, cleanup, err := SetupTestBackend()
+	require.NoError(t, err)
+	defer cleanup()
+
+	createUserWithRunningTask(backend)
+	_, err = backend.Repo.CreateProject(context.Background(), entities.CreateProjectRequest{ProjectName: "Acme"})
+	require.NoError(t, err)
+	router := setupProjectRouter(backend, 1)
+
+	project_id := 1
+	rr := doJSONRequest(router, "POST", "/tasks", entities.CreateTaskRequest{TaskName: "task2", ProjectId: &project_id}, "")
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var response entities.CreateTaskResponse
+	err = json.NewDecoder(rr.Body).Decode(&response)
+	require.NoError(t, err)
+	require.NotNil(t, response.ProjectId)
+	assert.Equal(t, 1, *response.ProjectId)
+
+	rr = doJSONRequest(router, "GET", "/tasks?projectId=1", nil, "")
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var tasks entities.GetTasksResponse
+	err = json.NewDecoder(rr.Body).Decode(&tasks)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(tasks.Tasks))
+	assert.Equal(t, "task2", tasks.Tasks[0].TaskName)
+
+	unknown_project_id := 12
+	rr = doJSONRequest(router, "POST", "/tasks", entities.CreateTaskRequest{TaskName: "task3", ProjectId: &unknown_project_id}, "")
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestGetUserActivitiesHandler__GroupByProject(t *testing.T) {
+	backend, cleanup, err := SetupTestBackend()
+	require.NoError(t, err)
+	defer cleanup()
+
+	err = backend.CreateUsers(
+		entities.User{PassportSerie: "1212", PassportNumber: "232323", Surname: "Ivanov", Name: "Ivan"},
+	)
+	require.NoError(t, err)
+	_, err = backend.Repo.CreateProject(context.Background(), entities.CreateProjectRequest{ProjectName: "Acme"})
+	require.NoError(t, err)
+
+	project_id := 1
+	tasks := []struct {
+		name       string
+		project_id *int
+		start_time string
+		end_time   string
+	}{
+		{"task1", nil, "2024-07-09 11:50:07", "2024-07-09 19:24:07"},
+		{"task2", &project_id, "2024-07-06 10:55:07", "2024-07-08 14:24:07"},
+		{"task3", &project_id, "2024-07-10 11:50:07", "2024-07-11 15:24:07"},
+	}
+	for _, task := range tasks {
+		start_time, _ := time.Parse(time.DateTime, task.start_time)
+		end_time, _ := time.Parse(time.DateTime, task.end_time)
+		err = backend.CreateTask(entities.Task{
+			UserId:    1,
+			ProjectId: task.project_id,
+			TaskName:  task.name,
+			StartTime: start_time,
+			EndTime:   &end_time,
+		})
+		require.NoError(t, err)
+	}
+
+	router := setupProjectRouter(backend, 1)
+	rr := doJSONRequest(router, "GET", "/user-activities/1?groupBy=project", nil, "")
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var response entities.UserActivityResponse
+	err = json.NewDecoder(rr.Body).Decode(&response)
+	require.NoError(t, err)
+	assert.Equal(t, 3, len(response.Tasks))
+	require.Equal(t, 2, len(response.Projects))
+
+	acme := response.Projects[0]
+	require.NotNil(t, acme.ProjectId)
+	assert.Equal(t, "Acme", *acme.ProjectName)
+	assert.Equal(t, 79, acme.Hours)
+	assert.Equal(t, 3, acme.Minutes)
+	assert.Equal(t, 2, len(acme.Tasks))
+
+	no_project := response.Projects[1]
+	assert.Nil(t, no_project.ProjectId)
+	assert.Equal(t, 7, no_project.Hours)
+	assert.Equal(t, 34, no_project.Minutes)
+
+	rr = doJSONRequest(router, "GET", "/user-activities/1?groupBy=client", nil, "")
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
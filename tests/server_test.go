@@ -0,0 +1,136 @@
+package tests
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sync"
+	"task_tracker/src/config"
+	"task_tracker/src/server"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type shutdownRecorder struct {
+	mu    sync.Mutex
+	steps []string
+}
+
+func (r *shutdownRecorder) hook(name string) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.steps = append(r.steps, name)
+		return nil
+	}
+}
+
+func (r *shutdownRecorder) Steps() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string{}, r.steps...)
+}
+
+// startBlockingServer serves a handler that waits for release before
+// answering, and returns the server address and the channel Serve reports to.
+func startBlockingServer(
+	t *testing.T,
+	ctx context.Context,
+	shutdown_timeout time.Duration,
+	recorder *shutdownRecorder,
+	started chan<- struct{},
+	release <-chan struct{},
+) (string, <-chan error) {
+	server_config := config.Default().Server
+	server_config.ShutdownTimeout = shutdown_timeout
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-release
+		w.WriteHeader(http.StatusOK)
+	})
+
+	log := SetupLogger()
+	log.SetLevel(logrus.FatalLevel)
+	srv := server.New(server_config, handler, log)
+	srv.OnShutdown("workers", recorder.hook("workers"))
+	srv.OnShutdown("postgres pool", recorder.hook("postgres pool"))
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	serve_errors := make(chan error, 1)
+	go func() {
+		serve_errors <- srv.Serve(ctx, listener)
+	}()
+	return listener.Addr().String(), serve_errors
+}
+
+func TestServer__DrainsInFlightRequests(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	recorder := &shutdownRecorder{}
+	started := make(chan struct{})
+	release := make(chan struct{})
+	address, serve_errors := startBlockingServer(t, ctx, 5*time.Second, recorder, started, release)
+
+	response_codes := make(chan int, 1)
+	go func() {
+		resp, err := http.Get("http://" + address)
+		if err != nil {
+			response_codes <- 0
+			return
+		}
+		resp.Body.Close()
+		response_codes <- resp.StatusCode
+	}()
+	<-started
+
+	cancel()
+	require.Eventually(t, func() bool {
+		conn, err := net.Dial("tcp", address)
+		if err == nil {
+			conn.Close()
+		}
+		return err != nil
+	}, time.Second, 10*time.Millisecond, "server must stop accepting connections")
+	assert.Empty(t, recorder.Steps(), "hooks must wait for in-flight requests")
+
+	close(release)
+	assert.Equal(t, http.StatusOK, <-response_codes)
+	require.NoError(t, <-serve_errors)
+	assert.Equal(t, []string{"workers", "postgres pool"}, recorder.Steps())
+}
+
+func TestServer__DrainTimeout(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	recorder := &shutdownRecorder{}
+	started := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+	address, serve_errors := startBlockingServer(t, ctx, 50*time.Millisecond, recorder, started, release)
+
+	go func() {
+		resp, err := http.Get("http://" + address)
+		if err == nil {
+			resp.Body.Close()
+		}
+	}()
+	<-started
+
+	cancel()
+	select {
+	case err := <-serve_errors:
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	case <-time.After(5 * time.Second):
+		t.Fatal("server did not stop after the drain timeout")
+	}
+	assert.Equal(t, []string{"workers", "postgres pool"}, recorder.Steps())
+}
@@ -3,86 +3,232 @@ package tests
 import (
 	"context"
 	"fmt"
-	"log"
+	"net/http"
 	"os"
-	"path/filepath"
-
+	"task_tracker/src/auth"
+	"task_tracker/src/config"
+	"task_tracker/src/entities"
+	"task_tracker/src/errors/repo_errors"
+	"task_tracker/src/repository"
+	"time"
+
+	"github.com/gorilla/mux"
 	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/joho/godotenv"
 
 	"github.com/sirupsen/logrus"
 )
 
-func readDataFromFile(file string) (string, error) {
-	bytes, err := os.ReadFile(file)
+const (
+	MemoryBackend   = "memory"
+	PostgresBackend = "postgres"
+)
+
+type TestRepository interface {
+	repository.UserRepository
+	repository.TaskRepository
+	repository.AuthRepository
+	repository.ProjectRepository
+	repository.TimesheetRepository
+	repository.HealthRepository
+	repository.AuditRepository
+	repository.IdempotencyRepository
+}
+
+type TestBackend struct {
+	Repo     TestRepository
+	seedTask func(task entities.Task) error
+}
+
+func GetTestBackendName() string {
+	if backend := os.Getenv("TEST_BACKEND"); backend != "" {
+		return backend
+	}
+	return MemoryBackend
+}
+
+func SetupTestBackend() (*TestBackend, func(), error) {
+	switch GetTestBackendName() {
+	case MemoryBackend:
+		return setupMemoryBackend()
+	case PostgresBackend:
+		return setupPostgresBackend()
+	default:
+		return nil, nil, fmt.Errorf("unknown TEST_BACKEND %q", GetTestBackendName())
+	}
+}
+
+func setupMemoryBackend() (*TestBackend, func(), error) {
+	repo := repository.NewMemoryRepository()
+
+	seed_task := func(task entities.Task) error {
+		defer func() { repo.Now = time.Now }()
+
+		if !task.StartTime.IsZero() {
+			repo.Now = func() time.Time { return task.StartTime }
+		}
+		created_task, err := repo.CreateTask(
+			context.Background(),
+			entities.CreateTaskRequest{TaskName: task.TaskName, UserId: task.UserId, ProjectId: task.ProjectId},
+		)
+		if err != nil {
+			return err
+		}
+		if task.EndTime != nil {
+			repo.Now = func() time.Time { return *task.EndTime }
+			return repo.FinishTask(context.Background(), created_task.TaskId)
+		}
+		return nil
+	}
+
+	return &TestBackend{Repo: repo, seedTask: seed_task}, func() {}, nil
+}
+
+func setupPostgresBackend() (*TestBackend, func(), error) {
+	pool, cleanup, err := SetupTestDB()
 	if err != nil {
-		return "", err
+		return nil, nil, err
 	}
-	return string(bytes), nil
+
+	seed_task := func(task entities.Task) error {
+		start_time := task.StartTime
+		if start_time.IsZero() {
+			start_time = time.Now()
+		}
+		_, err := pool.Exec(
+			context.Background(),
+			`WITH created AS (
+				INSERT INTO tasks (user_id, task_name, project_id, start_time, end_time)
+				VALUES ($1, $2, $3, $4, $5)
+				RETURNING task_id, start_time, end_time
+			)
+			INSERT INTO task_intervals (task_id, start_time, end_time)
+			SELECT task_id, start_time, end_time FROM created`,
+			task.UserId, task.TaskName, task.ProjectId, start_time, task.EndTime,
+		)
+		return err
+	}
+
+	repo := repository.NewPostgresRepository(pool, SetupLogger())
+	return &TestBackend{Repo: repo, seedTask: seed_task}, cleanup, nil
 }
 
-func getDeleteSQL() (string, error) {
-	basePath, _ := os.Getwd()
-	baseDir := filepath.Dir(basePath)
-	schemaFilePath := filepath.Join(baseDir, "src", "repository", "drop_schema.sql")
-	return readDataFromFile(schemaFilePath)
+func (b *TestBackend) CreateUsers(users ...entities.User) error {
+	for _, user := range users {
+		if _, err := b.Repo.CreateUser(context.Background(), user); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-func getCreateSQL() (string, error) {
-	basePath, _ := os.Getwd()
-	baseDir := filepath.Dir(basePath)
-	schemaFilePath := filepath.Join(baseDir, "src", "repository", "schema.sql")
-	return readDataFromFile(schemaFilePath)
+// CreateTask stores a task with the given start and end time and a single
+// interval covering it. Task ids are assigned sequentially starting from 1.
+func (b *TestBackend) CreateTask(task entities.Task) error {
+	return b.seedTask(task)
 }
 
-func SetupTestDB() (*pgxpool.Pool, func(), error) {
-	PG_PORT_TEST := "5455"
-	PG_HOST_TEST := "localhost"
-	PG_DATABASE_TEST := "db_test"
-	PG_USER_TEST := "postgres"
-	PG_PASSWORD_TEST := "1234"
-
-	postgresUrl := fmt.Sprintf(
-		"postgres://%s:%s@%s:%s/%s",
-		PG_USER_TEST,
-		PG_PASSWORD_TEST,
-		PG_HOST_TEST,
-		PG_PORT_TEST,
-		PG_DATABASE_TEST,
+func (b *TestBackend) SetRole(user_id int, role string, manager_id *int) error {
+	return b.Repo.SetUserRole(
+		context.Background(),
+		entities.UserAccess{UserId: user_id, Role: role, ManagerId: manager_id},
+	)
+}
+
+// NewAdminTestRouter creates an admin, the only role allowed to create
+// users, and returns a router acting as them.
+func NewAdminTestRouter(backend *TestBackend) (*mux.Router, error) {
+	admin_id, err := backend.Repo.CreateUser(
+		context.Background(),
+		entities.User{PassportSerie: "9999", PassportNumber: "999999", Surname: "Admin", Name: "Admin"},
 	)
-	config, err := pgxpool.ParseConfig(postgresUrl)
 	if err != nil {
-		return nil, nil, fmt.Errorf("unable to parse database config: %s", err.Error())
+		return nil, err
 	}
+	if err = backend.SetRole(admin_id, entities.RoleAdmin, nil); err != nil {
+		return nil, err
+	}
+	return NewTestRouter(admin_id), nil
+}
 
-	pool, err := pgxpool.ConnectConfig(context.Background(), config)
+func (b *TestBackend) SetPassword(user_id int, password string) error {
+	password_hash, err := auth.HashPassword(password)
 	if err != nil {
-		return nil, nil, fmt.Errorf("unable to connect to database: %s", err.Error())
+		return err
 	}
+	return b.Repo.SetUserPassword(context.Background(), user_id, password_hash)
+}
 
-	delete_sql, err_d := getDeleteSQL()
-	create_sql, err_c := getCreateSQL()
+// NewTestRouter returns a router that treats every request as made by user_id,
+// standing in for api.AuthMiddleware.
+func NewTestRouter(user_id int) *mux.Router {
+	router := mux.NewRouter()
+	router.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(auth.WithUserId(r.Context(), user_id)))
+		})
+	})
+	return router
+}
+
+func (b *TestBackend) GetAllUsers() ([]entities.User, error) {
+	return b.Repo.GetUsers(context.Background(), entities.UsersQuery{Sort: entities.UsersSortId})
+}
 
-	if err_d != nil || err_c != nil {
-		var err error
-		if err_c != nil {
-			err = err_c
-		} else {
-			err = err_d
+func (b *TestBackend) GetUser(user_id int) (entities.User, error) {
+	users, err := b.GetAllUsers()
+	if err != nil {
+		return entities.User{}, err
+	}
+	for _, user := range users {
+		if user.Id == user_id {
+			return user, nil
 		}
-		return nil, nil, fmt.Errorf("unable to get sql commands: %s", err.Error())
 	}
+	return entities.User{}, repo_errors.ObjectNotFoundError{}
+}
+
+// NewTestConfig returns the defaults with a JWT secret set, so it passes
+// validation.
+func NewTestConfig() config.Config {
+	test_config := config.Default()
+	test_config.Auth.JWTSecret = "test-secret-0123456789abcdef012345"
+	return test_config
+}
+
+// SetupTestDB connects to the database described by the TEST_PG_* variables,
+// read from the environment or the .env file in the repository root.
+func SetupTestDB() (*pgxpool.Pool, func(), error) {
+	godotenv.Load("../.env")
+	postgres_config, err := config.PostgresFromEnv("TEST_PG_", config.Default().Postgres)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid test database config: %s", err.Error())
+	}
+
+	pool_config, err := pgxpool.ParseConfig(postgres_config.URL())
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to parse database config: %s", err.Error())
+	}
+
+	pool, err := pgxpool.ConnectConfig(context.Background(), pool_config)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to connect to database: %s", err.Error())
+	}
+
+	log := SetupLogger()
+	log.SetLevel(logrus.WarnLevel)
 
-	_, err_del := pool.Exec(context.Background(), delete_sql)
+	err_del := repository.MigrateDown(context.Background(), pool, log, repository.ExpectedSchemaVersion())
 	if err_del != nil {
 		return nil, nil, fmt.Errorf("unable to perform deleting tables: %s", err_del.Error())
 	}
-	_, err_create := pool.Exec(context.Background(), create_sql)
+	err_create := repository.MigrateUp(context.Background(), pool, log)
 	if err_create != nil {
 		return nil, nil, fmt.Errorf("unable to perform creation tables: %s", err_create.Error())
 	}
 
 	cleanup := func() {
-		_, err := pool.Exec(context.Background(), delete_sql)
+		err := repository.MigrateDown(context.Background(), pool, log, repository.ExpectedSchemaVersion())
 		if err != nil {
 			log.Printf("Failed to clean database: %s\n", err.Error())
 		}
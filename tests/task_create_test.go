@@ -0,0 +1,79 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"task_tracker/src/api"
+	"task_tracker/src/entities"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateTaskHandler__OK(t *testing.T) {
+	backend, cleanup, err := SetupTestBackend()
+	require.NoError(t, err)
+	defer cleanup()
+
+	router := NewTestRouter(1)
+	api.InitTaskRoutes(router, backend.Repo)
+
+	err = backend.CreateUsers(
+		entities.User{PassportSerie: "1212", PassportNumber: "232323", Surname: "Ivanov", Name: "Ivan"},
+	)
+	require.NoError(t, err)
+
+	body, _ := json.Marshal(entities.CreateTaskRequest{TaskName: "task1", UserId: 1})
+
+	req, err := http.NewRequest("POST", "/tasks", bytes.NewBuffer(body))
+	require.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var response entities.CreateTaskResponse
+	err = json.NewDecoder(rr.Body).Decode(&response)
+	require.NoError(t, err)
+	assert.Equal(t, 1, response.TaskId)
+	assert.Equal(t, "task1", response.TaskName)
+	assert.False(t, isTaskPaused(t, backend, response.TaskId))
+}
+
+func TestCreateTaskHandler__BadRequest__UserNotFound(t *testing.T) {
+	backend, cleanup, err := SetupTestBackend()
+	require.NoError(t, err)
+	defer cleanup()
+
+	router := NewTestRouter(1)
+	api.InitTaskRoutes(router, backend.Repo)
+
+	err = backend.CreateUsers(
+		entities.User{PassportSerie: "1212", PassportNumber: "232323", Surname: "Ivanov", Name: "Ivan"},
+	)
+	require.NoError(t, err)
+	require.NoError(t, backend.SetRole(1, entities.RoleAdmin, nil))
+
+	body, _ := json.Marshal(entities.CreateTaskRequest{TaskName: "task1", UserId: 12})
+
+	req, err := http.NewRequest("POST", "/tasks", bytes.NewBuffer(body))
+	require.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	var response entities.ProblemResponse
+	err = json.NewDecoder(rr.Body).Decode(&response)
+	require.NoError(t, err)
+	assert.Equal(t, "validation_failed", response.Code)
+	assert.Equal(t, "User with id=12 does not exist", response.Detail)
+}
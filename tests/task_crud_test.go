@@ -0,0 +1,198 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"task_tracker/src/api"
+	"task_tracker/src/entities"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetTaskHandler__OK(t *testing.T) {
+	backend, cleanup, err := SetupTestBackend()
+	require.NoError(t, err)
+	defer cleanup()
+
+	router := NewTestRouter(1)
+	api.InitTaskRoutes(router, backend.Repo)
+
+	createUserWithRunningTask(backend)
+
+	req, err := http.NewRequest("GET", "/tasks/1", nil)
+	require.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var response entities.Task
+	err = json.NewDecoder(rr.Body).Decode(&response)
+	require.NoError(t, err)
+	assert.Equal(t, 1, response.TaskId)
+	assert.Equal(t, 1, response.UserId)
+	assert.Equal(t, "task1", response.TaskName)
+	assert.Nil(t, response.EndTime)
+}
+
+func TestGetTaskHandler__NotFound(t *testing.T) {
+	backend, cleanup, err := SetupTestBackend()
+	require.NoError(t, err)
+	defer cleanup()
+
+	router := NewTestRouter(1)
+	api.InitTaskRoutes(router, backend.Repo)
+
+	createUserWithRunningTask(backend)
+
+	req, err := http.NewRequest("GET", "/tasks/12", nil)
+	require.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	var response entities.ProblemResponse
+	err = json.NewDecoder(rr.Body).Decode(&response)
+	require.NoError(t, err)
+	assert.Equal(t, "not_found", response.Code)
+	assert.Equal(t, "Task with id=12 does not exist", response.Detail)
+}
+
+func TestGetTasksHandler__FilterFinished(t *testing.T) {
+	backend, cleanup, err := SetupTestBackend()
+	require.NoError(t, err)
+	defer cleanup()
+
+	router := NewTestRouter(1)
+	api.InitTaskRoutes(router, backend.Repo)
+
+	createUserWithRunningTask(backend)
+	end_time := time.Date(2024, 7, 8, 14, 24, 7, 0, time.UTC)
+	err = backend.CreateTask(entities.Task{
+		UserId:    1,
+		TaskName:  "task2",
+		StartTime: time.Date(2024, 7, 6, 10, 55, 7, 0, time.UTC),
+		EndTime:   &end_time,
+	})
+	require.NoError(t, err)
+
+	req, err := http.NewRequest("GET", "/tasks?userId=1&finished=true", nil)
+	require.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var response entities.GetTasksResponse
+	err = json.NewDecoder(rr.Body).Decode(&response)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(response.Tasks))
+	assert.Equal(t, "task2", response.Tasks[0].TaskName)
+}
+
+func TestUpdateTaskHandler__OK(t *testing.T) {
+	backend, cleanup, err := SetupTestBackend()
+	require.NoError(t, err)
+	defer cleanup()
+
+	router := NewTestRouter(1)
+	api.InitTaskRoutes(router, backend.Repo)
+
+	createUserWithRunningTask(backend)
+
+	task_name := "renamed"
+	body, _ := json.Marshal(entities.UpdateTaskRequest{TaskName: &task_name})
+
+	req, err := http.NewRequest("PATCH", "/tasks/1", bytes.NewBuffer(body))
+	require.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	task_from_db, err := backend.Repo.GetTask(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, task_name, task_from_db.TaskName)
+}
+
+func TestDeleteTaskHandler__OK(t *testing.T) {
+	backend, cleanup, err := SetupTestBackend()
+	require.NoError(t, err)
+	defer cleanup()
+
+	router := NewTestRouter(1)
+	api.InitTaskRoutes(router, backend.Repo)
+
+	createUserWithRunningTask(backend)
+
+	req, err := http.NewRequest("DELETE", "/tasks/1", nil)
+	require.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+
+	req, err = http.NewRequest("DELETE", "/tasks/1", nil)
+	require.NoError(t, err)
+
+	rr = httptest.NewRecorder()
+
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestReopenTaskHandler__OK(t *testing.T) {
+	backend, cleanup, err := SetupTestBackend()
+	require.NoError(t, err)
+	defer cleanup()
+
+	router := NewTestRouter(1)
+	api.InitTaskRoutes(router, backend.Repo)
+
+	createUserWithRunningTask(backend)
+
+	body, _ := json.Marshal(entities.FinishTaskRequest{TaskId: 1})
+	req, err := http.NewRequest("POST", "/tasks/finish", bytes.NewBuffer(body))
+	require.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	req, err = http.NewRequest("POST", "/tasks/1/reopen", nil)
+	require.NoError(t, err)
+
+	rr = httptest.NewRecorder()
+
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var response entities.Task
+	err = json.NewDecoder(rr.Body).Decode(&response)
+	require.NoError(t, err)
+	assert.Nil(t, response.EndTime)
+
+	assert.False(t, isTaskPaused(t, backend, 1))
+}
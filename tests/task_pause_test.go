@@ -0,0 +1,107 @@
+package tests
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"task_tracker/src/api"
+	"task_tracker/src/entities"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stretchr/testify/require"
+)
+
+func createUserWithRunningTask(backend *TestBackend) {
+	err := backend.CreateUsers(
+		entities.User{PassportSerie: "1212", PassportNumber: "232323", Surname: "Ivanov", Name: "Ivan"},
+	)
+	if err != nil {
+		log.Fatalf("Failed to create user: %v\n", err)
+	}
+	err = backend.CreateTask(entities.Task{UserId: 1, TaskName: "task1"})
+	if err != nil {
+		log.Fatalf("Failed to create task: %v\n", err)
+	}
+}
+
+func isTaskPaused(t *testing.T, backend *TestBackend, task_id int) bool {
+	activities, err := backend.Repo.GetUserActivity(
+		context.Background(),
+		entities.UserActivityRequest{UserId: 1},
+	)
+	require.NoError(t, err)
+	for _, activity := range activities {
+		if activity.TaskID == task_id {
+			return activity.IsPaused
+		}
+	}
+	t.Fatalf("task %d not found in user activities", task_id)
+	return false
+}
+
+func TestPauseTaskHandler__OK(t *testing.T) {
+	backend, cleanup, err := SetupTestBackend()
+	require.NoError(t, err)
+	defer cleanup()
+
+	router := NewTestRouter(1)
+	api.InitTaskRoutes(router, backend.Repo)
+
+	createUserWithRunningTask(backend)
+
+	req, err := http.NewRequest("POST", "/tasks/1/pause", nil)
+	require.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	assert.True(t, isTaskPaused(t, backend, 1))
+
+	req, err = http.NewRequest("POST", "/tasks/1/pause", nil)
+	require.NoError(t, err)
+
+	rr = httptest.NewRecorder()
+
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusConflict, rr.Code)
+}
+
+func TestResumeTaskHandler__OK(t *testing.T) {
+	backend, cleanup, err := SetupTestBackend()
+	require.NoError(t, err)
+	defer cleanup()
+
+	router := NewTestRouter(1)
+	api.InitTaskRoutes(router, backend.Repo)
+
+	createUserWithRunningTask(backend)
+
+	for _, action := range []string{"pause", "resume"} {
+		req, err := http.NewRequest("POST", "/tasks/1/"+action, nil)
+		require.NoError(t, err)
+
+		rr := httptest.NewRecorder()
+
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+	}
+
+	assert.False(t, isTaskPaused(t, backend, 1))
+
+	req, err := http.NewRequest("POST", "/tasks/1/resume", nil)
+	require.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusConflict, rr.Code)
+}
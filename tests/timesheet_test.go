@@ -0,0 +1,157 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"task_tracker/src/api"
+	"task_tracker/src/entities"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createUsersWithTimesheetTasks(t *testing.T, backend *TestBackend) {
+	err := backend.CreateUsers(
+		entities.User{PassportSerie: "1212", PassportNumber: "232323", Surname: "Ivanov", Name: "Ivan"},
+		entities.User{PassportSerie: "3434", PassportNumber: "454545", Surname: "Petrov", Name: "Petr"},
+	)
+	require.NoError(t, err)
+
+	tasks := []struct {
+		name       string
+		start_time string
+		end_time   string
+	}{
+		// 22:30 - 01:30 in Moscow, crosses midnight.
+		{"task1", "2024-07-09 19:30:00", "2024-07-09 22:30:00"},
+		{"task2", "2024-07-12 08:00:00", "2024-07-12 10:15:00"},
+		{"task3", "2024-07-20 08:00:00", "2024-07-20 09:00:00"},
+	}
+	for _, task := range tasks {
+		start_time, _ := time.Parse(time.DateTime, task.start_time)
+		end_time, _ := time.Parse(time.DateTime, task.end_time)
+		err = backend.CreateTask(entities.Task{
+			UserId:    1,
+			TaskName:  task.name,
+			StartTime: start_time,
+			EndTime:   &end_time,
+		})
+		require.NoError(t, err)
+	}
+}
+
+func setupTimesheetRouter(backend *TestBackend, user_id int) *mux.Router {
+	router := NewTestRouter(user_id)
+	api.InitTimesheetRoutes(router, backend.Repo)
+	return router
+}
+
+func TestGetTimesheetHandler__Week__SplitsAtMidnight(t *testing.T) {
+	backend, cleanup, err := SetupTestBackend()
+	require.NoError(t, err)
+	defer cleanup()
+
+	createUsersWithTimesheetTasks(t, backend)
+	router := setupTimesheetRouter(backend, 1)
+
+	rr := doJSONRequest(router, "GET", "/timesheets/1?week=2024-W28&timezone=Europe/Moscow", nil, "")
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var response entities.Timesheet
+	err = json.NewDecoder(rr.Body).Decode(&response)
+	require.NoError(t, err)
+
+	assert.Equal(t, "Europe/Moscow", response.Timezone)
+	assert.Equal(t, []string{
+		"2024-07-08", "2024-07-09", "2024-07-10", "2024-07-11", "2024-07-12", "2024-07-13", "2024-07-14",
+	}, response.Days)
+	require.Equal(t, 2, len(response.Tasks))
+	assert.Equal(t, "task1", response.Tasks[0].TaskName)
+	assert.Equal(t, []int{0, 90, 90, 0, 0, 0, 0}, response.Tasks[0].Minutes)
+	assert.Equal(t, 180, response.Tasks[0].TotalMinutes)
+	assert.Equal(t, []int{0, 0, 0, 0, 135, 0, 0}, response.Tasks[1].Minutes)
+	assert.Equal(t, []int{0, 90, 90, 0, 135, 0, 0}, response.DayTotals)
+	assert.Equal(t, 315, response.TotalMinutes)
+
+	rr = doJSONRequest(router, "GET", "/timesheets/1?week=2024-W28", nil, "")
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	err = json.NewDecoder(rr.Body).Decode(&response)
+	require.NoError(t, err)
+	assert.Equal(t, "UTC", response.Timezone)
+	assert.Equal(t, []int{0, 180, 0, 0, 0, 0, 0}, response.Tasks[0].Minutes)
+}
+
+func TestGetTimesheetHandler__Month(t *testing.T) {
+	backend, cleanup, err := SetupTestBackend()
+	require.NoError(t, err)
+	defer cleanup()
+
+	createUsersWithTimesheetTasks(t, backend)
+	router := setupTimesheetRouter(backend, 1)
+
+	rr := doJSONRequest(router, "GET", "/timesheets/1?month=2024-07", nil, "")
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var response entities.Timesheet
+	err = json.NewDecoder(rr.Body).Decode(&response)
+	require.NoError(t, err)
+
+	assert.Equal(t, 31, len(response.Days))
+	assert.Equal(t, 3, len(response.Tasks))
+	assert.Equal(t, 60, response.DayTotals[19])
+	assert.Equal(t, 375, response.TotalMinutes)
+}
+
+func TestGetTimesheetHandler__BadRequest(t *testing.T) {
+	backend, cleanup, err := SetupTestBackend()
+	require.NoError(t, err)
+	defer cleanup()
+
+	createUsersWithTimesheetTasks(t, backend)
+	router := setupTimesheetRouter(backend, 1)
+
+	for _, query := range []string{
+		"",
+		"?week=2024-W28&month=2024-07",
+		"?week=2024-W53",
+		"?week=2024-28",
+		"?month=2024-13",
+		"?week=2024-W28&timezone=Mars/Olympus",
+	} {
+		rr := doJSONRequest(router, "GET", "/timesheets/1"+query, nil, "")
+		assert.Equal(t, http.StatusBadRequest, rr.Code, query)
+	}
+
+	rr := doJSONRequest(router, "GET", "/timesheets/1?week=2020-W53", nil, "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestGetTeamTimesheetHandler__OK(t *testing.T) {
+	backend, cleanup, err := SetupTestBackend()
+	require.NoError(t, err)
+	defer cleanup()
+
+	createUsersWithTimesheetTasks(t, backend)
+
+	rr := doJSONRequest(setupTimesheetRouter(backend, 1), "GET", "/timesheets?week=2024-W28", nil, "")
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+
+	require.NoError(t, backend.SetRole(2, entities.RoleAdmin, nil))
+	rr = doJSONRequest(setupTimesheetRouter(backend, 2), "GET", "/timesheets?week=2024-W28", nil, "")
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var response entities.TeamTimesheet
+	err = json.NewDecoder(rr.Body).Decode(&response)
+	require.NoError(t, err)
+
+	require.Equal(t, 2, len(response.Timesheets))
+	assert.Equal(t, 1, response.Timesheets[0].UserId)
+	assert.Equal(t, 315, response.Timesheets[0].TotalMinutes)
+	assert.Equal(t, 2, response.Timesheets[1].UserId)
+	assert.Equal(t, 0, len(response.Timesheets[1].Tasks))
+	assert.Equal(t, 0, response.Timesheets[1].TotalMinutes)
+}
@@ -0,0 +1,78 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"task_tracker/src/api"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// setupSpanRecorder installs a global tracer provider recording every span
+// and restores the previous one when the test ends.
+func setupSpanRecorder(t *testing.T) *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	previous_provider := otel.GetTracerProvider()
+	previous_propagator := otel.GetTextMapPropagator()
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previous_provider)
+		otel.SetTextMapPropagator(previous_propagator)
+	})
+	return recorder
+}
+
+func findSpan(spans []sdktrace.ReadOnlySpan, name string) sdktrace.ReadOnlySpan {
+	for _, span := range spans {
+		if span.Name() == name {
+			return span
+		}
+	}
+	return nil
+}
+
+func TestTracing__SpansFollowTraceparent(t *testing.T) {
+	backend, cleanup, err := SetupTestBackend()
+	require.NoError(t, err)
+	defer cleanup()
+
+	createUsersAndTasks(backend)
+	recorder := setupSpanRecorder(t)
+
+	router := NewTestRouter(1)
+	router.Use(api.TracingMiddleware)
+	api.InitHealthRoutes(router, backend.Repo, nil)
+	api.InitUserRoutes(router, backend.Repo, nil, NewTestConfig().Pagination)
+
+	req := httptest.NewRequest("GET", "/user-activities/1", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	spans := recorder.Ended()
+	server_span := findSpan(spans, "GET /user-activities/{userId}")
+	require.NotNil(t, server_span)
+	assert.Equal(t, trace.SpanKindServer, server_span.SpanKind())
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", server_span.SpanContext().TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", server_span.Parent().SpanID().String())
+
+	service_span := findSpan(spans, "services.GetUserActivities")
+	require.NotNil(t, service_span)
+	assert.Equal(t, server_span.SpanContext().SpanID(), service_span.Parent().SpanID())
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/healthz", nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Nil(t, findSpan(recorder.Ended(), "GET /healthz"))
+}
@@ -0,0 +1,116 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"task_tracker/src/entities"
+	"task_tracker/src/errors/repo_errors"
+	"testing"
+
+	"github.com/jackc/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithTx__Commit(t *testing.T) {
+	backend, cleanup, err := SetupTestBackend()
+	require.NoError(t, err)
+	defer cleanup()
+
+	err = backend.Repo.WithTx(context.Background(), func(ctx context.Context) error {
+		user_id, err := backend.Repo.CreateUser(ctx, entities.User{PassportSerie: "1212", PassportNumber: "232323", Surname: "Ivanov", Name: "Ivan"})
+		if err != nil {
+			return err
+		}
+		return backend.Repo.SetUserPassword(ctx, user_id, "hash")
+	})
+	require.NoError(t, err)
+
+	credentials, err := backend.Repo.GetUserCredentials(context.Background(), "1212", "232323")
+	require.NoError(t, err)
+	assert.Equal(t, "hash", credentials.PasswordHash)
+}
+
+func TestWithTx__Rollback(t *testing.T) {
+	backend, cleanup, err := SetupTestBackend()
+	require.NoError(t, err)
+	defer cleanup()
+
+	err = backend.CreateUsers(entities.User{PassportSerie: "1111", PassportNumber: "111111", Surname: "Petrov", Name: "Petr"})
+	require.NoError(t, err)
+
+	failure := errors.New("failed after the first write")
+	err = backend.Repo.WithTx(context.Background(), func(ctx context.Context) error {
+		_, err := backend.Repo.CreateUser(ctx, entities.User{PassportSerie: "1212", PassportNumber: "232323", Surname: "Ivanov", Name: "Ivan"})
+		if err != nil {
+			return err
+		}
+		// Nested units of work join the outer one and are rolled back with it.
+		err = backend.Repo.WithTx(ctx, func(ctx context.Context) error {
+			return backend.Repo.DeleteUser(ctx, 1)
+		})
+		if err != nil {
+			return err
+		}
+		return failure
+	})
+	assert.ErrorIs(t, err, failure)
+
+	users, err := backend.GetAllUsers()
+	require.NoError(t, err)
+	require.Equal(t, 1, len(users))
+	assert.Equal(t, "Petrov", users[0].Surname)
+
+	events, _, err := backend.Repo.GetAuditEvents(context.Background(), entities.AuditEventsFilter{Limit: 10})
+	require.NoError(t, err)
+	require.Equal(t, 1, len(events))
+	assert.Equal(t, entities.AuditActionCreate, events[0].Action)
+	assert.Equal(t, 1, events[0].EntityId)
+
+	_, err = backend.Repo.GetUserCredentials(context.Background(), "1212", "232323")
+	assert.ErrorIs(t, err, repo_errors.ObjectNotFoundError{})
+}
+
+func TestWithTx__Retry(t *testing.T) {
+	backend, cleanup, err := SetupTestBackend()
+	require.NoError(t, err)
+	defer cleanup()
+
+	// The first attempt is rolled back, so the passport is free on the second.
+	attempts := 0
+	err = backend.Repo.WithTx(context.Background(), func(ctx context.Context) error {
+		attempts++
+		_, err := backend.Repo.CreateUser(ctx, entities.User{PassportSerie: "1212", PassportNumber: "232323", Surname: "Ivanov", Name: "Ivan"})
+		if err != nil {
+			return err
+		}
+		if attempts == 1 {
+			return &pgconn.PgError{Code: "40001"}
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, attempts)
+
+	users, err := backend.GetAllUsers()
+	require.NoError(t, err)
+	assert.Equal(t, 1, len(users))
+
+	deadlock := &pgconn.PgError{Code: "40P01"}
+	attempts = 0
+	err = backend.Repo.WithTx(context.Background(), func(ctx context.Context) error {
+		attempts++
+		return deadlock
+	})
+	assert.ErrorIs(t, err, deadlock)
+	assert.Equal(t, 3, attempts)
+
+	failure := errors.New("not retryable")
+	attempts = 0
+	err = backend.Repo.WithTx(context.Background(), func(ctx context.Context) error {
+		attempts++
+		return failure
+	})
+	assert.ErrorIs(t, err, failure)
+	assert.Equal(t, 1, attempts)
+}
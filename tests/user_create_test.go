@@ -2,9 +2,7 @@ package tests
 
 import (
 	"bytes"
-	"context"
 	"encoding/json"
-	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"task_tracker/src/api"
@@ -13,27 +11,25 @@ import (
 
 	"github.com/stretchr/testify/assert"
 
-	"github.com/gorilla/mux"
 	"github.com/stretchr/testify/require"
 )
 
 func TestCreateUserHandler__OK(t *testing.T) {
-	pool, cleanup, err := SetupTestDB()
+	backend, cleanup, err := SetupTestBackend()
 	require.NoError(t, err)
 	defer cleanup()
 
-	log := SetupLogger()
-
-	router := mux.NewRouter()
-	api.InitUserRoutes(router, pool, log)
+	router, err := NewAdminTestRouter(backend)
+	require.NoError(t, err)
+	api.InitUserRoutes(router, backend.Repo, nil, NewTestConfig().Pagination)
 
-	passport_serie := 2233
-	passport_number := 895044
+	passport_serie := "2233"
+	passport_number := "895044"
 	name := "Ivan"
 	surname := "Petrov"
 
 	user := entities.UserCreateRequest{
-		PassportNumber: fmt.Sprintf("%d %d", passport_serie, passport_number),
+		PassportNumber: passport_serie + " " + passport_number,
 		Name:           name,
 		Surname:        surname,
 	}
@@ -56,20 +52,7 @@ func TestCreateUserHandler__OK(t *testing.T) {
 	assert.Equal(t, response.PassportNumber, passport_number)
 	assert.Equal(t, response.PassportSerie, passport_serie)
 
-	var userFromDB entities.User
-	row := pool.QueryRow(
-		context.Background(),
-		"SELECT * FROM users WHERE surname = $1",
-		surname,
-	)
-
-	err = row.Scan(
-		&userFromDB.Id,
-		&userFromDB.PassportSerie,
-		&userFromDB.PassportNumber,
-		&userFromDB.Surname,
-		&userFromDB.Name,
-	)
+	userFromDB, err := backend.GetUser(response.Id)
 	require.NoError(t, err)
 
 	assert.Equal(t, name, userFromDB.Name)
@@ -77,14 +60,13 @@ func TestCreateUserHandler__OK(t *testing.T) {
 }
 
 func TestCreateUserHandler__ValidationError__NoPassportData(t *testing.T) {
-	pool, cleanup, err := SetupTestDB()
+	backend, cleanup, err := SetupTestBackend()
 	require.NoError(t, err)
 	defer cleanup()
 
-	log := SetupLogger()
-
-	router := mux.NewRouter()
-	api.InitUserRoutes(router, pool, log)
+	router, err := NewAdminTestRouter(backend)
+	require.NoError(t, err)
+	api.InitUserRoutes(router, backend.Repo, nil, NewTestConfig().Pagination)
 
 	name := "Ivan"
 	surname := "Petrov"
@@ -104,28 +86,34 @@ func TestCreateUserHandler__ValidationError__NoPassportData(t *testing.T) {
 
 	assert.Equal(t, http.StatusBadRequest, rr.Code)
 
-	var response entities.ErrorResponse
+	var response entities.ProblemResponse
 	err = json.NewDecoder(rr.Body).Decode(&response)
 	require.NoError(t, err)
-	assert.Equal(t, entities.ErrorResponse{Error: "Bad Request: Validation failed on field 'PassportNumber', condition: 'required'"}, response)
+	assert.Equal(t, entities.ProblemResponse{
+		Type:   "about:blank",
+		Title:  "Bad Request",
+		Status: http.StatusBadRequest,
+		Detail: "Validation failed on field 'passportNumber', condition: 'required'",
+		Code:   "validation_failed",
+		InvalidParams: []entities.InvalidParam{
+			{Name: "passportNumber", Reason: "is required"},
+		},
+	}, response)
 
-	var count int
-	pool.QueryRow(
-		context.Background(),
-		"SELECT count(*) FROM users",
-	).Scan(&count)
-	assert.Equal(t, 0, count)
+	// Only the admin making the request.
+	users, err := backend.GetAllUsers()
+	require.NoError(t, err)
+	assert.Equal(t, 1, len(users))
 }
 
 func TestCreateUserHandler__ValidationError__WrongPassportData(t *testing.T) {
-	pool, cleanup, err := SetupTestDB()
+	backend, cleanup, err := SetupTestBackend()
 	require.NoError(t, err)
 	defer cleanup()
 
-	log := SetupLogger()
-
-	router := mux.NewRouter()
-	api.InitUserRoutes(router, pool, log)
+	router, err := NewAdminTestRouter(backend)
+	require.NoError(t, err)
+	api.InitUserRoutes(router, backend.Repo, nil, NewTestConfig().Pagination)
 
 	name := "Ivan"
 	surname := "Petrov"
@@ -147,21 +135,56 @@ func TestCreateUserHandler__ValidationError__WrongPassportData(t *testing.T) {
 
 	assert.Equal(t, http.StatusBadRequest, rr.Code)
 
-	var response entities.ErrorResponse
+	var response entities.ProblemResponse
 	err = json.NewDecoder(rr.Body).Decode(&response)
 	require.NoError(t, err)
 	assert.Equal(
 		t,
-		entities.ErrorResponse{
-			Error: "Bad Request: Incorrect passportNumber format. Passport serie and passport number should be devided with space",
+		[]entities.InvalidParam{
+			{Name: "passportNumber", Reason: "must have 10 digits when written without separators, got 8"},
 		},
-		response,
+		response.InvalidParams,
+	)
+
+	// Only the admin making the request.
+	users, err := backend.GetAllUsers()
+	require.NoError(t, err)
+	assert.Equal(t, 1, len(users))
+}
+
+func TestCreateUserHandler__BadRequest__PassportAlreadyExists(t *testing.T) {
+	backend, cleanup, err := SetupTestBackend()
+	require.NoError(t, err)
+	defer cleanup()
+
+	router, err := NewAdminTestRouter(backend)
+	require.NoError(t, err)
+	api.InitUserRoutes(router, backend.Repo, nil, NewTestConfig().Pagination)
+
+	err = backend.CreateUsers(
+		entities.User{PassportSerie: "1212", PassportNumber: "232323", Surname: "Ivanov", Name: "Ivan"},
 	)
+	require.NoError(t, err)
+
+	user := entities.UserCreateRequest{
+		PassportNumber: "1212 232323",
+		Name:           "Petr",
+		Surname:        "Petrov",
+	}
+	body, _ := json.Marshal(user)
+
+	req, err := http.NewRequest("POST", "/users", bytes.NewBuffer(body))
+	require.NoError(t, err)
 
-	var count int
-	pool.QueryRow(
-		context.Background(),
-		"SELECT count(*) FROM users",
-	).Scan(&count)
-	assert.Equal(t, 0, count)
+	rr := httptest.NewRecorder()
+
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusConflict, rr.Code)
+
+	var response entities.ProblemResponse
+	err = json.NewDecoder(rr.Body).Decode(&response)
+	require.NoError(t, err)
+	assert.Equal(t, "conflict", response.Code)
+	assert.Equal(t, "User with passport number 1212 232323 already exists", response.Detail)
 }
@@ -5,31 +5,32 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"task_tracker/src/api"
+	"task_tracker/src/entities"
+	"task_tracker/src/services"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
-	"github.com/gorilla/mux"
 	"github.com/stretchr/testify/require"
 )
 
 func TestDeleteUserHandler__OK(t *testing.T) {
-	pool, cleanup, err := SetupTestDB()
+	backend, cleanup, err := SetupTestBackend()
 	require.NoError(t, err)
 	defer cleanup()
 
-	log := SetupLogger()
+	router := NewTestRouter(1)
+	api.InitUserRoutes(router, backend.Repo, nil, NewTestConfig().Pagination)
 
-	router := mux.NewRouter()
-	api.InitUserRoutes(router, pool, log)
-
-	pool.Exec(
-		context.Background(),
-		`INSERT INTO users (user_id, passport_serie, passport_number, surname, name)
-		VALUES (1, 1212, 232323, 'Ivanov', 'Ivan');
-		`,
+	err = backend.CreateUsers(
+		entities.User{PassportSerie: "1212", PassportNumber: "232323", Surname: "Ivanov", Name: "Ivan"},
+		entities.User{PassportSerie: "3434", PassportNumber: "454545", Surname: "Petrov", Name: "Petr"},
 	)
-	req, err := http.NewRequest("DELETE", "/users/1", nil)
+	require.NoError(t, err)
+	require.NoError(t, backend.SetRole(1, entities.RoleAdmin, nil))
+
+	req, err := http.NewRequest("DELETE", "/users/2", nil)
 	require.NoError(t, err)
 
 	rr := httptest.NewRecorder()
@@ -38,13 +39,88 @@ func TestDeleteUserHandler__OK(t *testing.T) {
 
 	assert.Equal(t, http.StatusNoContent, rr.Code)
 
-	var count_users int
-	err = pool.QueryRow(
-		context.Background(),
-		"SELECT count(*) FROM users;",
-	).Scan(&count_users)
+	users, err := backend.GetAllUsers()
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, len(users))
+	assert.Equal(t, 1, users[0].Id)
+}
+
+func TestDeleteUserHandler__KeepsTasksAndFreesPassport(t *testing.T) {
+	backend, cleanup, err := SetupTestBackend()
+	require.NoError(t, err)
+	defer cleanup()
+
+	router := NewTestRouter(1)
+	api.InitUserRoutes(router, backend.Repo, nil, NewTestConfig().Pagination)
+	api.InitTaskRoutes(router, backend.Repo)
+
+	err = backend.CreateUsers(
+		entities.User{PassportSerie: "1212", PassportNumber: "232323", Surname: "Ivanov", Name: "Ivan"},
+		entities.User{PassportSerie: "3434", PassportNumber: "454545", Surname: "Petrov", Name: "Petr"},
+	)
+	require.NoError(t, err)
+	require.NoError(t, backend.SetRole(1, entities.RoleAdmin, nil))
+	require.NoError(t, backend.CreateTask(entities.Task{UserId: 2, TaskName: "task1"}))
+
+	rr := doJSONRequest(router, "DELETE", "/users/2", nil, "")
+	require.Equal(t, http.StatusNoContent, rr.Code)
+
+	rr = doJSONRequest(router, "DELETE", "/users/2", nil, "")
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	// The task is archived: kept, but no longer running.
+	task, err := backend.Repo.GetTask(context.Background(), 1)
+	require.NoError(t, err)
+	assert.NotNil(t, task.EndTime)
+
+	rr = doJSONRequest(router, "POST", "/tasks", entities.CreateTaskRequest{TaskName: "task2", UserId: 2}, "")
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	rr = doJSONRequest(router, "POST", "/users", entities.UserCreateRequest{PassportNumber: "3434 454545"}, "")
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	rr = doJSONRequest(router, "POST", "/users/2/restore", nil, "")
+	assert.Equal(t, http.StatusConflict, rr.Code)
+
+	rr = doJSONRequest(router, "DELETE", "/users/3", nil, "")
+	require.Equal(t, http.StatusNoContent, rr.Code)
+
+	rr = doJSONRequest(router, "POST", "/users/2/restore", nil, "")
+	require.Equal(t, http.StatusNoContent, rr.Code)
+
+	rr = doJSONRequest(router, "POST", "/users/2/restore", nil, "")
+	assert.Equal(t, http.StatusConflict, rr.Code)
+
+	users, err := backend.GetAllUsers()
+	require.NoError(t, err)
+	assert.Equal(t, 2, len(users))
+}
+
+func TestPurgeDeletedUsers(t *testing.T) {
+	backend, cleanup, err := SetupTestBackend()
+	require.NoError(t, err)
+	defer cleanup()
+
+	err = backend.CreateUsers(
+		entities.User{PassportSerie: "1212", PassportNumber: "232323", Surname: "Ivanov", Name: "Ivan"},
+		entities.User{PassportSerie: "3434", PassportNumber: "454545", Surname: "Petrov", Name: "Petr"},
+	)
+	require.NoError(t, err)
+	require.NoError(t, backend.CreateTask(entities.Task{UserId: 2, TaskName: "task1"}))
+
+	ctx := context.Background()
+	require.NoError(t, backend.Repo.DeleteUser(ctx, 2))
+
+	purged, err := services.PurgeDeletedUsers(ctx, backend.Repo, 30, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 0, purged)
 
+	purged, err = services.PurgeDeletedUsers(ctx, backend.Repo, 30, time.Now().AddDate(0, 0, 31))
 	require.NoError(t, err)
+	assert.Equal(t, 1, purged)
 
-	assert.Equal(t, 0, count_users)
+	_, err = backend.Repo.GetTask(ctx, 1)
+	assert.Error(t, err)
+	assert.Error(t, backend.Repo.RestoreUser(ctx, 2))
 }
@@ -0,0 +1,134 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"task_tracker/src/api"
+	"task_tracker/src/clients/people_info"
+	"task_tracker/src/entities"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stretchr/testify/require"
+)
+
+func setupPeopleInfoClient(server *httptest.Server) *people_info.Client {
+	return people_info.NewClient(
+		people_info.Config{
+			BaseURL:    server.URL,
+			Timeout:    time.Second,
+			MaxRetries: 2,
+			RetryDelay: time.Millisecond,
+		},
+		SetupLogger(),
+	)
+}
+
+func TestCreateUserHandler__EnrichedFromPeopleInfo(t *testing.T) {
+	backend, cleanup, err := SetupTestBackend()
+	require.NoError(t, err)
+	defer cleanup()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/info", r.URL.Path)
+		assert.Equal(t, "1234", r.URL.Query().Get("passportSerie"))
+		assert.Equal(t, "567890", r.URL.Query().Get("passportNumber"))
+
+		json.NewEncoder(w).Encode(people_info.People{
+			Surname:    "Иванов",
+			Name:       "Иван",
+			Patronymic: "Иванович",
+			Address:    "г. Москва, ул. Ленина, д. 5, кв. 1",
+		})
+	}))
+	defer server.Close()
+
+	router, err := NewAdminTestRouter(backend)
+	require.NoError(t, err)
+	api.InitUserRoutes(router, backend.Repo, setupPeopleInfoClient(server), NewTestConfig().Pagination)
+
+	body, _ := json.Marshal(entities.UserCreateRequest{PassportNumber: "1234 567890", Name: "Ivan"})
+
+	req, err := http.NewRequest("POST", "/users", bytes.NewBuffer(body))
+	require.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var response entities.UserCreateResponse
+	err = json.NewDecoder(rr.Body).Decode(&response)
+	require.NoError(t, err)
+	assert.Equal(t, "Иванов", response.Surname)
+	assert.Equal(t, "Иван", response.Name)
+	assert.Equal(t, "Иванович", response.Patronymic)
+	assert.Equal(t, "г. Москва, ул. Ленина, д. 5, кв. 1", response.Address)
+
+	userFromDB, err := backend.GetUser(response.Id)
+	require.NoError(t, err)
+	assert.Equal(t, "Иванович", userFromDB.Patronymic)
+}
+
+func TestCreateUserHandler__PeopleInfoUnavailable__FallbackToRequest(t *testing.T) {
+	backend, cleanup, err := SetupTestBackend()
+	require.NoError(t, err)
+	defer cleanup()
+
+	var requests_count int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests_count, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	router, err := NewAdminTestRouter(backend)
+	require.NoError(t, err)
+	api.InitUserRoutes(router, backend.Repo, setupPeopleInfoClient(server), NewTestConfig().Pagination)
+
+	body, _ := json.Marshal(entities.UserCreateRequest{
+		PassportNumber: "1234 567890",
+		Name:           "Ivan",
+		Surname:        "Petrov",
+	})
+
+	req, err := http.NewRequest("POST", "/users", bytes.NewBuffer(body))
+	require.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&requests_count))
+
+	var response entities.UserCreateResponse
+	err = json.NewDecoder(rr.Body).Decode(&response)
+	require.NoError(t, err)
+	assert.Equal(t, "Petrov", response.Surname)
+	assert.Equal(t, "Ivan", response.Name)
+	assert.Equal(t, "", response.Patronymic)
+}
+
+func TestPeopleInfoClient__BadRequest__NoRetry(t *testing.T) {
+	var requests_count int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests_count, 1)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	client := setupPeopleInfoClient(server)
+
+	people, err := client.GetPeopleInfo(context.Background(), "1234", "567890")
+	assert.Error(t, err)
+	assert.Nil(t, people)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&requests_count))
+}
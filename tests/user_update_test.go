@@ -2,45 +2,37 @@ package tests
 
 import (
 	"bytes"
-	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
-	"strconv"
 	"task_tracker/src/api"
 	"task_tracker/src/entities"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 
-	"github.com/gorilla/mux"
 	"github.com/stretchr/testify/require"
 )
 
 func TestUpdateUserHandler__OK(t *testing.T) {
-	pool, cleanup, err := SetupTestDB()
+	backend, cleanup, err := SetupTestBackend()
 	require.NoError(t, err)
 	defer cleanup()
 
-	log := SetupLogger()
+	router := NewTestRouter(1)
+	api.InitUserRoutes(router, backend.Repo, nil, NewTestConfig().Pagination)
 
-	router := mux.NewRouter()
-	api.InitUserRoutes(router, pool, log)
-
-	_, err_create := pool.Exec(
-		context.Background(),
-		`INSERT INTO users (user_id, passport_serie, passport_number, surname, name)
-		VALUES (1, 1212, 232323, 'Ivanov', 'Ivan');
-		`,
+	err = backend.CreateUsers(
+		entities.User{PassportSerie: "1212", PassportNumber: "232323", Surname: "Ivanov", Name: "Ivan"},
 	)
-	require.NoError(t, err_create)
+	require.NoError(t, err)
 
-	passport_serie := 2233
-	passport_number := 895044
+	passport_serie := "2233"
+	passport_number := "895044"
 	name := "Ivan"
 	surname := "Petrov"
 
-	passport_number_for_request := strconv.Itoa(passport_serie) + " " + strconv.Itoa(passport_number)
+	passport_number_for_request := passport_serie + " " + passport_number
 	user := entities.UserUpdateRequest{
 		PassportNumber: &passport_number_for_request,
 		Surname:        &surname,
@@ -65,49 +57,33 @@ func TestUpdateUserHandler__OK(t *testing.T) {
 	assert.Equal(t, response.PassportNumber, passport_number)
 	assert.Equal(t, response.PassportSerie, passport_serie)
 
-	var userFromDB entities.User
-	row := pool.QueryRow(
-		context.Background(),
-		"SELECT * FROM users WHERE user_id = 1;",
-	)
-
-	err = row.Scan(
-		&userFromDB.Id,
-		&userFromDB.PassportSerie,
-		&userFromDB.PassportNumber,
-		&userFromDB.Surname,
-		&userFromDB.Name,
-	)
+	userFromDB, err := backend.GetUser(1)
 	require.NoError(t, err)
 
 	assert.Equal(t, name, userFromDB.Name)
 	assert.Equal(t, passport_number, userFromDB.PassportNumber)
 }
 
-func TestUpdateUserHandler__BadRequest__ObjectNotFound(t *testing.T) {
-	pool, cleanup, err := SetupTestDB()
+func TestUpdateUserHandler__NotFound(t *testing.T) {
+	backend, cleanup, err := SetupTestBackend()
 	require.NoError(t, err)
 	defer cleanup()
 
-	log := SetupLogger()
-
-	router := mux.NewRouter()
-	api.InitUserRoutes(router, pool, log)
+	router := NewTestRouter(1)
+	api.InitUserRoutes(router, backend.Repo, nil, NewTestConfig().Pagination)
 
-	_, err_create := pool.Exec(
-		context.Background(),
-		`INSERT INTO users (user_id, passport_serie, passport_number, surname, name)
-		VALUES (1, 1212, 232323, 'Ivanov', 'Ivan');
-		`,
+	err = backend.CreateUsers(
+		entities.User{PassportSerie: "1212", PassportNumber: "232323", Surname: "Ivanov", Name: "Ivan"},
 	)
-	require.NoError(t, err_create)
+	require.NoError(t, err)
+	require.NoError(t, backend.SetRole(1, entities.RoleAdmin, nil))
 
-	passport_serie := 2233
-	passport_number := 895044
+	passport_serie := "2233"
+	passport_number := "895044"
 	name := "Ivan"
 	surname := "Petrov"
 
-	passport_number_for_request := strconv.Itoa(passport_serie) + " " + strconv.Itoa(passport_number)
+	passport_number_for_request := passport_serie + " " + passport_number
 	user := entities.UserUpdateRequest{
 		PassportNumber: &passport_number_for_request,
 		Surname:        &surname,
@@ -122,10 +98,82 @@ func TestUpdateUserHandler__BadRequest__ObjectNotFound(t *testing.T) {
 
 	router.ServeHTTP(rr, req)
 
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	var response entities.ProblemResponse
+	err = json.NewDecoder(rr.Body).Decode(&response)
+	require.NoError(t, err)
+	assert.Equal(t, "not_found", response.Code)
+	assert.Equal(t, "User with id=12 does not exist", response.Detail)
+}
+
+func TestUpdateUserHandler__BadRequest__WrongPassportData(t *testing.T) {
+	backend, cleanup, err := SetupTestBackend()
+	require.NoError(t, err)
+	defer cleanup()
+
+	router := NewTestRouter(1)
+	api.InitUserRoutes(router, backend.Repo, nil, NewTestConfig().Pagination)
+
+	err = backend.CreateUsers(
+		entities.User{PassportSerie: "1212", PassportNumber: "232323", Surname: "Ivanov", Name: "Ivan"},
+	)
+	require.NoError(t, err)
+
+	passport_number_for_request := "12 232323"
+	user := entities.UserUpdateRequest{PassportNumber: &passport_number_for_request}
+	body, _ := json.Marshal(user)
+
+	req, err := http.NewRequest("PATCH", "/users/1", bytes.NewBuffer(body))
+	require.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+
+	router.ServeHTTP(rr, req)
+
 	assert.Equal(t, http.StatusBadRequest, rr.Code)
 
-	var response entities.ErrorResponse
+	var response entities.ProblemResponse
 	err = json.NewDecoder(rr.Body).Decode(&response)
 	require.NoError(t, err)
-	assert.Equal(t, "Bad Request: Object not found. userId=12", response.Error)
+	assert.Equal(t, []entities.InvalidParam{
+		{Name: "passportNumber", Reason: "serie must have 4 digits, got 2"},
+	}, response.InvalidParams)
+
+	user_from_db, err := backend.GetUser(1)
+	require.NoError(t, err)
+	assert.Equal(t, "1212", user_from_db.PassportSerie)
+}
+
+func TestUpdateUserHandler__BadRequest__EmptyBody(t *testing.T) {
+	backend, cleanup, err := SetupTestBackend()
+	require.NoError(t, err)
+	defer cleanup()
+
+	router := NewTestRouter(1)
+	api.InitUserRoutes(router, backend.Repo, nil, NewTestConfig().Pagination)
+
+	err = backend.CreateUsers(
+		entities.User{PassportSerie: "1212", PassportNumber: "232323", Surname: "Ivanov", Name: "Ivan"},
+	)
+	require.NoError(t, err)
+
+	req, err := http.NewRequest("PATCH", "/users/1", bytes.NewBufferString(`{}`))
+	require.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	var response entities.ProblemResponse
+	err = json.NewDecoder(rr.Body).Decode(&response)
+	require.NoError(t, err)
+	assert.Equal(t, "validation_failed", response.Code)
+	assert.Equal(t, "Request body must contain at least one field to update", response.Detail)
+
+	user_from_db, err := backend.GetUser(1)
+	require.NoError(t, err)
+	assert.Equal(t, 1, user_from_db.Version)
 }
@@ -2,12 +2,18 @@ package tests
 
 import (
 	"errors"
+	"net/http"
+	"net/http/httptest"
 	"strings"
+	"task_tracker/src/api"
+	"task_tracker/src/entities"
 	"task_tracker/src/errors/api_errors"
 	"task_tracker/src/utils"
 	"testing"
 
+	"github.com/gorilla/mux"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // Example struct to use for testing
@@ -37,7 +43,7 @@ func TestValidateRequestData__MissingRequiredFields(t *testing.T) {
 	assert.Error(t, err)
 	var apiErr api_errors.BadRequestError
 	assert.True(t, errors.As(err, &apiErr))
-	assert.Contains(t, apiErr.Detail, "Validation failed on field 'Email'")
+	assert.Contains(t, apiErr.Detail, "Validation failed on field 'email'")
 }
 
 func TestValidateRequestData__WrongFieldType(t *testing.T) {
@@ -54,3 +60,68 @@ func TestValidateRequestData__WrongFieldType(t *testing.T) {
 	assert.True(t, errors.As(err, &apiErr))
 	assert.Contains(t, apiErr.Detail, "Field 'email' must be of type string")
 }
+
+type TestNestedRequest struct {
+	Title string        `json:"title" validate:"required,task_name"`
+	Items []TestRequest `json:"items" validate:"dive"`
+}
+
+func TestValidateRequestData__AllFieldErrors(t *testing.T) {
+	body := strings.NewReader(`{"title": "  ", "items": [{"name": "a", "email": "b"}, {}]}`)
+
+	_, err := utils.ValidateRequestData(TestNestedRequest{}, body)
+	var apiErr api_errors.BadRequestError
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, []entities.InvalidParam{
+		{Name: "title", Reason: "must not be blank, longer than 255 characters or contain control characters"},
+		{Name: "items[1].name", Reason: "is required"},
+		{Name: "items[1].email", Reason: "is required"},
+	}, apiErr.InvalidParams)
+}
+
+func TestValidateRequestData__TrailingData(t *testing.T) {
+	body := strings.NewReader(`{"name": "John Doe", "email": "john"} {"name": "x"}`)
+
+	_, err := utils.ValidateRequestData(TestRequest{}, body)
+	var apiErr api_errors.BadRequestError
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, "Request body must contain a single JSON object", apiErr.Detail)
+}
+
+func TestValidateRequestData__StrictDecoding(t *testing.T) {
+	json_body := `{"name": "John Doe", "email": "john", "age": 30}`
+
+	_, err := utils.ValidateRequestData(TestRequest{}, strings.NewReader(json_body))
+	assert.NoError(t, err)
+
+	utils.SetStrictDecoding(true)
+	defer utils.SetStrictDecoding(false)
+
+	_, err = utils.ValidateRequestData(TestRequest{}, strings.NewReader(json_body))
+	var apiErr api_errors.BadRequestError
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, []entities.InvalidParam{{Name: "age", Reason: "is not allowed"}}, apiErr.InvalidParams)
+}
+
+func TestValidateRequestData__BodyLimit(t *testing.T) {
+	router := mux.NewRouter()
+	router.Use(api.BodyLimitMiddleware(32))
+	router.HandleFunc("/echo", func(w http.ResponseWriter, r *http.Request) {
+		if _, err := utils.ValidateRequestData(TestRequest{}, r.Body); err != nil {
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+			var apiErr api_errors.PayloadTooLargeError
+			assert.True(t, errors.As(err, &apiErr))
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}).Methods("POST")
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("POST", "/echo", strings.NewReader(`{"name": "a", "email": "b"}`)))
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	rr = httptest.NewRecorder()
+	long_body := `{"name": "` + strings.Repeat("a", 64) + `", "email": "b"}`
+	router.ServeHTTP(rr, httptest.NewRequest("POST", "/echo", strings.NewReader(long_body)))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rr.Code)
+}